@@ -0,0 +1,178 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Audited actions
+const (
+	ActionPaymentCreate       = "payment.create"
+	ActionPaymentVerify       = "payment.verify"
+	ActionEscrowCreate        = "escrow.create"
+	ActionEscrowComplete      = "escrow.mark_completed"
+	ActionEscrowRelease       = "escrow.release"
+	ActionEscrowRefund        = "escrow.refund"
+	ActionPaymentRefund       = "payment.refund"
+	ActionPayout              = "payout.create"
+	ActionRoleGrant           = "user.role_grant"
+	ActionPayoutDetailsChange = "user.payout_details_change"
+	ActionWalletChange        = "user.wallet_change"
+	ActionPasswordChange      = "user.password_change"
+	ActionPasswordReset       = "user.password_reset"
+	ActionTwoFactorEnable     = "user.2fa_enable"
+	ActionTwoFactorDisable    = "user.2fa_disable"
+	ActionAdminAuditQuery     = "admin.audit_query"
+	ActionAdminAuditVerify    = "admin.audit_verify"
+	ActionDisputeOpen         = "dispute.open"
+	ActionDisputeAssign       = "dispute.assign"
+	ActionDisputeRuling       = "dispute.ruling"
+	ActionSkillCreate         = "skill.create"
+	ActionSkillUpdate         = "skill.update"
+	ActionSkillDelete         = "skill.delete"
+)
+
+// Target types
+const (
+	TargetPayment    = "payment"
+	TargetAssignment = "assignment"
+	TargetUser       = "user"
+	TargetAuditLog   = "audit_log"
+	TargetDispute    = "dispute"
+	TargetSkill      = "skill"
+)
+
+// appendRetries bounds how often Record retries when another writer took
+// the next sequence number first.
+const appendRetries = 10
+
+// Event describes an action to record. Before and After are marshalled to
+// JSON and must not contain secrets.
+type Event struct {
+	Action     string
+	ActorID    primitive.ObjectID // zero for system actions
+	ActorRole  string
+	TargetType string
+	TargetID   string
+	Before     interface{}
+	After      interface{}
+	IP         string
+	UserAgent  string
+}
+
+func collection() *mongo.Collection {
+	return config.DB.Collection("audit_log")
+}
+
+// EnsureIndexes creates the unique sequence index that keeps the chain
+// linear, plus indexes for the admin query filters.
+func EnsureIndexes(ctx context.Context) error {
+	_, err := collection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "seq", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "action", Value: 1}, {Key: "seq", Value: -1}}},
+		{Keys: bson.D{{Key: "actorId", Value: 1}, {Key: "seq", Value: -1}}},
+		{Keys: bson.D{{Key: "targetType", Value: 1}, {Key: "targetId", Value: 1}, {Key: "seq", Value: -1}}},
+	})
+	return err
+}
+
+// Record appends an entry to the end of the chain. Concurrent writers race
+// for the next sequence number; the unique index lets exactly one win and
+// the others retry against the new head.
+func Record(ctx context.Context, e Event) error {
+	before, err := marshalSnapshot(e.Before)
+	if err != nil {
+		return err
+	}
+	after, err := marshalSnapshot(e.After)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; attempt < appendRetries; attempt++ {
+		var head models.AuditEntry
+		err := collection().FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.D{{Key: "seq", Value: -1}})).Decode(&head)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+
+		entry := models.AuditEntry{
+			ID:         primitive.NewObjectID(),
+			Seq:        head.Seq + 1,
+			Action:     e.Action,
+			ActorID:    e.ActorID,
+			ActorRole:  e.ActorRole,
+			TargetType: e.TargetType,
+			TargetID:   e.TargetID,
+			Before:     before,
+			After:      after,
+			IP:         e.IP,
+			UserAgent:  e.UserAgent,
+			// MongoDB stores milliseconds; truncate so the hash survives a round trip
+			CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+			PrevHash:  head.Hash,
+		}
+		entry.Hash = computeHash(entry)
+
+		_, err = collection().InsertOne(ctx, entry)
+		if err == nil {
+			return nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+	return fmt.Errorf("audit: gave up appending %s after %d attempts", e.Action, appendRetries)
+}
+
+// computeHash hashes the entry's fields in a fixed order together with the
+// previous entry's hash.
+func computeHash(e models.AuditEntry) string {
+	actor := ""
+	if !e.ActorID.IsZero() {
+		actor = e.ActorID.Hex()
+	}
+	payload, _ := json.Marshal(struct {
+		Seq        int64           `json:"seq"`
+		Action     string          `json:"action"`
+		ActorID    string          `json:"actorId"`
+		ActorRole  string          `json:"actorRole"`
+		TargetType string          `json:"targetType"`
+		TargetID   string          `json:"targetId"`
+		Before     json.RawMessage `json:"before"`
+		After      json.RawMessage `json:"after"`
+		IP         string          `json:"ip"`
+		UserAgent  string          `json:"userAgent"`
+		CreatedAt  string          `json:"createdAt"`
+		PrevHash   string          `json:"prevHash"`
+	}{
+		e.Seq, e.Action, actor, e.ActorRole, e.TargetType, e.TargetID,
+		e.Before, e.After, e.IP, e.UserAgent,
+		e.CreatedAt.UTC().Format(time.RFC3339Nano), e.PrevHash,
+	})
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+func marshalSnapshot(v interface{}) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("audit: cannot marshal snapshot: %w", err)
+	}
+	return b, nil
+}
@@ -0,0 +1,159 @@
+// Command trainranker fits the learned solver ranker from logged match
+// impressions and their outcomes, and writes the model the API loads from
+// MATCH_MODEL_PATH.
+//
+//	go run ./cmd/trainranker -out ranker-model.json
+//	go run ./cmd/trainranker -export pairs.jsonl -objective pairwise
+//	go run ./cmd/trainranker -data pairs.jsonl -out ranker-model.json
+//
+// Without -data it reads from the MongoDB at MONGO_URI. A share of the
+// assignments is held out to compare the model with the heuristic weights.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/matching"
+)
+
+func main() {
+	var (
+		since        = flag.Duration("since", 90*24*time.Hour, "train on impressions logged within this long")
+		data         = flag.String("data", "", "read shown pairs from this JSON Lines file instead of MongoDB")
+		export       = flag.String("export", "", "also write the shown pairs to this JSON Lines file")
+		out          = flag.String("out", "ranker-model.json", "where to write the model; empty to skip")
+		objective    = flag.String("objective", matching.ObjectivePointwise, "pointwise or pairwise")
+		minRelevance = flag.Int("min-relevance", 2, "pointwise: grade that counts as a conversion (1 bid, 2 accepted, 3 on time)")
+		epochs       = flag.Int("epochs", 500, "gradient descent epochs")
+		rate         = flag.Float64("lr", 0.5, "learning rate")
+		l2           = flag.Float64("l2", 0.001, "L2 weight decay")
+		holdout      = flag.Int("holdout", 20, "percent of assignments held out for evaluation")
+	)
+	flag.Parse()
+	if *holdout < 0 || *holdout >= 100 {
+		log.Fatal("-holdout must be 0-99")
+	}
+
+	var pairs []matching.ShownPair
+	var err error
+	if *data != "" {
+		pairs, err = readPairs(*data)
+	} else {
+		pairs, err = loadPairs(time.Now().Add(-*since))
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("loaded %d shown pairs", len(pairs))
+
+	if *export != "" {
+		if err := writePairs(*export, pairs); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("exported to %s", *export)
+	}
+
+	var train, test []matching.ShownPair
+	for _, p := range pairs {
+		if matching.InHoldout(p.AssignmentID, *holdout) {
+			test = append(test, p)
+		} else {
+			train = append(train, p)
+		}
+	}
+
+	model, err := matching.Train(train, matching.TrainOptions{
+		Objective:    *objective,
+		MinRelevance: *minRelevance,
+		Epochs:       *epochs,
+		LearningRate: *rate,
+		L2:           *l2,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("fitted %s on %d examples: bias %.4f, weights %v", model.Version, model.Samples, model.Bias, model.Weights)
+
+	weights, err := matching.ParseWeights(os.Getenv("MATCH_WEIGHTS"), matching.DefaultWeights)
+	if err != nil {
+		weights = matching.DefaultWeights
+	}
+	log.Printf("holdout pairwise accuracy: heuristic %s, learned %s",
+		percent(matching.PairwiseAccuracy(test, matching.HeuristicScore(weights))),
+		percent(matching.PairwiseAccuracy(test, model.Logit)))
+
+	if *out != "" {
+		if err := model.Save(*out); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("wrote %s; set MATCH_MODEL_PATH to serve it", *out)
+	}
+}
+
+func loadPairs(since time.Time) ([]matching.ShownPair, error) {
+	config.ConnectDB()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	var pairs []matching.ShownPair
+	err := matching.EachShownPair(ctx, since, func(p matching.ShownPair) error {
+		pairs = append(pairs, p)
+		return nil
+	})
+	return pairs, err
+}
+
+func readPairs(path string) ([]matching.ShownPair, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var pairs []matching.ShownPair
+	dec := json.NewDecoder(bufio.NewReader(f))
+	for dec.More() {
+		var p matching.ShownPair
+		if err := dec.Decode(&p); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		pairs = append(pairs, p)
+	}
+	return pairs, nil
+}
+
+func writePairs(path string, pairs []matching.ShownPair) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, p := range pairs {
+		if err := enc.Encode(p); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func percent(v float64) string {
+	if math.IsNaN(v) {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.1f%%", v*100)
+}
@@ -2,18 +2,47 @@ package config
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 var DB *mongo.Database
 
+// JWTSecret is the HMAC key used to sign and verify access tokens.
+var JWTSecret []byte
+
+// Token lifetimes, overridable via ACCESS_TOKEN_TTL / REFRESH_TOKEN_TTL
+// (Go duration strings such as "15m" or "720h").
+var (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// EmailCollation makes email lookups and the users.email unique index
+// case-insensitive. Queries on email must pass it to use the index.
+var EmailCollation = &options.Collation{Locale: "en", Strength: 2}
+
+// FieldEncryptionKeys holds the AES-256 keys used to encrypt sensitive user
+// fields at rest, by key ID. New values are always sealed with
+// FieldEncryptionKeyID; older keys stay loaded so existing values can be
+// decrypted until the startup migration re-encrypts them.
+var (
+	FieldEncryptionKeys  map[string][]byte
+	FieldEncryptionKeyID string
+)
+
+// Redis is nil unless REDIS_URL is set.
+var Redis *redis.Client
+
 func ConnectDB() {
 	err := godotenv.Load()
 	if err != nil {
@@ -42,3 +71,92 @@ func ConnectDB() {
 	DB = client.Database("startup-2025")
 	fmt.Println("✅ Connected to MongoDB successfully")
 }
+
+// LoadAuthConfig reads the JWT signing key from the environment.
+func LoadAuthConfig() {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		log.Fatal("JWT_SECRET not set in environment")
+	}
+	JWTSecret = []byte(secret)
+
+	AccessTokenTTL = durationFromEnv("ACCESS_TOKEN_TTL", AccessTokenTTL)
+	RefreshTokenTTL = durationFromEnv("REFRESH_TOKEN_TTL", RefreshTokenTTL)
+}
+
+// LoadEncryptionConfig reads FIELD_ENCRYPTION_KEYS, a comma-separated list
+// of "<keyID>:<base64 32-byte key>" pairs, and FIELD_ENCRYPTION_KEY_ID, the
+// key used for new writes. The active key defaults to the first one listed.
+func LoadEncryptionConfig() {
+	raw := os.Getenv("FIELD_ENCRYPTION_KEYS")
+	if raw == "" {
+		log.Fatal("FIELD_ENCRYPTION_KEYS not set in environment")
+	}
+
+	keys := make(map[string][]byte)
+	var first string
+	for _, entry := range strings.Split(raw, ",") {
+		id, encoded, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || id == "" {
+			log.Fatalf("Invalid FIELD_ENCRYPTION_KEYS entry %q, want <keyID>:<base64 key>", entry)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != 32 {
+			log.Fatalf("FIELD_ENCRYPTION_KEYS key %q must be 32 bytes, base64 encoded", id)
+		}
+		if first == "" {
+			first = id
+		}
+		keys[id] = key
+	}
+
+	active := os.Getenv("FIELD_ENCRYPTION_KEY_ID")
+	if active == "" {
+		active = first
+	}
+	if _, ok := keys[active]; !ok {
+		log.Fatalf("FIELD_ENCRYPTION_KEY_ID %q is not in FIELD_ENCRYPTION_KEYS", active)
+	}
+
+	FieldEncryptionKeys = keys
+	FieldEncryptionKeyID = active
+}
+
+// ConnectRedis connects to REDIS_URL when it is set. Redis is optional:
+// features that can use it fall back to MongoDB or memory when it is absent.
+func ConnectRedis() {
+	redisURL := os.Getenv("REDIS_URL")
+	if redisURL == "" {
+		log.Println("REDIS_URL not set, Redis-backed features disabled")
+		return
+	}
+
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		log.Fatal("Invalid REDIS_URL: ", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := redis.NewClient(opts)
+	if err := client.Ping(ctx).Err(); err != nil {
+		log.Fatal("Failed to connect to Redis: ", err)
+	}
+
+	Redis = client
+	fmt.Println("✅ Connected to Redis successfully")
+}
+
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Printf("Invalid %s %q, using %s", key, v, fallback)
+		return fallback
+	}
+	return d
+}
@@ -0,0 +1,290 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/audit"
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/mailer"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/Aashishvatwani/homeworld/sessions"
+	"github.com/Aashishvatwani/homeworld/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	verifyEmailTokenTTL   = 48 * time.Hour
+	passwordResetTokenTTL = 1 * time.Hour
+	minPasswordLength     = 8
+)
+
+var errTokenInvalid = errors.New("token is invalid, expired or already used")
+
+// POST /api/auth/verify-email/request - (Re)send the verification email
+// Body: { "email": "..." }. Always responds 200 so addresses cannot be probed.
+func RequestEmailVerification(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "email is required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := findUserByEmail(ctx, req.Email)
+	if err == nil && !user.EmailVerified {
+		if err := sendVerificationEmail(ctx, user); err != nil {
+			fmt.Printf("[RequestEmailVerification] failed to send to %s: %v\n", user.Email, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "If the account exists and is unverified, a verification email has been sent"})
+}
+
+// POST /api/auth/verify-email/confirm - Consume a verification token
+// Body: { "token": "..." }
+func ConfirmEmailVerification(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, err := consumeUserToken(ctx, req.Token, models.TokenPurposeVerifyEmail)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, err = config.DB.Collection("users").UpdateOne(ctx,
+		bson.M{"_id": userID},
+		bson.M{"$set": bson.M{"emailVerified": true, "emailVerifiedAt": time.Now()}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+}
+
+// POST /api/auth/password/forgot - Email a password reset link
+// Body: { "email": "..." }. Always responds 200 so addresses cannot be probed.
+func ForgotPassword(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "email is required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if user, err := findUserByEmail(ctx, req.Email); err == nil {
+		token, err := issueUserToken(ctx, user.ID, models.TokenPurposePasswordReset, passwordResetTokenTTL)
+		if err != nil {
+			fmt.Printf("[ForgotPassword] failed to issue token: %v\n", err)
+		} else {
+			err = mailer.Send(ctx, mailer.Message{
+				To:      user.Email,
+				Subject: "Reset your password",
+				Body: "Hi " + user.Name + ",\n\n" +
+					"Someone asked to reset the password for this account. If it was you, open the link below within 1 hour:\n\n" +
+					frontendLink("/reset-password", token) + "\n\n" +
+					"If you did not ask for this, you can ignore this email.\n",
+			})
+			if err != nil {
+				fmt.Printf("[ForgotPassword] failed to send to %s: %v\n", user.Email, err)
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "If the account exists, a password reset email has been sent"})
+}
+
+// POST /api/auth/password/reset - Set a new password using a reset token
+// Body: { "token": "...", "new_password": "..." }
+func ResetPassword(c *gin.Context) {
+	var req struct {
+		Token       string `json:"token" binding:"required"`
+		NewPassword string `json:"new_password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token and new_password are required"})
+		return
+	}
+	if len(req.NewPassword) < minPasswordLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("password must be at least %d characters", minPasswordLength)})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	userID, err := consumeUserToken(ctx, req.Token, models.TokenPurposePasswordReset)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
+	// Receiving the reset email also proves ownership of the address
+	_, err = config.DB.Collection("users").UpdateOne(ctx,
+		bson.M{"_id": userID},
+		bson.M{"$set": bson.M{"password": hashedPassword, "emailVerified": true}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	// Sign out every device that may have been using the old password
+	if _, err := sessions.RevokeAll(ctx, userID, sessions.ReasonLogoutAll); err != nil {
+		fmt.Printf("[ResetPassword] failed to revoke sessions for %s: %v\n", userID.Hex(), err)
+	}
+	recordAudit(c, audit.ActionPasswordReset, audit.TargetUser, userID.Hex(), nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset. Please log in again."})
+}
+
+// sendVerificationEmail issues a fresh verification token and emails it.
+func sendVerificationEmail(ctx context.Context, user models.User) error {
+	token, err := issueUserToken(ctx, user.ID, models.TokenPurposeVerifyEmail, verifyEmailTokenTTL)
+	if err != nil {
+		return err
+	}
+	return mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: "Hi " + user.Name + ",\n\n" +
+			"Please confirm your email address by opening the link below:\n\n" +
+			frontendLink("/verify-email", token) + "\n\n" +
+			"The link expires in 48 hours.\n",
+	})
+}
+
+// issueUserToken stores a hashed single-use token and returns the raw value.
+// Any earlier unused tokens for the same purpose are invalidated.
+func issueUserToken(ctx context.Context, userID primitive.ObjectID, purpose string, ttl time.Duration) (string, error) {
+	return storeUserToken(ctx, models.UserToken{UserID: userID, Purpose: purpose}, ttl)
+}
+
+// storeUserToken fills in the hash and timestamps of token and saves it.
+func storeUserToken(ctx context.Context, token models.UserToken, ttl time.Duration) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	raw := base64.RawURLEncoding.EncodeToString(b)
+
+	now := time.Now()
+	tokenCollection := config.DB.Collection("user_tokens")
+	_, err := tokenCollection.UpdateMany(ctx,
+		bson.M{"userId": token.UserID, "purpose": token.Purpose, "usedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"usedAt": now}},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	token.ID = primitive.NewObjectID()
+	token.TokenHash = hashUserToken(raw)
+	token.CreatedAt = now
+	token.ExpiresAt = now.Add(ttl)
+	_, err = tokenCollection.InsertOne(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+// findUserToken returns a usable token without consuming it.
+func findUserToken(ctx context.Context, raw, purpose string) (models.UserToken, error) {
+	var token models.UserToken
+	err := config.DB.Collection("user_tokens").FindOne(ctx, bson.M{
+		"tokenHash": hashUserToken(raw),
+		"purpose":   purpose,
+		"usedAt":    bson.M{"$exists": false},
+		"expiresAt": bson.M{"$gt": time.Now()},
+	}).Decode(&token)
+	if err == mongo.ErrNoDocuments {
+		return token, errTokenInvalid
+	}
+	return token, err
+}
+
+// consumeUserToken atomically marks a token used and returns its owner.
+func consumeUserToken(ctx context.Context, raw, purpose string) (primitive.ObjectID, error) {
+	now := time.Now()
+	var token models.UserToken
+	err := config.DB.Collection("user_tokens").FindOneAndUpdate(ctx,
+		bson.M{
+			"tokenHash": hashUserToken(raw),
+			"purpose":   purpose,
+			"usedAt":    bson.M{"$exists": false},
+			"expiresAt": bson.M{"$gt": now},
+		},
+		bson.M{"$set": bson.M{"usedAt": now}},
+	).Decode(&token)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return primitive.NilObjectID, errTokenInvalid
+		}
+		return primitive.NilObjectID, err
+	}
+	return token.UserID, nil
+}
+
+func hashUserToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// findUserByEmail looks a user up case-insensitively.
+func findUserByEmail(ctx context.Context, email string) (models.User, error) {
+	var user models.User
+	err := config.DB.Collection("users").FindOne(ctx,
+		bson.M{"email": strings.TrimSpace(email)},
+		options.FindOne().SetCollation(config.EmailCollation),
+	).Decode(&user)
+	return user, err
+}
+
+// frontendLink builds a link into the web app, e.g. /reset-password?token=...
+func frontendLink(path, token string) string {
+	base := os.Getenv("APP_BASE_URL")
+	if base == "" {
+		base = "http://localhost:3000"
+	}
+	return strings.TrimRight(base, "/") + path + "?token=" + url.QueryEscape(token)
+}
@@ -2,23 +2,26 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/Aashishvatwani/homeworld/audit"
 	"github.com/Aashishvatwani/homeworld/config"
 	"github.com/Aashishvatwani/homeworld/models"
 	"github.com/Aashishvatwani/homeworld/utils"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // POST /api/assignments/complete
-// Body: { "assignmentId": "<hex>", "completedBy": "<userId - optional>" }
+// Body: { "assignmentId": "<hex>" } - caller must be the buyer who owns the assignment.
+// Equivalent to accepting the latest deliverable.
 func AssignmentCompleted(c *gin.Context) {
 	var req struct {
 		AssignmentID string `json:"assignmentId" binding:"required"`
-		CompletedBy  string `json:"completedBy"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -35,27 +38,118 @@ func AssignmentCompleted(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	assignmentCollection := config.DB.Collection("assignments")
-	paymentCollection := config.DB.Collection("payments")
-
 	// Load assignment
-	var assignment models.Assignment
-	if err := assignmentCollection.FindOne(ctx, bson.M{"_id": assignmentObjID}).Decode(&assignment); err != nil {
+	assignment, err := loadAssignment(ctx, assignmentObjID)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "assignment not found"})
 		return
 	}
 
-	if assignment.Status == "completed" || assignment.Status == "Completed" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "assignment already completed"})
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+	if !isAssignmentOwner(callerID, assignment) {
+		forbidden(c, "only the assignment owner can mark it completed")
+		return
+	}
+
+	latest, err := latestDeliverable(ctx, assignmentObjID)
+	if err != nil && err != mongo.ErrNoDocuments {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load deliverables"})
 		return
 	}
+	var pending *models.Deliverable
+	if err == nil && latest.Status == models.DeliverableSubmitted {
+		pending = &latest
+	}
+	acceptDelivery(ctx, c, &assignment, pending, callerID)
+}
+
+// acceptDelivery accepts the submitted deliverable, if any, then releases the
+// assignment's funds and marks it completed. The deliverable goes back to
+// submitted if the release fails so the buyer can retry. Milestone
+// assignments release the deliverable's milestone instead.
+func acceptDelivery(ctx context.Context, c *gin.Context, assignment *models.Assignment, deliverable *models.Deliverable, callerID primitive.ObjectID) {
+	if len(assignment.Milestones) > 0 {
+		if escrowFrozen(ctx, c, assignment.ID) {
+			return
+		}
+		releaseMilestone(ctx, c, assignment, deliverable, callerID)
+		return
+	}
+
+	// Check before any funds move; the transition itself is applied after release
+	if !models.CanTransitionAssignment(assignment.Status, models.AssignmentCompleted) {
+		respondTransitionError(c, &models.IllegalTransitionError{From: assignment.Status, To: models.AssignmentCompleted})
+		return
+	}
+	if escrowFrozen(ctx, c, assignment.ID) {
+		return
+	}
+
+	if deliverable != nil {
+		deliverables := deliverableCollection()
+		result, err := deliverables.UpdateOne(ctx,
+			bson.M{"_id": deliverable.ID, "status": models.DeliverableSubmitted},
+			bson.M{"$set": bson.M{"status": models.DeliverableAccepted, "reviewedAt": time.Now()}},
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to accept deliverable"})
+			return
+		}
+		if result.MatchedCount == 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "deliverable has already been reviewed"})
+			return
+		}
+		if !releaseAssignmentFunds(ctx, c, assignment, callerID) {
+			_, err := deliverables.UpdateOne(ctx,
+				bson.M{"_id": deliverable.ID},
+				bson.M{"$set": bson.M{"status": models.DeliverableSubmitted}, "$unset": bson.M{"reviewedAt": ""}},
+			)
+			if err != nil {
+				fmt.Printf("[acceptDelivery] failed to reopen deliverable %s: %v\n", deliverable.ID.Hex(), err)
+			}
+		}
+		return
+	}
+	releaseAssignmentFunds(ctx, c, assignment, callerID)
+}
+
+// releaseAssignmentFunds pays the solver from the assignment's paid payment,
+// by bank payout or on-chain escrow release, and marks the assignment
+// completed. The payment is claimed as "releasing" first, as refundPayment
+// does, so it cannot be released twice or refunded while the payout is in
+// flight; it goes back to "paid" if no money moved. It writes the response
+// and reports whether it succeeded.
+func releaseAssignmentFunds(ctx context.Context, c *gin.Context, assignment *models.Assignment, callerID primitive.ObjectID) bool {
+	assignmentObjID := assignment.ID
+	paymentCollection := config.DB.Collection("payments")
 
 	// Find a paid payment for this assignment
 	var payment models.Payment
-	err = paymentCollection.FindOne(ctx, bson.M{"assignmentId": assignmentObjID, "status": "paid"}).Decode(&payment)
+	err := paymentCollection.FindOne(ctx, bson.M{"assignmentId": assignmentObjID, "status": "paid"}).Decode(&payment)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "no paid payment found for assignment; cannot release funds"})
-		return
+		return false
+	}
+
+	claim, err := paymentCollection.UpdateOne(ctx,
+		bson.M{"_id": payment.ID, "status": "paid"},
+		bson.M{"$set": bson.M{"status": "releasing"}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to claim payment for release"})
+		return false
+	}
+	if claim.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "payment is already being released or refunded"})
+		return false
+	}
+	reopen := func() {
+		if _, err := paymentCollection.UpdateOne(ctx, bson.M{"_id": payment.ID}, bson.M{"$set": bson.M{"status": "paid"}}); err != nil {
+			fmt.Printf("[releaseAssignmentFunds] failed to reopen payment %s: %v\n", payment.ID.Hex(), err)
+		}
 	}
 
 	// If payment was made via bank (Razorpay), payout to solver via payouts; otherwise use on-chain release
@@ -66,20 +160,43 @@ func AssignmentCompleted(c *gin.Context) {
 	_ = userCollection.FindOne(ctx, bson.M{"_id": payment.SolverID}).Decode(&solver)
 
 	if payment.PaymentMethod == "bank" || payment.PaymentMethod == "razorpay" {
+		solverPayout, err := utils.DecryptPayout(solver.ID, solver.Payout)
+		if err != nil {
+			reopen()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read solver payout details"})
+			return false
+		}
+		if !solverPayout.IsComplete() {
+			reopen()
+			c.JSON(http.StatusConflict, gin.H{"error": "solver has not set up payout details"})
+			return false
+		}
+
 		// Perform payout to solver using Razorpay Payouts (mocked util)
 		payoutInfo := map[string]string{
-			"accountHolderName": solver.Payout.AccountHolderName,
-			"accountNumber":     solver.Payout.AccountNumber,
-			"ifsc":              solver.Payout.IFSC,
-			"bankName":          solver.Payout.BankName,
-			"upi":               solver.Payout.UPI,
+			"accountHolderName": solverPayout.AccountHolderName,
+			"accountNumber":     solverPayout.AccountNumber,
+			"ifsc":              solverPayout.IFSC,
+			"bankName":          solverPayout.BankName,
+			"upi":               solverPayout.UPI,
 		}
 
 		payoutID, err := utils.CreateRazorpayPayout(payment.SolverAmount, "INR", payoutInfo)
 		if err != nil {
+			reopen()
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create payout: " + err.Error()})
-			return
+			return false
 		}
+		recordAudit(c, audit.ActionPayout, audit.TargetPayment, payment.ID.Hex(),
+			gin.H{"status": payment.Status},
+			gin.H{
+				"status":      "released",
+				"payoutId":    payoutID,
+				"amount":      payment.SolverAmount,
+				"solverId":    payment.SolverID.Hex(),
+				"destination": solverPayout.Masked(),
+			},
+		)
 
 		// Update payment: mark released and attach payout id
 		updatePayment := bson.M{"$set": bson.M{
@@ -89,15 +206,16 @@ func AssignmentCompleted(c *gin.Context) {
 			"releasedAt":       time.Now(),
 		}}
 		if _, err := paymentCollection.UpdateOne(ctx, bson.M{"_id": payment.ID}, updatePayment); err != nil {
+			// The payout has been sent, so the payment stays claimed; the audit entry records it
+			fmt.Printf("[releaseAssignmentFunds] payout %s sent but payment %s not updated: %v\n", payoutID, payment.ID.Hex(), err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update payment record"})
-			return
+			return false
 		}
 
 		// Update assignment status to completed
-		_, err = assignmentCollection.UpdateOne(ctx, bson.M{"_id": assignmentObjID}, bson.M{"$set": bson.M{"status": "completed"}})
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update assignment status"})
-			return
+		if err := transitionAssignment(ctx, assignment, models.AssignmentCompleted, callerID, "payout "+payoutID+" initiated", nil); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update assignment status: " + err.Error()})
+			return false
 		}
 
 		// Send notifications
@@ -110,7 +228,7 @@ func AssignmentCompleted(c *gin.Context) {
 			"payment_id":    payment.ID.Hex(),
 			"payout_id":     payoutID,
 		})
-		return
+		return true
 	}
 
 	// Otherwise assume on-chain escrow release
@@ -126,9 +244,11 @@ func AssignmentCompleted(c *gin.Context) {
 	// Step 1: Mark assignment as completed on-chain (required before release)
 	_, err = utils.MarkAssignmentComplete(assignmentObjID.Hex(), solverAddr)
 	if err != nil {
+		reopen()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to mark assignment completed on-chain: " + err.Error()})
-		return
+		return false
 	}
+	recordAudit(c, audit.ActionEscrowComplete, audit.TargetAssignment, assignmentObjID.Hex(), nil, gin.H{"solver": solverAddr})
 
 	// Wait a moment for the transaction to be mined (optional but safer)
 	time.Sleep(3 * time.Second)
@@ -136,9 +256,14 @@ func AssignmentCompleted(c *gin.Context) {
 	// Step 2: Release payment to solver and platform
 	txHash, err := utils.ReleaseEscrowPayment(assignmentObjID.Hex(), buyerAddr, solverAddr)
 	if err != nil {
+		reopen()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to release escrow: " + err.Error()})
-		return
+		return false
 	}
+	recordAudit(c, audit.ActionEscrowRelease, audit.TargetPayment, payment.ID.Hex(),
+		gin.H{"status": payment.Status},
+		gin.H{"status": "released", "assignmentId": assignmentObjID.Hex(), "buyer": buyerAddr, "solver": solverAddr, "txHash": txHash},
+	)
 
 	// Update payment: mark released and attach transaction hash
 	updatePayment := bson.M{"$set": bson.M{
@@ -147,15 +272,16 @@ func AssignmentCompleted(c *gin.Context) {
 		"paidAt":          time.Now(),
 	}}
 	if _, err := paymentCollection.UpdateOne(ctx, bson.M{"_id": payment.ID}, updatePayment); err != nil {
+		// The escrow has been released, so the payment stays claimed; the audit entry records it
+		fmt.Printf("[releaseAssignmentFunds] escrow released in %s but payment %s not updated: %v\n", txHash, payment.ID.Hex(), err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update payment record"})
-		return
+		return false
 	}
 
 	// Update assignment status to completed
-	_, err = assignmentCollection.UpdateOne(ctx, bson.M{"_id": assignmentObjID}, bson.M{"$set": bson.M{"status": "completed"}})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update assignment status"})
-		return
+	if err := transitionAssignment(ctx, assignment, models.AssignmentCompleted, callerID, "escrow released in "+txHash, nil); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update assignment status: " + err.Error()})
+		return false
 	}
 
 	// Send notifications to buyer and solver
@@ -169,4 +295,5 @@ func AssignmentCompleted(c *gin.Context) {
 		"payment_id":       payment.ID.Hex(),
 		"transaction_hash": txHash,
 	})
+	return true
 }
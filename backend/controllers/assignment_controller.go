@@ -6,8 +6,11 @@ import (
 	"time"
 
 	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/middleware"
 	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/Aashishvatwani/homeworld/skills"
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func CreateAssignment(c *gin.Context) {
@@ -17,7 +20,10 @@ func CreateAssignment(c *gin.Context) {
 		return
 	}
 	assignment.CreatedAt = time.Now()
-	assignment.Status = "Pending"
+	assignment.Skills = skills.Canonicalize(assignment.Skills)
+	assignment.SolverID = primitive.NilObjectID
+	callerID, _ := middleware.CurrentUserID(c)
+	assignment.InitStatus(models.AssignmentPosted, callerID)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
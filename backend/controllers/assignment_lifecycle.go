@@ -0,0 +1,203 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// errAssignmentChanged is returned when another request changed the
+// assignment's status between loading and updating it.
+var errAssignmentChanged = errors.New("assignment status changed concurrently; reload and retry")
+
+// Status changes the owner and accepted solver may request directly.
+// Acceptance and completion move money, and delivery and revisions carry a
+// deliverable, so those have their own endpoints.
+var (
+	ownerStatusChanges = map[string]bool{
+		models.AssignmentPosted:    true,
+		models.AssignmentCancelled: true,
+	}
+	solverStatusChanges = map[string]bool{
+		models.AssignmentInProgress: true,
+	}
+)
+
+// transitionAssignment moves the assignment to status to and appends the
+// change to its history. The update only applies while the assignment is
+// still in the state it was loaded in, so two concurrent requests cannot
+// both act on the same state. Fields in extra are set in the same update.
+// Milestones still open when the assignment ends are closed.
+func transitionAssignment(ctx context.Context, assignment *models.Assignment, to string, actorID primitive.ObjectID, reason string, extra bson.M) error {
+	change, err := assignment.NewStatusChange(to, actorID, reason)
+	if err != nil {
+		return err
+	}
+
+	set := bson.M{"status": to, "statusUpdatedAt": change.At}
+	for k, v := range extra {
+		set[k] = v
+	}
+	result, err := config.DB.Collection("assignments").UpdateOne(ctx,
+		bson.M{"_id": assignment.ID, "status": assignment.Status},
+		bson.M{"$set": set, "$push": bson.M{"statusHistory": change}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errAssignmentChanged
+	}
+
+	assignment.Status = to
+	assignment.StatusUpdatedAt = change.At
+	assignment.StatusHistory = append(assignment.StatusHistory, change)
+	if assignment.IsTerminal() && len(assignment.Milestones) > 0 {
+		closeOpenMilestones(ctx, assignment, change.At)
+	}
+	recordTransitionOutcome(ctx, *assignment, to, extra, change.At)
+	if assignment.AutoAssign != nil && assignment.AutoAssign.Active && to != models.AssignmentPosted && to != models.AssignmentMatched {
+		reason := models.AutoAssignStopped
+		if to == models.AssignmentAccepted {
+			reason = models.AutoAssignClaimed
+		}
+		if err := endAutoAssign(ctx, assignment, reason, change.At); err != nil {
+			fmt.Printf("[transitionAssignment] failed to end auto-assign for %s: %v\n", assignment.ID.Hex(), err)
+		}
+	}
+	return nil
+}
+
+func loadAssignment(ctx context.Context, assignmentID primitive.ObjectID) (models.Assignment, error) {
+	var assignment models.Assignment
+	err := config.DB.Collection("assignments").FindOne(ctx, bson.M{"_id": assignmentID}).Decode(&assignment)
+	return assignment, err
+}
+
+// respondTransitionError writes the response for a failed transitionAssignment.
+func respondTransitionError(c *gin.Context, err error) {
+	var illegal *models.IllegalTransitionError
+	switch {
+	case errors.As(err, &illegal):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   err.Error(),
+			"allowed": models.NextAssignmentStatuses(illegal.From),
+		})
+	case errors.Is(err, errAssignmentChanged):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update assignment status"})
+	}
+}
+
+// acceptAssignmentForPayment moves the paid-for assignment to accepted and
+// records the chosen solver. The payment has already been captured, so
+// failures are logged rather than returned to the client.
+func acceptAssignmentForPayment(ctx context.Context, payment models.Payment, actorID primitive.ObjectID) {
+	assignment, err := loadAssignment(ctx, payment.AssignmentID)
+	if err != nil {
+		fmt.Printf("[acceptAssignmentForPayment] assignment %s not found: %v\n", payment.AssignmentID.Hex(), err)
+		return
+	}
+	// Accepting a bid already moved it to accepted, and later milestones are
+	// funded while the work is already under way
+	if assignment.Status == models.AssignmentAccepted && assignment.SolverID == payment.SolverID {
+		return
+	}
+	if !payment.MilestoneID.IsZero() && assignment.SolverID == payment.SolverID {
+		return
+	}
+	err = transitionAssignment(ctx, &assignment, models.AssignmentAccepted, actorID, "payment "+payment.ID.Hex()+" confirmed",
+		bson.M{"solverId": payment.SolverID, "dueAt": solverDueAt(ctx, assignment, payment.SolverID)},
+	)
+	if err != nil {
+		fmt.Printf("[acceptAssignmentForPayment] failed to accept assignment %s: %v\n", assignment.ID.Hex(), err)
+	}
+}
+
+// PUT /api/assignments/:id/status - Move an assignment to a new state
+// Body: { "status": "in_progress", "reason": "optional note" }
+// The owner may post a draft or cancel before a solver is accepted; the
+// accepted solver may start work. Delivery goes through the deliverables endpoints.
+func UpdateAssignmentStatus(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment ID"})
+		return
+	}
+
+	var req struct {
+		Status string `json:"status" binding:"required"`
+		Reason string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status is required"})
+		return
+	}
+	if !models.IsAssignmentStatus(req.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown status " + req.Status})
+		return
+	}
+	if len(req.Reason) > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason must be at most 500 characters"})
+		return
+	}
+
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	assignment, err := loadAssignment(ctx, objID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+		return
+	}
+	if assignment.Status == models.AssignmentDisputed {
+		c.JSON(http.StatusConflict, gin.H{"error": "Assignment is in dispute; it moves on when an admin rules"})
+		return
+	}
+
+	switch {
+	case isAssignmentOwner(callerID, assignment):
+		if !ownerStatusChanges[req.Status] {
+			forbidden(c, "The assignment owner cannot move it to "+req.Status+" directly")
+			return
+		}
+		if req.Status == models.AssignmentCancelled && !assignment.SolverID.IsZero() {
+			c.JSON(http.StatusConflict, gin.H{"error": "A solver has already been accepted; use POST /api/assignments/:id/cancel instead"})
+			return
+		}
+	case isSelf(callerID, assignment.SolverID):
+		if !solverStatusChanges[req.Status] {
+			forbidden(c, "The solver cannot move the assignment to "+req.Status+" directly")
+			return
+		}
+	default:
+		forbidden(c, "Only the assignment owner or its accepted solver can change its status")
+		return
+	}
+
+	if err := transitionAssignment(ctx, &assignment, req.Status, callerID, req.Reason, nil); err != nil {
+		respondTransitionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Assignment status updated",
+		"status":     assignment.Status,
+		"next":       models.NextAssignmentStatuses(assignment.Status),
+		"changed_at": assignment.StatusUpdatedAt,
+	})
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestTransitionAssignment(t *testing.T) {
+	actor := primitive.NewObjectID()
+	loaded := func() models.Assignment {
+		return models.Assignment{ID: primitive.NewObjectID(), Status: models.AssignmentAccepted}
+	}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("moves from the loaded state", func(mt *mtest.T) {
+		config.DB = mt.Client.Database("test")
+		mt.AddMockResponses(updated(1))
+		a := loaded()
+		if err := transitionAssignment(context.Background(), &a, models.AssignmentInProgress, actor, "started", bson.M{"startedBy": actor}); err != nil {
+			mt.Fatal(err)
+		}
+
+		update := startedCommands(mt)[0].Lookup("updates").Array().Index(0).Value().Document()
+		if from := update.Lookup("q", "status").StringValue(); from != models.AssignmentAccepted {
+			mt.Fatalf("update matches status %q, want the loaded %q", from, models.AssignmentAccepted)
+		}
+		if to := update.Lookup("u", "$set", "status").StringValue(); to != models.AssignmentInProgress {
+			mt.Fatalf("update sets status %q, want %q", to, models.AssignmentInProgress)
+		}
+		if _, err := update.LookupErr("u", "$set", "startedBy"); err != nil {
+			mt.Fatalf("extra fields not set in the same update: %v", update)
+		}
+		if a.Status != models.AssignmentInProgress || len(a.StatusHistory) != 1 {
+			mt.Fatalf("assignment = %s with %d history entries, want in_progress with 1", a.Status, len(a.StatusHistory))
+		}
+	})
+
+	mt.Run("status changed underneath", func(mt *mtest.T) {
+		config.DB = mt.Client.Database("test")
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}))
+		a := loaded()
+		err := transitionAssignment(context.Background(), &a, models.AssignmentInProgress, actor, "started", nil)
+		if !errors.Is(err, errAssignmentChanged) {
+			mt.Fatalf("err = %v, want errAssignmentChanged", err)
+		}
+		if a.Status != models.AssignmentAccepted || len(a.StatusHistory) != 0 {
+			mt.Fatalf("assignment = %s with %d history entries, want it unchanged", a.Status, len(a.StatusHistory))
+		}
+	})
+
+	mt.Run("illegal move sends nothing", func(mt *mtest.T) {
+		config.DB = mt.Client.Database("test")
+		a := loaded()
+		var illegal *models.IllegalTransitionError
+		if err := transitionAssignment(context.Background(), &a, models.AssignmentCompleted, actor, "", nil); !errors.As(err, &illegal) {
+			mt.Fatalf("err = %v, want IllegalTransitionError", err)
+		}
+		if n := len(startedCommands(mt)); n != 0 {
+			mt.Fatalf("%d commands sent, want 0", n)
+		}
+	})
+}
@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"math"
 	"net/http"
-	"sort"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -13,15 +13,16 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
 	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/matching"
 	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/Aashishvatwani/homeworld/skills"
+	"github.com/Aashishvatwani/homeworld/utils"
 )
 
-type SolverRank struct {
-	Solver models.User `json:"solver"`
-	Score  float64     `json:"score"`
-}
-
 // POST /api/assignment/create
+// Pass "status": "draft" to save without posting; anything else posts it.
+// Optional "bidding": { "mode": "reverse_auction", "closesAt": "<RFC 3339>" }
+// Optional "milestones": [{ "title", "description", "amount", "dueAt" }], as for PUT /api/assignments/:id/milestones
 func CreateAssignmentRoute(c *gin.Context) {
 	var assignment models.Assignment
 	if err := c.ShouldBindJSON(&assignment); err != nil {
@@ -29,7 +30,43 @@ func CreateAssignmentRoute(c *gin.Context) {
 		return
 	}
 
-	assignment.Status = "posted"
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	if assignment.Location.IsZero() {
+		assignment.Location = nil
+	} else if !utils.ValidateCoordinates(assignment.Location.Latitude(), assignment.Location.Longitude()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "location must have latitude in [-90, 90] and longitude in [-180, 180]"})
+		return
+	}
+	if err := validateBiddingSettings(assignment.Bidding); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	milestones, err := buildMilestones(milestoneSpecs(assignment.Milestones), assignment.Deadline)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	assignment.Milestones = milestones
+	assignment.Skills = skills.Canonicalize(assignment.Skills)
+	if total := assignment.MilestoneTotal(); len(milestones) > 0 && assignment.Price > 0 && math.Abs(total-assignment.Price) > milestoneAmountTolerance {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Milestones add up to %.2f but the price is %.2f", total, assignment.Price)})
+		return
+	}
+
+	status := models.AssignmentPosted
+	if assignment.Status == models.AssignmentDraft {
+		status = models.AssignmentDraft
+	}
+	// The owner is always the caller, whatever the body says
+	assignment.UserID = callerID
+	assignment.SolverID = primitive.NilObjectID
+	assignment.AcceptedBidID = primitive.NilObjectID
+	assignment.BidAmount = 0
+	assignment.InitStatus(status, callerID)
 	assignment.CreatedAt = time.Now()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -43,7 +80,10 @@ func CreateAssignmentRoute(c *gin.Context) {
 	}
 
 	// Find top solvers
-	topSolvers := findTopSolvers(ctx, assignment)
+	if id, ok := result.InsertedID.(primitive.ObjectID); ok {
+		assignment.ID = id
+	}
+	topSolvers := findTopSolvers(ctx, assignment, callerID)
 	c.JSON(http.StatusOK, gin.H{
 		"message":     "Assignment created successfully",
 		"id":          result.InsertedID,
@@ -51,100 +91,147 @@ func CreateAssignmentRoute(c *gin.Context) {
 	})
 }
 
-func findTopSolvers(ctx context.Context, assignment models.Assignment) []SolverRank {
-	solverCollection := config.DB.Collection("users")
-	cursor, _ := solverCollection.Find(ctx, bson.M{"role": "solver"})
-
-	var solvers []models.User
-	cursor.All(ctx, &solvers)
-
-	var scored []SolverRank
-
-	for _, solver := range solvers {
-		// Simple scoring algorithm
-		score := float64(0)
+// findTopSolvers ranks solvers for a new assignment shown to its owner.
+// Matching is a suggestion, so a failure only leaves the list empty.
+func findTopSolvers(ctx context.Context, assignment models.Assignment, ownerID primitive.ObjectID) []matching.Match {
+	matches, err := rankSolvers(ctx, assignment, ownerID, models.SurfaceCreate)
+	if err != nil {
+		fmt.Printf("[findTopSolvers] failed to rank solvers: %v\n", err)
+		return []matching.Match{}
+	}
+	return matches
+}
 
-		// Skill match score (40%)
-		for _, skill := range solver.Skills {
-			if stringInSlice(skill, assignment.Skills) {
-				score += 40.0 / float64(len(assignment.Skills))
-			}
-		}
+// assignmentSorts are the sort orders accepted by GetAssignments.
+var assignmentSorts = map[string]sortSpec{
+	"newest":     {Field: "createdAt", Desc: true},
+	"oldest":     {Field: "createdAt"},
+	"deadline":   {Field: "deadline"},
+	"price_asc":  {Field: "price"},
+	"price_desc": {Field: "price", Desc: true},
+}
 
-		// Price score (20%)
-		if solver.PricePerJob > 0 {
-			score += 20.0 / (solver.PricePerJob / 1000)
-		}
+// GET /api/assignments - Search assignments
+// Query: status (comma list), skills (comma list) with skills_match=any|all,
+// urgency, min_price, max_price, deadline_from, deadline_to (RFC 3339),
+// owner (user id), q (text search on title/description),
+// sort (newest | oldest | deadline | price_asc | price_desc), limit, cursor.
+// Drafts are only listed for their owner.
+func GetAssignments(c *gin.Context) {
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
 
-		// Speed score (20%)
-		if solver.Speed > 0 {
-			score += 20.0 / (solver.Speed / 48)
-		}
+	filter, err := assignmentSearchFilter(c, callerID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	page, err := parsePageRequest(c, assignmentSorts, "newest")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-		// Distance score (20%)
-		distance := haversineDistance(
-			assignment.Lat, assignment.Lng,
-			solver.Location.Latitude, solver.Location.Longitude,
-		)
-		if distance > 0 {
-			score += 20.0 / (distance / 100)
-		}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-		scored = append(scored, SolverRank{Solver: solver, Score: score})
+	var assignments []models.Assignment
+	next, err := findPage(ctx, config.DB.Collection("assignments"), filter, page, nil, &assignments)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch assignments"})
+		return
 	}
 
-	sort.Slice(scored, func(i, j int) bool {
-		return scored[i].Score > scored[j].Score
-	})
-
-	if len(scored) > 10 {
-		scored = scored[:10]
-	} else {
-		return scored
+	resp := gin.H{"assignments": assignments}
+	if next != "" {
+		resp["next_cursor"] = next
 	}
-	return scored
+	c.JSON(http.StatusOK, resp)
 }
 
-func haversineDistance(lat1, lon1, lat2, lon2 float64) float64 {
-	const R = 6371 // Earth radius in km
-
-	dLat := (lat2 - lat1) * math.Pi / 180
-	dLon := (lon2 - lon1) * math.Pi / 180
-	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
-		math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*
-			math.Sin(dLon/2)*math.Sin(dLon/2)
-	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
-	return R * c
-}
+// assignmentSearchFilter builds the Mongo filter for GetAssignments.
+func assignmentSearchFilter(c *gin.Context, callerID primitive.ObjectID) (bson.M, error) {
+	filter := bson.M{}
 
-func stringInSlice(a string, list []string) bool {
-	for _, b := range list {
-		if b == a {
-			return true
+	if v := c.Query("status"); v != "" {
+		statuses := splitList(v)
+		for _, s := range statuses {
+			if !models.IsAssignmentStatus(s) {
+				return nil, fmt.Errorf("unknown status %q", s)
+			}
 		}
+		filter["status"] = bson.M{"$in": statuses}
+	}
+	if v := c.Query("owner"); v != "" {
+		ownerID, err := primitive.ObjectIDFromHex(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid owner")
+		}
+		filter["userId"] = ownerID
+	}
+	// Drafts are private to their owner
+	filter["$or"] = bson.A{
+		bson.M{"status": bson.M{"$ne": models.AssignmentDraft}},
+		bson.M{"userId": callerID},
 	}
-	return false
-}
 
-// GET /api/assignments
-func GetAssignments(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	if raw := splitList(c.Query("skills")); len(raw) > 0 {
+		canonical := skills.Canonicalize(raw)
+		switch c.DefaultQuery("skills_match", "any") {
+		case "any":
+			filter["skills"] = bson.M{"$in": canonical}
+		case "all":
+			filter["skills"] = bson.M{"$all": canonical}
+		default:
+			return nil, fmt.Errorf("skills_match must be any or all")
+		}
+	}
+	if v := c.Query("urgency"); v != "" {
+		filter["urgency"] = v
+	}
 
-	assignmentCollection := config.DB.Collection("assignments")
-	cursor, err := assignmentCollection.Find(ctx, bson.M{})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch assignments"})
-		return
+	price := bson.M{}
+	if v, ok, err := queryFloat(c, "min_price"); err != nil {
+		return nil, err
+	} else if ok {
+		price["$gte"] = v
+	}
+	if v, ok, err := queryFloat(c, "max_price"); err != nil {
+		return nil, err
+	} else if ok {
+		price["$lte"] = v
+	}
+	if len(price) > 0 {
+		filter["price"] = price
 	}
 
-	var assignments []models.Assignment
-	cursor.All(ctx, &assignments)
+	deadline := bson.M{}
+	for param, op := range map[string]string{"deadline_from": "$gte", "deadline_to": "$lte"} {
+		if v := c.Query(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be an RFC 3339 timestamp", param)
+			}
+			deadline[op] = t
+		}
+	}
+	if len(deadline) > 0 {
+		filter["deadline"] = deadline
+	}
 
-	c.JSON(http.StatusOK, assignments)
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		if len(q) > 200 {
+			return nil, fmt.Errorf("q must be at most 200 characters")
+		}
+		filter["$text"] = bson.M{"$search": q}
+	}
+	return filter, nil
 }
 
 // GET /api/assignments/:id
+// Drafts are only visible to their owner; anyone else gets a 404.
 func GetAssignment(c *gin.Context) {
 	assignmentID := c.Param("id")
 	fmt.Printf("assignmentID %s\n", assignmentID)
@@ -156,6 +243,11 @@ func GetAssignment(c *gin.Context) {
 		return
 	}
 
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -167,6 +259,10 @@ func GetAssignment(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
 		return
 	}
+	if assignment.Status == models.AssignmentDraft && !isAssignmentOwner(callerID, assignment) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+		return
+	}
 
 	c.JSON(http.StatusOK, assignment)
 }
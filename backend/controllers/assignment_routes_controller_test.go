@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/middleware"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// Drafts are private to their owner, as in GetAssignments.
+func TestGetAssignmentHidesOthersDrafts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	owner := primitive.NewObjectID()
+	assignmentID := primitive.NewObjectID()
+	draft := bson.D{
+		{Key: "_id", Value: assignmentID},
+		{Key: "userId", Value: owner},
+		{Key: "title", Value: "Unfinished brief"},
+		{Key: "status", Value: models.AssignmentDraft},
+	}
+
+	cases := []struct {
+		name   string
+		caller primitive.ObjectID
+		want   int
+	}{
+		{name: "owner", caller: owner, want: http.StatusOK},
+		{name: "another user", caller: primitive.NewObjectID(), want: http.StatusNotFound},
+	}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	for _, tc := range cases {
+		mt.Run(tc.name, func(mt *mtest.T) {
+			config.DB = mt.Client.Database("test")
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "test.assignments", mtest.FirstBatch, draft))
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			c.Params = gin.Params{{Key: "id", Value: assignmentID.Hex()}}
+			c.Set(middleware.ContextUserID, tc.caller)
+
+			GetAssignment(c)
+
+			if w.Code != tc.want {
+				mt.Fatalf("status = %d, want %d; body %s", w.Code, tc.want, w.Body.String())
+			}
+		})
+	}
+}
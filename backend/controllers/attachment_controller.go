@@ -0,0 +1,481 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/middleware"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/Aashishvatwani/homeworld/storage"
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	defaultMaxAttachmentBytes = 25 << 20
+	defaultAttachmentURLTTL   = 15 * time.Minute
+	maxAttachmentNameLength   = 200
+	// maxAttachmentsPerRecord caps the files on one assignment brief,
+	// message or deliverable.
+	maxAttachmentsPerRecord = 10
+)
+
+// defaultAttachmentTypes are the content types accepted unless
+// ATTACHMENT_ALLOWED_TYPES overrides them. Types are detected from the file
+// content, not taken from the client.
+var defaultAttachmentTypes = []string{
+	"application/pdf",
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"image/webp",
+	"text/plain",
+	"text/csv",
+	"application/json",
+	"application/zip",
+	"application/msword",
+	"application/vnd.ms-excel",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation",
+}
+
+// maxAttachmentBytes is the largest accepted upload, from ATTACHMENT_MAX_BYTES.
+func maxAttachmentBytes() int64 {
+	if n, err := strconv.ParseInt(os.Getenv("ATTACHMENT_MAX_BYTES"), 10, 64); err == nil && n > 0 {
+		return n
+	}
+	return defaultMaxAttachmentBytes
+}
+
+// attachmentURLTTL is how long download links stay valid, from ATTACHMENT_URL_TTL.
+func attachmentURLTTL() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("ATTACHMENT_URL_TTL")); err == nil && d > 0 {
+		return d
+	}
+	return defaultAttachmentURLTTL
+}
+
+// allowedAttachmentTypes reads the comma-separated ATTACHMENT_ALLOWED_TYPES.
+func allowedAttachmentTypes() []string {
+	if types := splitList(os.Getenv("ATTACHMENT_ALLOWED_TYPES")); len(types) > 0 {
+		return types
+	}
+	return defaultAttachmentTypes
+}
+
+func attachmentCollection() *mongo.Collection {
+	return config.DB.Collection("attachments")
+}
+
+// sanitizeFilename keeps the base name of a client-supplied filename and
+// drops control characters so it is safe to echo in headers.
+func sanitizeFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' {
+			return -1
+		}
+		return r
+	}, name))
+	if runes := []rune(name); len(runes) > maxAttachmentNameLength {
+		name = string(runes[len(runes)-maxAttachmentNameLength:])
+	}
+	if name == "" || name == "." || name == "/" {
+		return "file"
+	}
+	return name
+}
+
+// uploadPurpose checks that the caller may attach files to the parent and
+// returns what the upload is for: the owner's brief, the accepted solver's
+// deliverable, or a chat message.
+func uploadPurpose(ctx context.Context, c *gin.Context, callerID primitive.ObjectID, parentType string, parentID primitive.ObjectID) (string, bool) {
+	switch parentType {
+	case models.AttachmentParentAssignment:
+		assignment, err := loadAssignment(ctx, parentID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+			return "", false
+		}
+		if assignment.IsTerminal() {
+			c.JSON(http.StatusConflict, gin.H{"error": "Files cannot be attached to a " + assignment.Status + " assignment"})
+			return "", false
+		}
+		switch {
+		case isAssignmentOwner(callerID, assignment):
+			if len(assignment.Attachments) >= maxAttachmentsPerRecord {
+				c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("An assignment can have at most %d attachments", maxAttachmentsPerRecord)})
+				return "", false
+			}
+			return models.AttachmentBrief, true
+		case isSelf(callerID, assignment.SolverID):
+			return models.AttachmentDeliverable, true
+		}
+		forbidden(c, "Only the assignment owner or its solver can attach files")
+		return "", false
+
+	case models.AttachmentParentChat:
+		var chat models.Chat
+		if err := config.DB.Collection("chats").FindOne(ctx, bson.M{"_id": parentID}).Decode(&chat); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Chat not found"})
+			return "", false
+		}
+		if !isChatParticipant(callerID, chat) {
+			forbidden(c, "You are not a participant in this chat")
+			return "", false
+		}
+		return models.AttachmentChat, true
+
+	case models.AttachmentParentDispute:
+		var dispute models.Dispute
+		if err := disputeCollection().FindOne(ctx, bson.M{"_id": parentID}).Decode(&dispute); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Dispute not found"})
+			return "", false
+		}
+		if disputeParty(callerID, dispute) == "" {
+			forbidden(c, "Only the parties to a dispute can attach evidence")
+			return "", false
+		}
+		if !isDisputeAwaitingRuling(dispute) || time.Now().After(dispute.EvidenceDueAt) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Evidence is no longer accepted for this dispute"})
+			return "", false
+		}
+		return models.AttachmentEvidence, true
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{"error": "parentType must be assignment, chat or dispute"})
+	return "", false
+}
+
+// POST /api/attachments - Upload a file (multipart/form-data)
+// Fields: file, parentType ("assignment" or "chat"), parentId
+// Owner uploads to an assignment are added to its brief straight away;
+// solver and chat uploads are referenced by ID when submitting the
+// deliverable or sending the message.
+func UploadAttachment(c *gin.Context) {
+	limit := maxAttachmentBytes()
+	// Leave room for the multipart framing and the other form fields
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit+1<<20)
+
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Files must be at most %d bytes", limit)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A file is required"})
+		return
+	}
+	if fileHeader.Size > limit {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Files must be at most %d bytes", limit)})
+		return
+	}
+	if fileHeader.Size == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is empty"})
+		return
+	}
+	parentType := c.PostForm("parentType")
+	parentID, err := primitive.ObjectIDFromHex(c.PostForm("parentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parentId"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	purpose, ok := uploadPurpose(ctx, c, callerID, parentType, parentID)
+	if !ok {
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read upload"})
+		return
+	}
+	defer file.Close()
+
+	detected, err := mimetype.DetectReader(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read upload"})
+		return
+	}
+	allowed := allowedAttachmentTypes()
+	contentType := ""
+	for _, t := range allowed {
+		if detected.Is(t) {
+			contentType = t
+			break
+		}
+	}
+	if contentType == "" {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "File type " + detected.String() + " is not allowed", "allowed": allowed})
+		return
+	}
+
+	// Hash the whole body first so the backend can verify what it stores
+	sum, err := hashUpload(file)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read upload"})
+		return
+	}
+
+	attachment := models.Attachment{
+		ID:          primitive.NewObjectID(),
+		UploaderID:  callerID,
+		ParentType:  parentType,
+		ParentID:    parentID,
+		Purpose:     purpose,
+		Name:        sanitizeFilename(fileHeader.Filename),
+		ContentType: contentType,
+		Size:        fileHeader.Size,
+		SHA256:      hex.EncodeToString(sum),
+		CreatedAt:   time.Now(),
+	}
+	attachment.StorageKey = parentType + "s/" + parentID.Hex() + "/" + attachment.ID.Hex()
+
+	if err := storage.Put(ctx, attachment.StorageKey, file, fileHeader.Size, storage.PutOptions{ContentType: contentType, SHA256: sum}); err != nil {
+		fmt.Printf("[UploadAttachment] storing %s failed: %v\n", attachment.StorageKey, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to store file"})
+		return
+	}
+	if _, err := attachmentCollection().InsertOne(ctx, attachment); err != nil {
+		discardStoredAttachment(ctx, attachment)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save attachment"})
+		return
+	}
+
+	if purpose == models.AttachmentBrief {
+		// The positional check keeps concurrent uploads within the limit
+		result, err := config.DB.Collection("assignments").UpdateOne(ctx,
+			bson.M{"_id": parentID, fmt.Sprintf("attachments.%d", maxAttachmentsPerRecord-1): bson.M{"$exists": false}},
+			bson.M{"$push": bson.M{"attachments": attachment.Ref()}},
+		)
+		if err != nil || result.MatchedCount == 0 {
+			attachmentCollection().DeleteOne(ctx, bson.M{"_id": attachment.ID})
+			discardStoredAttachment(ctx, attachment)
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("An assignment can have at most %d attachments", maxAttachmentsPerRecord)})
+			return
+		}
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "File uploaded", "attachment": attachment})
+}
+
+// hashUpload returns the SHA-256 of the whole file and rewinds it.
+func hashUpload(file io.ReadSeeker) ([]byte, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
+
+func discardStoredAttachment(ctx context.Context, attachment models.Attachment) {
+	if err := storage.Delete(ctx, attachment.StorageKey); err != nil {
+		fmt.Printf("[UploadAttachment] failed to remove orphaned file %s: %v\n", attachment.StorageKey, err)
+	}
+}
+
+// canAccessAttachment applies the access rules of the attachment's parent:
+// chat participants see chat files; the assignment owner and solver see all
+// of its files; anyone who can see a posted assignment can read its brief.
+// Dispute evidence is visible to both parties and to admins, who may also
+// read the files of any assignment that has been disputed.
+func canAccessAttachment(ctx context.Context, callerID primitive.ObjectID, isAdmin bool, attachment models.Attachment) (bool, error) {
+	if isSelf(callerID, attachment.UploaderID) {
+		return true, nil
+	}
+	switch attachment.ParentType {
+	case models.AttachmentParentDispute:
+		if isAdmin {
+			return true, nil
+		}
+		var dispute models.Dispute
+		if err := disputeCollection().FindOne(ctx, bson.M{"_id": attachment.ParentID}).Decode(&dispute); err != nil {
+			return false, err
+		}
+		return disputeParty(callerID, dispute) != "", nil
+	case models.AttachmentParentChat:
+		var chat models.Chat
+		if err := config.DB.Collection("chats").FindOne(ctx, bson.M{"_id": attachment.ParentID}).Decode(&chat); err != nil {
+			return false, err
+		}
+		return isChatParticipant(callerID, chat), nil
+	case models.AttachmentParentAssignment:
+		assignment, err := loadAssignment(ctx, attachment.ParentID)
+		if err != nil {
+			return false, err
+		}
+		if isAssignmentOwner(callerID, assignment) || isSelf(callerID, assignment.SolverID) {
+			return true, nil
+		}
+		// Admins arbitrating a dispute see the brief and the delivered work
+		if isAdmin {
+			n, err := disputeCollection().CountDocuments(ctx, bson.M{"assignmentId": assignment.ID})
+			if err != nil {
+				return false, err
+			}
+			if n > 0 {
+				return true, nil
+			}
+		}
+		return attachment.Purpose == models.AttachmentBrief && assignment.Status != models.AssignmentDraft, nil
+	}
+	return false, nil
+}
+
+// GET /api/attachments/:id - Attachment metadata with a signed, expiring download URL
+func GetAttachment(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return
+	}
+
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var attachment models.Attachment
+	if err := attachmentCollection().FindOne(ctx, bson.M{"_id": objID}).Decode(&attachment); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		return
+	}
+	allowed, err := canAccessAttachment(ctx, callerID, middleware.CurrentRole(c) == models.RoleAdmin, attachment)
+	if err != nil && err != mongo.ErrNoDocuments {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check access"})
+		return
+	}
+	if !allowed {
+		forbidden(c, "You do not have access to this attachment")
+		return
+	}
+
+	ttl := attachmentURLTTL()
+	downloadURL, err := storage.SignedURL(ctx, attachment.StorageKey, storage.Download{Filename: attachment.Name, ContentType: attachment.ContentType}, ttl)
+	if err != nil {
+		fmt.Printf("[GetAttachment] signing %s failed: %v\n", attachment.StorageKey, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create download link"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"attachment": attachment,
+		"url":        downloadURL,
+		"expires_at": time.Now().Add(ttl),
+	})
+}
+
+// GET /api/files/*key - Serve a file from the local storage backend
+// The signed query string issued by GetAttachment is the only authorization.
+func DownloadFile(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	download, err := storage.VerifyDownload(key, c.Request.URL.Query())
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+			return
+		}
+		forbidden(c, err.Error())
+		return
+	}
+
+	body, err := storage.Open(c.Request.Context(), key)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	defer body.Close()
+
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.DataFromReader(http.StatusOK, -1, download.ContentType, body, map[string]string{
+		"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{"filename": download.Filename}),
+	})
+}
+
+// claimAttachments resolves attachment IDs sent with a message or
+// deliverable. Each must have been uploaded by the caller to the same parent
+// for the same purpose. Refs are returned in request order.
+func claimAttachments(ctx context.Context, rawIDs []string, uploaderID primitive.ObjectID, parentType string, parentID primitive.ObjectID, purpose string) ([]models.AttachmentRef, error) {
+	if len(rawIDs) > maxAttachmentsPerRecord {
+		return nil, fmt.Errorf("at most %d attachments can be sent at once", maxAttachmentsPerRecord)
+	}
+	ids := make([]primitive.ObjectID, 0, len(rawIDs))
+	seen := make(map[primitive.ObjectID]bool, len(rawIDs))
+	for _, raw := range rawIDs {
+		id, err := primitive.ObjectIDFromHex(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid attachment ID %q", raw)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	refs := make([]models.AttachmentRef, 0, len(ids))
+	if len(ids) == 0 {
+		return refs, nil
+	}
+
+	cursor, err := attachmentCollection().Find(ctx, bson.M{
+		"_id":        bson.M{"$in": ids},
+		"uploaderId": uploaderID,
+		"parentType": parentType,
+		"parentId":   parentID,
+		"purpose":    purpose,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var found []models.Attachment
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, err
+	}
+	byID := make(map[primitive.ObjectID]models.Attachment, len(found))
+	for _, a := range found {
+		byID[a.ID] = a
+	}
+	for _, id := range ids {
+		a, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("attachment %s was not uploaded by you to this %s", id.Hex(), parentType)
+		}
+		refs = append(refs, a.Ref())
+	}
+	return refs, nil
+}
@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/audit"
+	"github.com/Aashishvatwani/homeworld/middleware"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 500
+)
+
+// recordAudit appends an audit entry for an action the caller has just
+// performed. The action has already happened, so failures are logged rather
+// than returned to the client.
+func recordAudit(c *gin.Context, action, targetType, targetID string, before, after interface{}) {
+	actorID, _ := middleware.CurrentUserID(c)
+	event := audit.Event{
+		Action:     action,
+		ActorID:    actorID,
+		ActorRole:  middleware.CurrentRole(c),
+		TargetType: targetType,
+		TargetID:   targetID,
+		Before:     before,
+		After:      after,
+		IP:         c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := audit.Record(ctx, event); err != nil {
+		fmt.Printf("[audit] failed to record %s on %s %s: %v\n", action, targetType, targetID, err)
+	}
+}
+
+// GET /api/admin/audit - Query the audit log (admin only)
+// Query: action, actor_id, target_type, target_id, from, to (RFC 3339),
+// before_seq (page cursor), limit
+func GetAuditLog(c *gin.Context) {
+	filter := audit.Filter{
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+		Limit:      defaultAuditPageSize,
+	}
+
+	if v := c.Query("actor_id"); v != "" {
+		id, err := primitive.ObjectIDFromHex(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid actor_id"})
+			return
+		}
+		filter.ActorID = id
+	}
+	for param, dst := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if v := c.Query(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": param + " must be an RFC 3339 timestamp"})
+				return
+			}
+			*dst = t
+		}
+	}
+	if v := c.Query("before_seq"); v != "" {
+		seq, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || seq <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid before_seq"})
+			return
+		}
+		filter.BeforeSeq = seq
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		filter.Limit = min(limit, maxAuditPageSize)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	entries, err := audit.Query(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query audit log"})
+		return
+	}
+
+	// Reading the audit log is itself an admin action
+	recordAudit(c, audit.ActionAdminAuditQuery, audit.TargetAuditLog, "", nil, gin.H{
+		"action": filter.Action, "actor_id": c.Query("actor_id"), "target_type": filter.TargetType,
+		"target_id": filter.TargetID, "from": c.Query("from"), "to": c.Query("to"),
+		"before_seq": filter.BeforeSeq, "limit": filter.Limit,
+	})
+
+	resp := gin.H{"entries": entries}
+	if int64(len(entries)) == filter.Limit {
+		resp["next_before_seq"] = entries[len(entries)-1].Seq
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GET /api/admin/audit/verify - Recompute the hash chain (admin only)
+func VerifyAuditLog(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	result, err := audit.Verify(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify audit log"})
+		return
+	}
+
+	recordAudit(c, audit.ActionAdminAuditVerify, audit.TargetAuditLog, "", nil, result)
+	c.JSON(http.StatusOK, result)
+}
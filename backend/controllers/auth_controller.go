@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/middleware"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/Aashishvatwani/homeworld/sessions"
+	"github.com/Aashishvatwani/homeworld/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// issueTokens starts a new session for the user and returns the access and
+// refresh tokens to include in the response.
+func issueTokens(ctx context.Context, c *gin.Context, userID primitive.ObjectID, email, activeRole string) (gin.H, error) {
+	session, refreshToken, err := sessions.Start(ctx, userID, activeRole, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := utils.GenerateJWT(userID, email, activeRole, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return gin.H{
+		"token":         accessToken,
+		"refresh_token": refreshToken,
+		"expires_in":    int(config.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+// POST /api/auth/refresh - Exchange a refresh token for a new token pair
+// Body: { "refresh_token": "...", "role": "solver" (optional, switches active role) }
+func RefreshToken(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+		Role         string `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_token is required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	session, err := sessions.Validate(ctx, req.RefreshToken)
+	if err != nil {
+		respondSessionError(c, err)
+		return
+	}
+
+	// Roles may have changed since login, so re-read the user
+	var user models.User
+	if err := config.DB.Collection("users").FindOne(ctx, bson.M{"_id": session.UserID}).Decode(&user); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User no longer exists"})
+		return
+	}
+
+	activeRole := session.ActiveRole
+	if req.Role != "" {
+		activeRole = req.Role
+	}
+	if !user.HasRole(activeRole) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "The " + activeRole + " role is not enabled for this account"})
+		return
+	}
+
+	refreshToken, err := sessions.Rotate(ctx, session, req.RefreshToken, activeRole)
+	if err != nil {
+		respondSessionError(c, err)
+		return
+	}
+
+	accessToken, err := utils.GenerateJWT(user.ID, user.Email, activeRole, session.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Token refreshed",
+		"token":         accessToken,
+		"refresh_token": refreshToken,
+		"expires_in":    int(config.AccessTokenTTL.Seconds()),
+		"active_role":   activeRole,
+	})
+}
+
+// POST /api/auth/logout - Revoke the caller's current session
+func Logout(c *gin.Context) {
+	sessionID, ok := middleware.CurrentSessionID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := sessions.Revoke(ctx, sessionID, sessions.ReasonLogout); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+// POST /api/auth/logout-all - Revoke every session of the caller (all devices)
+func LogoutAll(c *gin.Context) {
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	revoked, err := sessions.RevokeAll(ctx, callerID, sessions.ReasonLogoutAll)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out all devices"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "Logged out of all devices",
+		"sessions_revoked": revoked,
+	})
+}
+
+func respondSessionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, sessions.ErrTokenReused):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error(), "code": "token_reused"})
+	case errors.Is(err, sessions.ErrInvalidToken), errors.Is(err, sessions.ErrRevoked):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh session"})
+	}
+}
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Aashishvatwani/homeworld/middleware"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// requireCaller returns the authenticated user's ID, writing a 401 response
+// if the request did not pass through middleware.AuthRequired.
+func requireCaller(c *gin.Context) (primitive.ObjectID, bool) {
+	userID, ok := middleware.CurrentUserID(c)
+	if !ok || userID.IsZero() {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return primitive.NilObjectID, false
+	}
+	return userID, true
+}
+
+// forbidden writes a 403 response with the given reason.
+func forbidden(c *gin.Context, reason string) {
+	c.JSON(http.StatusForbidden, gin.H{"error": reason})
+}
+
+// isSelf reports whether the caller is acting on their own user record.
+func isSelf(callerID, targetID primitive.ObjectID) bool {
+	return !callerID.IsZero() && callerID == targetID
+}
+
+// isAssignmentOwner reports whether the caller is the buyer who posted the assignment.
+func isAssignmentOwner(callerID primitive.ObjectID, assignment models.Assignment) bool {
+	return isSelf(callerID, assignment.UserID)
+}
+
+// isChatParticipant reports whether the caller is the buyer or solver of the chat.
+func isChatParticipant(callerID primitive.ObjectID, chat models.Chat) bool {
+	return isSelf(callerID, chat.BuyerID) || isSelf(callerID, chat.SolverID)
+}
+
+// isPaymentParty reports whether the caller is the buyer or solver of the payment.
+func isPaymentParty(callerID primitive.ObjectID, payment models.Payment) bool {
+	return isSelf(callerID, payment.BuyerID) || isSelf(callerID, payment.SolverID)
+}
+
+// isNotificationOwner reports whether the notification was addressed to the caller.
+func isNotificationOwner(callerID primitive.ObjectID, notification models.Notification) bool {
+	return isSelf(callerID, notification.UserID)
+}
@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/middleware"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// accessCase calls handler as a user who does not own or take part in the
+// resource it acts on. found is what the handler's first lookup returns.
+type accessCase struct {
+	name    string
+	handler gin.HandlerFunc
+	method  string
+	params  gin.Params
+	body    string
+	found   *foundDoc
+}
+
+type foundDoc struct {
+	ns  string
+	doc bson.D
+}
+
+func TestCrossUserAccessIsForbidden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	buyer := primitive.NewObjectID()
+	solver := primitive.NewObjectID()
+	stranger := primitive.NewObjectID()
+	resourceID := primitive.NewObjectID()
+
+	t.Setenv("RAZORPAY_KEY_SECRET", "test-secret")
+	mac := hmac.New(sha256.New, []byte("test-secret"))
+	mac.Write([]byte("order_1|pay_1"))
+	signature := fmt.Sprintf("%x", mac.Sum(nil))
+
+	chat := &foundDoc{"test.chats", bson.D{
+		{Key: "_id", Value: resourceID}, {Key: "buyerId", Value: buyer}, {Key: "solverId", Value: solver},
+	}}
+	assignment := &foundDoc{"test.assignments", bson.D{
+		{Key: "_id", Value: resourceID}, {Key: "userId", Value: buyer}, {Key: "status", Value: "posted"},
+	}}
+	payment := &foundDoc{"test.payments", bson.D{
+		{Key: "_id", Value: resourceID}, {Key: "buyerId", Value: buyer}, {Key: "solverId", Value: solver},
+	}}
+	notification := &foundDoc{"test.notifications", bson.D{
+		{Key: "_id", Value: resourceID}, {Key: "userId", Value: buyer},
+	}}
+	byID := gin.Params{{Key: "id", Value: resourceID.Hex()}}
+	byUser := gin.Params{{Key: "id", Value: buyer.Hex()}}
+
+	cases := []accessCase{
+		{name: "create chat for others", handler: CreateChat, method: http.MethodPost,
+			body: fmt.Sprintf(`{"buyerId":%q,"solverId":%q}`, buyer.Hex(), solver.Hex())},
+		{name: "send message", handler: SendMessage, method: http.MethodPost, params: byID,
+			body: `{"content":"hello"}`, found: chat},
+		{name: "get chat", handler: GetChat, method: http.MethodGet, params: byID, found: chat},
+		{name: "negotiate price", handler: NegotiatePrice, method: http.MethodPut, params: byID,
+			body: `{"agreedPrice":100}`, found: chat},
+		{name: "create payment", handler: CreatePayment, method: http.MethodPost,
+			body: fmt.Sprintf(`{"assignmentId":%q,"solverId":%q}`, resourceID.Hex(), solver.Hex()), found: assignment},
+		{name: "verify payment", handler: VerifyPayment, method: http.MethodPost,
+			body:  fmt.Sprintf(`{"order_id":"order_1","payment_id":"pay_1","signature":%q}`, signature),
+			found: payment},
+		{name: "get payment", handler: GetPayment, method: http.MethodGet, params: byID, found: payment},
+		{name: "list notifications", handler: GetNotifications, method: http.MethodGet,
+			params: gin.Params{{Key: "userId", Value: buyer.Hex()}}},
+		{name: "mark notification read", handler: MarkNotificationRead, method: http.MethodPut, params: byID, found: notification},
+		{name: "mark all notifications read", handler: MarkAllNotificationsRead, method: http.MethodPut,
+			params: gin.Params{{Key: "userId", Value: buyer.Hex()}}},
+		{name: "delete notification", handler: DeleteNotification, method: http.MethodDelete, params: byID, found: notification},
+		{name: "complete assignment", handler: AssignmentCompleted, method: http.MethodPost,
+			body: fmt.Sprintf(`{"assignmentId":%q}`, resourceID.Hex()), found: assignment},
+		{name: "rate assignment", handler: RateAssignment, method: http.MethodPost, params: byID,
+			body: `{"rating":1}`, found: assignment},
+		{name: "update user", handler: UpdateUser, method: http.MethodPut, params: byUser, body: `{"about":"mine now"}`},
+		{name: "change password", handler: ChangePassword, method: http.MethodPut, params: byUser,
+			body: `{"current_password":"x","new_password":"long enough password"}`},
+	}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	for _, tc := range cases {
+		mt.Run(tc.name, func(mt *mtest.T) {
+			config.DB = mt.Client.Database("test")
+			if tc.found != nil {
+				mt.AddMockResponses(mtest.CreateCursorResponse(0, tc.found.ns, mtest.FirstBatch, tc.found.doc))
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(tc.method, "/", strings.NewReader(tc.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Params = tc.params
+			c.Set(middleware.ContextUserID, stranger)
+
+			tc.handler(c)
+
+			if w.Code != http.StatusForbidden {
+				mt.Fatalf("status = %d, want %d; body %s", w.Code, http.StatusForbidden, w.Body.String())
+			}
+			var resp map[string]string
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp["error"] == "" {
+				mt.Fatalf("want an error message, got %s", w.Body.String())
+			}
+		})
+	}
+}
+
+// Other users get the public profile rather than a 403, without the owner's
+// private fields.
+func TestGetUserHidesPrivateProfileFromOthers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	owner := primitive.NewObjectID()
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("stranger", func(mt *mtest.T) {
+		config.DB = mt.Client.Database("test")
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "test.users", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: owner},
+			{Key: "name", Value: "Owner"},
+			{Key: "email", Value: "owner@example.com"},
+		}))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		c.Params = gin.Params{{Key: "id", Value: owner.Hex()}}
+		c.Set(middleware.ContextUserID, primitive.NewObjectID())
+
+		GetUser(c)
+
+		if w.Code != http.StatusOK {
+			mt.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+		}
+		if strings.Contains(w.Body.String(), "owner@example.com") {
+			mt.Fatalf("public profile leaks the email: %s", w.Body.String())
+		}
+	})
+}
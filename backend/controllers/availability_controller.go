@@ -0,0 +1,205 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	maxWorkingWindows = 28 // four a day
+	maxAwayPeriods    = 20
+	maxConcurrentCap  = 50
+	maxAwayPeriod     = 365 * 24 * time.Hour
+)
+
+// workloadStatuses are the assignment states that count towards a solver's
+// concurrent workload.
+var workloadStatuses = bson.A{models.AssignmentAccepted, models.AssignmentInProgress}
+
+// solverWorkloads counts the accepted and in-progress assignments of each
+// solver. Solvers with none are left out of the map.
+func solverWorkloads(ctx context.Context, solverIDs []primitive.ObjectID) (map[primitive.ObjectID]int, error) {
+	workloads := make(map[primitive.ObjectID]int, len(solverIDs))
+	if len(solverIDs) == 0 {
+		return workloads, nil
+	}
+	cursor, err := config.DB.Collection("assignments").Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"solverId": bson.M{"$in": solverIDs}, "status": bson.M{"$in": workloadStatuses}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$solverId", "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		ID    primitive.ObjectID `bson:"_id"`
+		Count int                `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		workloads[r.ID] = r.Count
+	}
+	return workloads, nil
+}
+
+// notAwayFilter matches solvers who are not in an away period at now.
+func notAwayFilter(now time.Time) bson.M {
+	return bson.M{"$not": bson.M{"$elemMatch": bson.M{"from": bson.M{"$lte": now}, "until": bson.M{"$gt": now}}}}
+}
+
+// GET /api/users/:id/availability - A solver's availability and current workload
+func GetAvailability(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := loadUser(ctx, objID)
+	if err != nil || !user.HasRole(models.RoleSolver) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Solver not found"})
+		return
+	}
+	workloads, err := solverWorkloads(ctx, []primitive.ObjectID{objID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load workload"})
+		return
+	}
+
+	now := time.Now()
+	av := models.Availability{}
+	if user.Availability != nil {
+		av = *user.Availability
+	}
+	active := workloads[objID]
+	resp := gin.H{
+		"availability":       user.Availability,
+		"active_assignments": active,
+		"has_capacity":       av.HasCapacity(active),
+		"away":               av.AwayAt(now),
+		"working_now":        av.WorkingAt(now),
+	}
+	if next := av.NextWorkingTime(now); !next.IsZero() {
+		resp["next_working_at"] = next
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// PUT /api/users/:id/availability - Set the caller's availability (solver only)
+// Body: { "timeZone": "Asia/Kolkata", "weekly": [{ "day": 1, "start": "09:00", "end": "17:00" }],
+// "maxConcurrent": 3, "away": [{ "from": "<RFC 3339>", "until": "<RFC 3339>" }] }
+// Days run from 0 (Sunday); an end at or before the start runs past midnight.
+func SetAvailability(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+	if !isSelf(callerID, objID) {
+		forbidden(c, "You can only change your own availability")
+		return
+	}
+
+	var av models.Availability
+	if err := c.ShouldBindJSON(&av); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	now := time.Now()
+	if err := normalizeAvailability(&av, now); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	av.UpdatedAt = now
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := config.DB.Collection("users").UpdateOne(ctx,
+		bson.M{"_id": objID, "roles": models.RoleSolver},
+		bson.M{"$set": bson.M{"availability": av}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update availability"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		forbidden(c, "Enable the solver role before setting availability")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Availability updated", "availability": av})
+}
+
+// normalizeAvailability validates av and drops away periods that have
+// already ended.
+func normalizeAvailability(av *models.Availability, now time.Time) error {
+	if av.TimeZone == "" {
+		av.TimeZone = "UTC"
+	}
+	if _, err := time.LoadLocation(av.TimeZone); err != nil {
+		return fmt.Errorf("unknown time zone %q", av.TimeZone)
+	}
+
+	if len(av.Weekly) > maxWorkingWindows {
+		return fmt.Errorf("at most %d working windows are allowed", maxWorkingWindows)
+	}
+	if av.Weekly == nil {
+		av.Weekly = []models.WorkingHours{}
+	}
+	for _, w := range av.Weekly {
+		if w.Day < time.Sunday || w.Day > time.Saturday {
+			return fmt.Errorf("day must be 0 (Sunday) to 6 (Saturday)")
+		}
+		start, err := models.ParseClock(w.Start)
+		if err != nil {
+			return err
+		}
+		end, err := models.ParseClock(w.End)
+		if err != nil {
+			return err
+		}
+		if start == end || start == 24*60 {
+			return fmt.Errorf("working window %s-%s is empty", w.Start, w.End)
+		}
+	}
+
+	if av.MaxConcurrent < 0 || av.MaxConcurrent > maxConcurrentCap {
+		return fmt.Errorf("maxConcurrent must be 0 (no cap) to %d", maxConcurrentCap)
+	}
+
+	if len(av.Away) > maxAwayPeriods {
+		return fmt.Errorf("at most %d away periods are allowed", maxAwayPeriods)
+	}
+	away := []models.AwayPeriod{}
+	for _, p := range av.Away {
+		if !p.Until.After(p.From) {
+			return fmt.Errorf("away periods must end after they start")
+		}
+		if p.Until.Sub(p.From) > maxAwayPeriod {
+			return fmt.Errorf("away periods may last at most a year")
+		}
+		if p.Until.After(now) {
+			away = append(away, p)
+		}
+	}
+	av.Away = away
+	return nil
+}
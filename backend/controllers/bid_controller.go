@@ -0,0 +1,541 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	maxCoverNoteLength = 2000
+	maxBidETAHours     = 24 * 90
+)
+
+type bidRequest struct {
+	Amount    float64 `json:"amount" binding:"required"`
+	ETAHours  int     `json:"eta_hours" binding:"required"`
+	CoverNote string  `json:"cover_note"`
+}
+
+func (r *bidRequest) validate() error {
+	r.CoverNote = strings.TrimSpace(r.CoverNote)
+	if r.Amount <= 0 {
+		return fmt.Errorf("amount must be positive")
+	}
+	if r.ETAHours <= 0 || r.ETAHours > maxBidETAHours {
+		return fmt.Errorf("eta_hours must be between 1 and %d", maxBidETAHours)
+	}
+	if len(r.CoverNote) > maxCoverNoteLength {
+		return fmt.Errorf("cover_note must be at most %d characters", maxCoverNoteLength)
+	}
+	return nil
+}
+
+// BidView is a bid together with the bidding solver's public profile.
+type BidView struct {
+	models.Bid
+	Solver models.PublicUser `json:"solver"`
+}
+
+// validateBiddingSettings checks the bidding settings of a new assignment.
+func validateBiddingSettings(b models.BiddingSettings) error {
+	switch b.Mode {
+	case "", models.BiddingOpen:
+		if !b.ClosesAt.IsZero() && !b.ClosesAt.After(time.Now()) {
+			return fmt.Errorf("bidding.closesAt must be in the future")
+		}
+	case models.BiddingReverseAuction:
+		if !b.ClosesAt.After(time.Now()) {
+			return fmt.Errorf("reverse auctions need a bidding.closesAt in the future")
+		}
+	default:
+		return fmt.Errorf("bidding.mode must be %q or %q", models.BiddingOpen, models.BiddingReverseAuction)
+	}
+	return nil
+}
+
+func bidCollection() *mongo.Collection {
+	return config.DB.Collection("bids")
+}
+
+// lowestPendingBid returns the lowest pending bid amount on the assignment,
+// ignoring the bid with ID exclude. It reports false when there is none.
+func lowestPendingBid(ctx context.Context, assignmentID, exclude primitive.ObjectID) (float64, bool, error) {
+	filter := bson.M{"assignmentId": assignmentID, "status": models.BidPending}
+	if !exclude.IsZero() {
+		filter["_id"] = bson.M{"$ne": exclude}
+	}
+	var lowest models.Bid
+	err := bidCollection().FindOne(ctx, filter, options.FindOne().SetSort(bson.D{{Key: "amount", Value: 1}})).Decode(&lowest)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return lowest.Amount, true, nil
+}
+
+// loadBidForAssignment loads a bid by the :bidId param and checks it belongs
+// to the assignment in :id, writing an error response if not.
+func loadBidForAssignment(ctx context.Context, c *gin.Context) (models.Bid, models.Assignment, bool) {
+	assignmentID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment ID"})
+		return models.Bid{}, models.Assignment{}, false
+	}
+	bidID, err := primitive.ObjectIDFromHex(c.Param("bidId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bid ID"})
+		return models.Bid{}, models.Assignment{}, false
+	}
+
+	var bid models.Bid
+	if err := bidCollection().FindOne(ctx, bson.M{"_id": bidID, "assignmentId": assignmentID}).Decode(&bid); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bid not found"})
+		return models.Bid{}, models.Assignment{}, false
+	}
+	assignment, err := loadAssignment(ctx, assignmentID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+		return models.Bid{}, models.Assignment{}, false
+	}
+	return bid, assignment, true
+}
+
+// POST /api/assignments/:id/bids - Bid on a posted assignment (solver only)
+// Body: { "amount": 1500, "eta_hours": 48, "cover_note": "..." }
+func SubmitBid(c *gin.Context) {
+	assignmentID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment ID"})
+		return
+	}
+
+	var req bidRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount and eta_hours are required"})
+		return
+	}
+	if err := req.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	assignment, err := loadAssignment(ctx, assignmentID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+		return
+	}
+	if isAssignmentOwner(callerID, assignment) {
+		forbidden(c, "You cannot bid on your own assignment")
+		return
+	}
+	if !assignment.BiddingOpenAt(time.Now()) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Bidding is closed for this assignment"})
+		return
+	}
+
+	if assignment.BiddingMode() == models.BiddingReverseAuction {
+		lowest, found, err := lowestPendingBid(ctx, assignmentID, primitive.NilObjectID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check current bids"})
+			return
+		}
+		if found && req.Amount >= lowest {
+			c.JSON(http.StatusConflict, gin.H{"error": "Bids must undercut the current lowest bid", "lowest_bid": lowest})
+			return
+		}
+	}
+
+	now := time.Now()
+	bid := models.Bid{
+		ID:           primitive.NewObjectID(),
+		AssignmentID: assignmentID,
+		SolverID:     callerID,
+		Amount:       req.Amount,
+		ETAHours:     req.ETAHours,
+		CoverNote:    req.CoverNote,
+		Status:       models.BidPending,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if _, err := bidCollection().InsertOne(ctx, bid); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "You already have an active bid on this assignment; revise it instead"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit bid"})
+		return
+	}
+	recordOutcome(ctx, models.MatchOutcome{AssignmentID: assignmentID, SolverID: callerID, Event: models.OutcomeBid, At: now})
+
+	go CreateBuyerNotification(assignment.UserID, models.NotifTypeBidReceived, "New Bid",
+		fmt.Sprintf("A solver bid %.2f to complete \"%s\" in %d hours.", bid.Amount, assignment.Title, bid.ETAHours),
+		assignmentID, "assignment", models.PriorityMedium)
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Bid submitted", "bid": bid})
+}
+
+// PUT /api/assignments/:id/bids/:bidId - Revise a pending bid (bidding solver only)
+// Body: same as SubmitBid. In a reverse auction the amount may not go up.
+func ReviseBid(c *gin.Context) {
+	var req bidRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount and eta_hours are required"})
+		return
+	}
+	if err := req.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	bid, assignment, ok := loadBidForAssignment(ctx, c)
+	if !ok {
+		return
+	}
+	if !isSelf(callerID, bid.SolverID) {
+		forbidden(c, "You can only revise your own bids")
+		return
+	}
+	if bid.Status != models.BidPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only pending bids can be revised"})
+		return
+	}
+	if !assignment.BiddingOpenAt(time.Now()) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Bidding is closed for this assignment"})
+		return
+	}
+
+	if assignment.BiddingMode() == models.BiddingReverseAuction {
+		if req.Amount > bid.Amount {
+			c.JSON(http.StatusConflict, gin.H{"error": "Bids cannot be raised in a reverse auction"})
+			return
+		}
+		lowest, found, err := lowestPendingBid(ctx, assignment.ID, bid.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check current bids"})
+			return
+		}
+		if found && req.Amount >= lowest {
+			c.JSON(http.StatusConflict, gin.H{"error": "Bids must undercut the current lowest bid", "lowest_bid": lowest})
+			return
+		}
+	}
+
+	now := time.Now()
+	previous := models.BidRevision{Amount: bid.Amount, ETAHours: bid.ETAHours, CoverNote: bid.CoverNote, RevisedAt: now}
+	result, err := bidCollection().UpdateOne(ctx,
+		bson.M{"_id": bid.ID, "status": models.BidPending},
+		bson.M{
+			"$set":  bson.M{"amount": req.Amount, "etaHours": req.ETAHours, "coverNote": req.CoverNote, "updatedAt": now},
+			"$push": bson.M{"revisions": previous},
+		},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revise bid"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Bid is no longer pending"})
+		return
+	}
+
+	bid.Amount, bid.ETAHours, bid.CoverNote, bid.UpdatedAt = req.Amount, req.ETAHours, req.CoverNote, now
+	bid.Revisions = append(bid.Revisions, previous)
+	c.JSON(http.StatusOK, gin.H{"message": "Bid revised", "bid": bid})
+}
+
+// DELETE /api/assignments/:id/bids/:bidId - Withdraw a pending bid (bidding solver only)
+func WithdrawBid(c *gin.Context) {
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	bid, _, ok := loadBidForAssignment(ctx, c)
+	if !ok {
+		return
+	}
+	if !isSelf(callerID, bid.SolverID) {
+		forbidden(c, "You can only withdraw your own bids")
+		return
+	}
+
+	now := time.Now()
+	result, err := bidCollection().UpdateOne(ctx,
+		bson.M{"_id": bid.ID, "status": models.BidPending},
+		bson.M{"$set": bson.M{"status": models.BidWithdrawn, "updatedAt": now, "decidedAt": now}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to withdraw bid"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only pending bids can be withdrawn"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Bid withdrawn"})
+}
+
+// GET /api/assignments/:id/bids - List bids
+// The owner sees every bid with the solver's profile; solvers see only their
+// own bids, plus the lowest bid in a reverse auction.
+// Query: status (default pending, "all" for every status), sort (amount | eta | rating | newest)
+func GetBids(c *gin.Context) {
+	assignmentID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment ID"})
+		return
+	}
+
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	assignment, err := loadAssignment(ctx, assignmentID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+		return
+	}
+
+	isOwner := isAssignmentOwner(callerID, assignment)
+	filter := bson.M{"assignmentId": assignmentID}
+	if !isOwner {
+		filter["solverId"] = callerID
+	}
+	switch status := c.DefaultQuery("status", models.BidPending); status {
+	case "all":
+	case models.BidPending, models.BidAccepted, models.BidRejected, models.BidWithdrawn:
+		filter["status"] = status
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status filter"})
+		return
+	}
+
+	cursor, err := bidCollection().Find(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bids"})
+		return
+	}
+	var bids []models.Bid
+	if err := cursor.All(ctx, &bids); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bids"})
+		return
+	}
+
+	views, err := bidViews(ctx, bids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bidders"})
+		return
+	}
+	if !sortBidViews(views, c.DefaultQuery("sort", "amount")) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be amount, eta, rating or newest"})
+		return
+	}
+
+	resp := gin.H{
+		"bids":    views,
+		"bidding": gin.H{"mode": assignment.BiddingMode(), "closes_at": assignment.Bidding.ClosesAt, "open": assignment.BiddingOpenAt(time.Now())},
+	}
+	if isOwner || assignment.BiddingMode() == models.BiddingReverseAuction {
+		if lowest, found, err := lowestPendingBid(ctx, assignmentID, primitive.NilObjectID); err == nil && found {
+			resp["lowest_bid"] = lowest
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// bidViews attaches each bidder's public profile to their bid.
+func bidViews(ctx context.Context, bids []models.Bid) ([]BidView, error) {
+	solverIDs := make([]primitive.ObjectID, 0, len(bids))
+	for _, bid := range bids {
+		solverIDs = append(solverIDs, bid.SolverID)
+	}
+
+	solvers := map[primitive.ObjectID]models.User{}
+	if len(solverIDs) > 0 {
+		cursor, err := config.DB.Collection("users").Find(ctx,
+			bson.M{"_id": bson.M{"$in": solverIDs}},
+			options.Find().SetProjection(publicUserProjection),
+		)
+		if err != nil {
+			return nil, err
+		}
+		var users []models.User
+		if err := cursor.All(ctx, &users); err != nil {
+			return nil, err
+		}
+		for _, u := range users {
+			solvers[u.ID] = u
+		}
+	}
+
+	views := make([]BidView, 0, len(bids))
+	for _, bid := range bids {
+		views = append(views, BidView{Bid: bid, Solver: solvers[bid.SolverID].Public()})
+	}
+	return views, nil
+}
+
+// sortBidViews orders bids for comparison. It reports false for an unknown key.
+func sortBidViews(views []BidView, key string) bool {
+	var less func(a, b BidView) bool
+	switch key {
+	case "amount":
+		less = func(a, b BidView) bool { return a.Amount < b.Amount }
+	case "eta":
+		less = func(a, b BidView) bool { return a.ETAHours < b.ETAHours }
+	case "rating":
+		less = func(a, b BidView) bool { return a.Solver.AvgRating > b.Solver.AvgRating }
+	case "newest":
+		less = func(a, b BidView) bool { return a.CreatedAt.After(b.CreatedAt) }
+	default:
+		return false
+	}
+	sort.SliceStable(views, func(i, j int) bool { return less(views[i], views[j]) })
+	return true
+}
+
+// POST /api/assignments/:id/bids/:bidId/accept - Accept a bid (assignment owner only)
+// Assigns the solver, moves the assignment to accepted and rejects every other
+// pending bid. Pay for the assignment afterwards with POST /api/payment/create.
+func AcceptBid(c *gin.Context) {
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	bid, assignment, ok := loadBidForAssignment(ctx, c)
+	if !ok {
+		return
+	}
+	if !isAssignmentOwner(callerID, assignment) {
+		forbidden(c, "Only the assignment owner can accept bids")
+		return
+	}
+	if bid.Status != models.BidPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only pending bids can be accepted"})
+		return
+	}
+	if assignment.BiddingMode() == models.BiddingReverseAuction && assignment.BiddingOpenAt(time.Now()) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Bids can be accepted once the auction closes", "closes_at": assignment.Bidding.ClosesAt})
+		return
+	}
+	if !models.CanTransitionAssignment(assignment.Status, models.AssignmentAccepted) {
+		respondTransitionError(c, &models.IllegalTransitionError{From: assignment.Status, To: models.AssignmentAccepted})
+		return
+	}
+
+	// Claim the bid first so a concurrent withdrawal cannot race the acceptance
+	now := time.Now()
+	result, err := bidCollection().UpdateOne(ctx,
+		bson.M{"_id": bid.ID, "status": models.BidPending},
+		bson.M{"$set": bson.M{"status": models.BidAccepted, "updatedAt": now, "decidedAt": now}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept bid"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Bid is no longer pending"})
+		return
+	}
+
+	err = transitionAssignment(ctx, &assignment, models.AssignmentAccepted, callerID, "accepted bid "+bid.ID.Hex(), bson.M{
+		"solverId":      bid.SolverID,
+		"acceptedBidId": bid.ID,
+		"bidAmount":     bid.Amount,
+		"dueAt":         solverDueAt(ctx, assignment, bid.SolverID),
+	})
+	if err != nil {
+		// Put the bid back so it can be accepted once the assignment is settled
+		if _, revertErr := bidCollection().UpdateOne(ctx,
+			bson.M{"_id": bid.ID},
+			bson.M{"$set": bson.M{"status": models.BidPending, "updatedAt": time.Now()}, "$unset": bson.M{"decidedAt": ""}},
+		); revertErr != nil {
+			fmt.Printf("[AcceptBid] failed to revert bid %s: %v\n", bid.ID.Hex(), revertErr)
+		}
+		respondTransitionError(c, err)
+		return
+	}
+
+	rejected := rejectPendingBids(ctx, assignment, now)
+
+	go CreateSolverNotification(bid.SolverID, models.NotifTypeBidAccepted, "Bid Accepted",
+		fmt.Sprintf("Your bid of %.2f on \"%s\" was accepted.", bid.Amount, assignment.Title),
+		assignment.ID, "assignment", models.PriorityHigh)
+	go CreateBuyerNotification(callerID, models.NotifTypeAssignmentAccepted, "Bid Accepted",
+		fmt.Sprintf("You accepted a bid of %.2f. Complete payment to start the work.", bid.Amount),
+		assignment.ID, "assignment", models.PriorityHigh)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Bid accepted",
+		"assignment_id": assignment.ID.Hex(),
+		"bid_id":        bid.ID.Hex(),
+		"solver_id":     bid.SolverID.Hex(),
+		"amount":        bid.Amount,
+		"rejected_bids": rejected,
+	})
+}
+
+// rejectPendingBids rejects the bids still pending on an assignment once a
+// solver has been chosen, tells their solvers and returns how many there were.
+func rejectPendingBids(ctx context.Context, assignment models.Assignment, now time.Time) int {
+	others := bson.M{"assignmentId": assignment.ID, "status": models.BidPending}
+	var rejected []models.Bid
+	if cursor, err := bidCollection().Find(ctx, others, options.Find().SetProjection(bson.M{"solverId": 1})); err == nil {
+		_ = cursor.All(ctx, &rejected)
+	}
+	if _, err := bidCollection().UpdateMany(ctx, others,
+		bson.M{"$set": bson.M{"status": models.BidRejected, "updatedAt": now, "decidedAt": now}},
+	); err != nil {
+		fmt.Printf("[rejectPendingBids] failed to reject bids on %s: %v\n", assignment.ID.Hex(), err)
+	}
+
+	for _, other := range rejected {
+		go CreateSolverNotification(other.SolverID, models.NotifTypeBidRejected, "Bid Not Selected",
+			fmt.Sprintf("The buyer chose another solver for \"%s\".", assignment.Title),
+			assignment.ID, "assignment", models.PriorityLow)
+	}
+	return len(rejected)
+}
@@ -10,11 +10,12 @@ import (
 	"github.com/Aashishvatwani/homeworld/models"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type BuyerRank struct {
-	Buyer models.User `json:"buyer"`
-	Score float64     `json:"score"`
+	Buyer models.PublicUser `json:"buyer"`
+	Score float64           `json:"score"`
 }
 
 // GET /api/buyers/top
@@ -23,7 +24,7 @@ func GetTopBuyers(c *gin.Context) {
 	defer cancel()
 
 	buyerCollection := config.DB.Collection("users")
-	cursor, err := buyerCollection.Find(ctx, bson.M{"role": "buyer"})
+	cursor, err := buyerCollection.Find(ctx, bson.M{"roles": models.RoleBuyer}, options.Find().SetProjection(publicUserProjection))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch buyers"})
 		return
@@ -39,7 +40,7 @@ func GetTopBuyers(c *gin.Context) {
 		reliability := buyer.AvgRating
 
 		score := (offer*0.5 + (1/(urgency+1))*0.2 + reliability*0.3)
-		ranked = append(ranked, BuyerRank{Buyer: buyer, Score: score})
+		ranked = append(ranked, BuyerRank{Buyer: buyer.Public(), Score: score})
 	}
 
 	sort.Slice(ranked, func(i, j int) bool {
@@ -0,0 +1,498 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/Aashishvatwani/homeworld/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultCancellationResponseWindow = 48 * time.Hour
+	maxCancellationReasonLength       = 2000
+	maxCancellationCommentLength      = 2000
+)
+
+func cancellationCollection() *mongo.Collection {
+	return config.DB.Collection("cancellations")
+}
+
+// cancellationResponseWindow is how long the solver has to accept or contest
+// a proposed split before the buyer may execute it, from
+// CANCELLATION_RESPONSE_HOURS.
+func cancellationResponseWindow() time.Duration {
+	if v, err := strconv.Atoi(os.Getenv("CANCELLATION_RESPONSE_HOURS")); err == nil && v > 0 {
+		return time.Duration(v) * time.Hour
+	}
+	return defaultCancellationResponseWindow
+}
+
+// openCancellation returns the assignment's unsettled cancellation, if any.
+func openCancellation(ctx context.Context, assignmentID primitive.ObjectID) (models.Cancellation, error) {
+	var cancellation models.Cancellation
+	err := cancellationCollection().FindOne(ctx, bson.M{"assignmentId": assignmentID, "open": true}).Decode(&cancellation)
+	return cancellation, err
+}
+
+// proposeRefundSplit asks the refund service how to split the payment and
+// falls back to ruleRefundPercent when the service is unavailable.
+func proposeRefundSplit(assignment models.Assignment, payment models.Payment, reason string, rating *int, delayDays int) models.RefundSplit {
+	prediction, err := utils.PredictRefund(utils.RefundPredictionRequest{
+		Price:      payment.Amount,
+		ReasonText: reason,
+		DelayDays:  delayDays,
+		Rating:     rating,
+	})
+	if err != nil {
+		fmt.Printf("[proposeRefundSplit] refund service unavailable, using rules: %v\n", err)
+		return newRefundSplit(payment, ruleRefundPercent(assignment, delayDays), models.RefundSourceRules)
+	}
+	return newRefundSplit(payment, prediction.RefundPercent, models.RefundSourceModel)
+}
+
+// ruleRefundPercent is the fallback refund policy: everything back when the
+// solver missed the deadline or has not started, half once work is under
+// way, and a quarter after a delivery was sent back for revision.
+func ruleRefundPercent(assignment models.Assignment, delayDays int) float64 {
+	switch {
+	case delayDays > 0 || !assignment.OverdueAt.IsZero():
+		return 100
+	case assignment.Status == models.AssignmentAccepted:
+		return 100
+	case assignment.Status == models.AssignmentRevisionRequested:
+		return 25
+	default:
+		return 50
+	}
+}
+
+// newRefundSplit refunds percent of the payment net of the platform fee and
+// leaves the rest to the solver. A full refund also returns the fee, as when
+// an overdue assignment is cancelled, so the payment ends up refunded rather
+// than partially refunded. An escrow deposit can only be refunded or
+// released whole, so on-chain payments are rounded to all or nothing.
+func newRefundSplit(payment models.Payment, percent float64, source string) models.RefundSplit {
+	percent = math.Max(0, math.Min(100, percent))
+	if isOnchainPayment(payment) && percent >= 50 {
+		percent = 100
+	}
+	if percent == 100 {
+		return models.RefundSplit{Source: source, RefundPercent: 100, CustomerRefund: payment.Amount}
+	}
+	if isOnchainPayment(payment) {
+		return models.RefundSplit{Source: source, PlatformFee: payment.Commission, SolverAmount: payment.SolverAmount}
+	}
+
+	net := payment.Amount - payment.Commission
+	refund := math.Round(net*percent) / 100
+	return models.RefundSplit{
+		Source:         source,
+		RefundPercent:  math.Round(percent*100) / 100,
+		PlatformFee:    payment.Commission,
+		CustomerRefund: refund,
+		SolverAmount:   math.Round((net-refund)*100) / 100,
+	}
+}
+
+// overdueDays counts the started days since dueAt, or 0 before it.
+func overdueDays(dueAt, now time.Time) int {
+	if dueAt.IsZero() || !now.After(dueAt) {
+		return 0
+	}
+	return int(math.Ceil(now.Sub(dueAt).Hours() / 24))
+}
+
+// loadCancellationParties loads the assignment named in the URL and its
+// open cancellation, writing the error response when either is missing.
+func loadCancellationParties(ctx context.Context, c *gin.Context) (models.Assignment, models.Cancellation, bool) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment ID"})
+		return models.Assignment{}, models.Cancellation{}, false
+	}
+	assignment, err := loadAssignment(ctx, objID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+		return models.Assignment{}, models.Cancellation{}, false
+	}
+	cancellation, err := openCancellation(ctx, objID)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No pending cancellation for this assignment"})
+		return models.Assignment{}, models.Cancellation{}, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load cancellation"})
+		return models.Assignment{}, models.Cancellation{}, false
+	}
+	return assignment, cancellation, true
+}
+
+// POST /api/assignments/:id/cancel - Ask to cancel an assignment (owner only)
+// Body: { "reason": "...", "rating": 2 }
+// Without a captured payment the assignment is cancelled straight away.
+// Otherwise a refund split is proposed to the solver, who may accept or
+// contest it; rating (1-5) is the buyer's view of the work so far.
+func RequestCancellation(c *gin.Context) {
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+		Rating *int   `json:"rating"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required"})
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" || len(req.Reason) > maxCancellationReasonLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("reason must be 1-%d characters", maxCancellationReasonLength)})
+		return
+	}
+	if req.Rating != nil && (*req.Rating < 1 || *req.Rating > 5) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rating must be between 1 and 5"})
+		return
+	}
+
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment ID"})
+		return
+	}
+
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	assignment, err := loadAssignment(ctx, objID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+		return
+	}
+	if !isAssignmentOwner(callerID, assignment) {
+		forbidden(c, "Only the assignment owner can cancel it")
+		return
+	}
+	if assignment.Status == models.AssignmentDisputed {
+		c.JSON(http.StatusConflict, gin.H{"error": "Assignment is in dispute; its outcome is decided there"})
+		return
+	}
+	if !models.CanTransitionAssignment(assignment.Status, models.AssignmentCancelled) {
+		respondTransitionError(c, &models.IllegalTransitionError{From: assignment.Status, To: models.AssignmentCancelled})
+		return
+	}
+
+	payment, err := paidPayment(ctx, assignment)
+	if err == mongo.ErrNoDocuments {
+		// Nothing is held for the solver, so there is nothing to split
+		if err := transitionAssignment(ctx, &assignment, models.AssignmentCancelled, callerID, req.Reason, nil); err != nil {
+			respondTransitionError(c, err)
+			return
+		}
+		if req.Rating != nil {
+			recordOutcome(ctx, models.MatchOutcome{AssignmentID: assignment.ID, SolverID: assignment.SolverID, Event: models.OutcomeRated, Rating: *req.Rating})
+		}
+		if !assignment.SolverID.IsZero() {
+			go CreateSolverNotification(assignment.SolverID, models.NotifTypeAssignmentCancelled, "Assignment Cancelled",
+				fmt.Sprintf("The buyer cancelled \"%s\": %s", assignment.Title, req.Reason),
+				assignment.ID, "assignment", models.PriorityHigh)
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Assignment cancelled", "assignment_id": assignment.ID.Hex()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load payment"})
+		return
+	}
+
+	if _, err := openCancellation(ctx, assignment.ID); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "A cancellation is already pending for this assignment"})
+		return
+	}
+
+	now := time.Now()
+	delayDays := overdueDays(assignment.DueAt, now)
+	cancellation := models.Cancellation{
+		ID:           primitive.NewObjectID(),
+		AssignmentID: assignment.ID,
+		BuyerID:      callerID,
+		SolverID:     payment.SolverID,
+		PaymentID:    payment.ID,
+		Reason:       req.Reason,
+		Rating:       req.Rating,
+		DelayDays:    delayDays,
+		Split:        proposeRefundSplit(assignment, payment, req.Reason, req.Rating, delayDays),
+		Status:       models.CancellationProposed,
+		Open:         true,
+		RespondBy:    now.Add(cancellationResponseWindow()),
+		CreatedAt:    now,
+	}
+	if _, err := cancellationCollection().InsertOne(ctx, cancellation); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "A cancellation is already pending for this assignment"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save cancellation"})
+		return
+	}
+	if req.Rating != nil {
+		recordOutcome(ctx, models.MatchOutcome{AssignmentID: assignment.ID, SolverID: cancellation.SolverID, Event: models.OutcomeRated, Rating: *req.Rating, At: now})
+	}
+
+	go CreateSolverNotification(cancellation.SolverID, models.NotifTypeCancelRequested, "Cancellation Requested",
+		fmt.Sprintf("The buyer wants to cancel \"%s\": %s. Proposed split: %.2f refunded to the buyer, %.2f paid to you. Accept or contest it by %s.",
+			assignment.Title, req.Reason, cancellation.Split.CustomerRefund, cancellation.Split.SolverAmount, cancellation.RespondBy.UTC().Format(time.RFC1123)),
+		assignment.ID, "assignment", models.PriorityHigh)
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Cancellation proposed to the solver", "cancellation": cancellation})
+}
+
+// GET /api/assignments/:id/cancellation - Latest cancellation of an assignment (owner or solver)
+func GetCancellation(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment ID"})
+		return
+	}
+
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	assignment, err := loadAssignment(ctx, objID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+		return
+	}
+	if !isAssignmentOwner(callerID, assignment) && !isSelf(callerID, assignment.SolverID) {
+		forbidden(c, "Only the assignment owner or its accepted solver can view its cancellation")
+		return
+	}
+
+	var cancellation models.Cancellation
+	err = cancellationCollection().FindOne(ctx, bson.M{"assignmentId": objID},
+		options.FindOne().SetSort(bson.D{{Key: "createdAt", Value: -1}}),
+	).Decode(&cancellation)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No cancellation for this assignment"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load cancellation"})
+		return
+	}
+	c.JSON(http.StatusOK, cancellation)
+}
+
+// POST /api/assignments/:id/cancellation/accept - Accept and execute the proposed split
+// The solver may accept at any time; the buyer may execute the split once
+// the solver's response window has passed. Either party may retry an
+// execution that failed part way.
+func AcceptCancellation(c *gin.Context) {
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	assignment, cancellation, ok := loadCancellationParties(ctx, c)
+	if !ok {
+		return
+	}
+	isSolver := isSelf(callerID, cancellation.SolverID)
+	isBuyer := isAssignmentOwner(callerID, assignment)
+	switch {
+	case !isSolver && !isBuyer:
+		forbidden(c, "Only the assignment owner or its accepted solver can accept the cancellation")
+		return
+	case cancellation.Status == models.CancellationProposed && !isSolver && time.Now().Before(cancellation.RespondBy):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":      "The solver can still respond to this cancellation",
+			"respond_by": cancellation.RespondBy,
+		})
+		return
+	case cancellation.Status != models.CancellationProposed && cancellation.Status != models.CancellationFailed:
+		c.JSON(http.StatusConflict, gin.H{"error": "Cancellation is " + cancellation.Status})
+		return
+	}
+
+	// Claim the cancellation so the money only moves once
+	now := time.Now()
+	set := bson.M{"status": models.CancellationExecuting}
+	if cancellation.Status == models.CancellationProposed {
+		set["respondedAt"] = now
+	}
+	result, err := cancellationCollection().UpdateOne(ctx,
+		bson.M{"_id": cancellation.ID, "status": cancellation.Status},
+		bson.M{"$set": set},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update cancellation"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cancellation changed concurrently; reload and retry"})
+		return
+	}
+
+	if err := executeCancellation(ctx, c, &cancellation, callerID); err != nil {
+		fmt.Printf("[AcceptCancellation] cancellation %s failed: %v\n", cancellation.ID.Hex(), err)
+		_, updateErr := cancellationCollection().UpdateOne(ctx, bson.M{"_id": cancellation.ID}, bson.M{"$set": bson.M{
+			"status":    models.CancellationFailed,
+			"lastError": err.Error(),
+		}})
+		if updateErr != nil {
+			fmt.Printf("[AcceptCancellation] failed to mark cancellation %s failed: %v\n", cancellation.ID.Hex(), updateErr)
+		}
+		var illegal *models.IllegalTransitionError
+		if errors.As(err, &illegal) || errors.Is(err, errAssignmentChanged) {
+			respondTransitionError(c, err)
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Cancellation could not be completed: " + err.Error() + "; it can be retried"})
+		return
+	}
+
+	_, err = cancellationCollection().UpdateOne(ctx, bson.M{"_id": cancellation.ID}, bson.M{
+		"$set":   bson.M{"status": models.CancellationExecuted, "executedAt": time.Now()},
+		"$unset": bson.M{"open": "", "lastError": ""},
+	})
+	if err != nil {
+		// The money has moved; the payment and audit log record it
+		fmt.Printf("[AcceptCancellation] cancellation %s executed but not updated: %v\n", cancellation.ID.Hex(), err)
+	}
+
+	split := cancellation.Split
+	go CreateBuyerNotification(cancellation.BuyerID, models.NotifTypeAssignmentCancelled, "Assignment Cancelled",
+		fmt.Sprintf("\"%s\" has been cancelled and %.2f is being refunded to you.", assignment.Title, split.CustomerRefund),
+		assignment.ID, "assignment", models.PriorityHigh)
+	go CreateSolverNotification(cancellation.SolverID, models.NotifTypeAssignmentCancelled, "Assignment Cancelled",
+		fmt.Sprintf("\"%s\" has been cancelled and %.2f is being paid to you.", assignment.Title, split.SolverAmount),
+		assignment.ID, "assignment", models.PriorityHigh)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Assignment cancelled",
+		"assignment_id":   assignment.ID.Hex(),
+		"customer_refund": split.CustomerRefund,
+		"solver_amount":   split.SolverAmount,
+		"refund_id":       cancellation.RefundID,
+		"payout_id":       cancellation.PayoutID,
+	})
+}
+
+// POST /api/assignments/:id/cancellation/contest - Reject the proposed split (solver only)
+// Body: { "comment": "..." }
+// A dispute is opened so an admin can rule on the split.
+func ContestCancellation(c *gin.Context) {
+	var req struct {
+		Comment string `json:"comment" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "comment is required"})
+		return
+	}
+	req.Comment = strings.TrimSpace(req.Comment)
+	if req.Comment == "" || len(req.Comment) > maxCancellationCommentLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("comment must be 1-%d characters", maxCancellationCommentLength)})
+		return
+	}
+
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	assignment, cancellation, ok := loadCancellationParties(ctx, c)
+	if !ok {
+		return
+	}
+	if !isSelf(callerID, cancellation.SolverID) {
+		forbidden(c, "Only the solver can contest the cancellation")
+		return
+	}
+	if cancellation.Status != models.CancellationProposed {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cancellation is " + cancellation.Status})
+		return
+	}
+	if time.Now().After(cancellation.RespondBy) {
+		c.JSON(http.StatusConflict, gin.H{"error": "The response window for this cancellation has closed"})
+		return
+	}
+	payment, err := paidPayment(ctx, assignment)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load payment"})
+		return
+	}
+
+	result, err := cancellationCollection().UpdateOne(ctx,
+		bson.M{"_id": cancellation.ID, "status": models.CancellationProposed},
+		bson.M{"$set": bson.M{"status": models.CancellationContested, "solverComment": req.Comment, "respondedAt": time.Now()}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update cancellation"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cancellation changed concurrently; reload and retry"})
+		return
+	}
+
+	dispute, ok := startDispute(ctx, c, &assignment, payment, callerID, "cancellation contested: "+req.Comment, cancellation.ID)
+	if !ok {
+		_, revertErr := cancellationCollection().UpdateOne(ctx,
+			bson.M{"_id": cancellation.ID},
+			bson.M{"$set": bson.M{"status": models.CancellationProposed}, "$unset": bson.M{"solverComment": "", "respondedAt": ""}},
+		)
+		if revertErr != nil {
+			fmt.Printf("[ContestCancellation] failed to reopen cancellation %s: %v\n", cancellation.ID.Hex(), revertErr)
+		}
+		return
+	}
+
+	go CreateBuyerNotification(cancellation.BuyerID, models.NotifTypeCancelContested, "Cancellation Contested",
+		fmt.Sprintf("The solver contested the refund split for \"%s\": %s", assignment.Title, req.Comment),
+		assignment.ID, "assignment", models.PriorityHigh)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Cancellation contested; an admin will rule on the dispute", "dispute": dispute})
+}
+
+// executeCancellation cancels the assignment and settles the payment as the
+// split says. A retry after a failure picks up where the last attempt stopped.
+func executeCancellation(ctx context.Context, c *gin.Context, cancellation *models.Cancellation, actorID primitive.ObjectID) error {
+	assignment, err := loadAssignment(ctx, cancellation.AssignmentID)
+	if err != nil {
+		return fmt.Errorf("failed to load assignment: %w", err)
+	}
+	if assignment.Status != models.AssignmentCancelled {
+		reason := fmt.Sprintf("cancellation accepted with a %.0f%% refund", cancellation.Split.RefundPercent)
+		if err := transitionAssignment(ctx, &assignment, models.AssignmentCancelled, actorID, reason, nil); err != nil {
+			return err
+		}
+	}
+	return settleSplit(ctx, c, cancellationCollection(), cancellation.ID, cancellation.PaymentID, cancellation.Split,
+		"cancelled: "+cancellation.Reason, &cancellation.RefundID, &cancellation.PayoutID)
+}
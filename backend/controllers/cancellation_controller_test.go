@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/Aashishvatwani/homeworld/models"
+)
+
+func TestNewRefundSplit(t *testing.T) {
+	bank := models.Payment{PaymentMethod: "bank", Amount: 1000, Commission: 100, SolverAmount: 900}
+	onchain := models.Payment{PaymentMethod: "onchain", Amount: 1000, Commission: 100, SolverAmount: 900}
+
+	cases := []struct {
+		name    string
+		payment models.Payment
+		percent float64
+		want    models.RefundSplit
+	}{
+		{"bank full refund returns the fee", bank, 100,
+			models.RefundSplit{RefundPercent: 100, CustomerRefund: 1000}},
+		{"bank over 100 is capped", bank, 140,
+			models.RefundSplit{RefundPercent: 100, CustomerRefund: 1000}},
+		{"bank half refund keeps the fee", bank, 50,
+			models.RefundSplit{RefundPercent: 50, PlatformFee: 100, CustomerRefund: 450, SolverAmount: 450}},
+		{"bank no refund", bank, 0,
+			models.RefundSplit{PlatformFee: 100, SolverAmount: 900}},
+		{"escrow rounds up to full", onchain, 50,
+			models.RefundSplit{RefundPercent: 100, CustomerRefund: 1000}},
+		{"escrow rounds down to release", onchain, 49,
+			models.RefundSplit{PlatformFee: 100, SolverAmount: 900}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.want.Source = models.RefundSourceRules
+			got := newRefundSplit(tc.payment, tc.percent, models.RefundSourceRules)
+			if got != tc.want {
+				t.Errorf("newRefundSplit(%v%%) = %+v, want %+v", tc.percent, got, tc.want)
+			}
+		})
+	}
+}
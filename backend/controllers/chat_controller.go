@@ -21,6 +21,15 @@ func CreateChat(c *gin.Context) {
 		return
 	}
 
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+	if !isChatParticipant(callerID, chat) {
+		forbidden(c, "You can only create chats you participate in")
+		return
+	}
+
 	// Initialize empty messages array (CRITICAL: prevents null error)
 	if chat.Messages == nil {
 		chat.Messages = []models.Message{}
@@ -53,22 +62,59 @@ func SendMessage(c *gin.Context) {
 	objID, _ := primitive.ObjectIDFromHex(chatID)
 	fmt.Printf("[SendMessage] Received request for chatID: %s\n", chatID)
 
-	var message models.Message
-	if err := c.ShouldBindJSON(&message); err != nil {
+	// attachmentIds reference files uploaded to this chat via POST /api/attachments
+	var req struct {
+		models.Message
+		AttachmentIDs []string `json:"attachmentIds"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
 		fmt.Printf("[SendMessage] Error binding JSON: %v\n", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	message := req.Message
 
-	message.ID = primitive.NewObjectID()
-	message.Timestamp = time.Now()
-	fmt.Printf("[SendMessage] Message prepared - ID: %s, SenderRole: %s, Content: %s\n",
-		message.ID.Hex(), message.SenderRole, message.Content)
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	chatCollection := config.DB.Collection("chats")
+
+	// Only the buyer and solver of this chat may post to it
+	var chat models.Chat
+	if err := chatCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&chat); err != nil {
+		fmt.Printf("[SendMessage] Error: Chat with ID %s not found\n", chatID)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chat not found. Please create a chat first."})
+		return
+	}
+	if !isChatParticipant(callerID, chat) {
+		forbidden(c, "You are not a participant in this chat")
+		return
+	}
+
+	attachments, err := claimAttachments(ctx, req.AttachmentIDs, callerID, models.AttachmentParentChat, objID, models.AttachmentChat)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	message.Attachments = attachments
+
+	// Sender identity comes from the token, not the request body
+	message.ID = primitive.NewObjectID()
+	message.SenderID = callerID
+	if callerID == chat.BuyerID {
+		message.SenderRole = "buyer"
+	} else {
+		message.SenderRole = "solver"
+	}
+	message.Timestamp = time.Now()
+	fmt.Printf("[SendMessage] Message prepared - ID: %s, SenderRole: %s, Content: %s\n",
+		message.ID.Hex(), message.SenderRole, message.Content)
+
 	fmt.Printf("[SendMessage] Updating chat document with new message\n")
 
 	// Push the entire message object, not just content
@@ -95,16 +141,6 @@ func SendMessage(c *gin.Context) {
 	fmt.Printf("[SendMessage] Message saved to database successfully (matched: %d, modified: %d)\n",
 		result.MatchedCount, result.ModifiedCount)
 
-	// Get chat details to notify the recipient
-	var chat models.Chat
-	err = chatCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&chat)
-	if err != nil {
-		fmt.Printf("[SendMessage] Error retrieving chat details: %v\n", err)
-	} else {
-		fmt.Printf("[SendMessage] Chat details retrieved - BuyerID: %s, SolverID: %s\n",
-			chat.BuyerID.Hex(), chat.SolverID.Hex())
-	}
-
 	// Notify recipient (if sender is buyer, notify solver, and vice versa)
 	var recipientID primitive.ObjectID
 	if message.SenderRole == "buyer" {
@@ -156,6 +192,15 @@ func GetChat(c *gin.Context) {
 		return
 	}
 
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+	if !isChatParticipant(callerID, chat) {
+		forbidden(c, "You are not a participant in this chat")
+		return
+	}
+
 	c.JSON(http.StatusOK, chat)
 }
 
@@ -176,7 +221,22 @@ func NegotiatePrice(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
 	chatCollection := config.DB.Collection("chats")
+	var chat models.Chat
+	if err := chatCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&chat); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chat not found"})
+		return
+	}
+	if !isChatParticipant(callerID, chat) {
+		forbidden(c, "You are not a participant in this chat")
+		return
+	}
+
 	_, err := chatCollection.UpdateOne(
 		ctx,
 		bson.M{"_id": objID},
@@ -191,29 +251,20 @@ func NegotiatePrice(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Price negotiated successfully"})
-}
-
-// POST /api/chat/fix-messages - Fix chats with null messages array (Migration helper)
-func FixChatMessages(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	chatCollection := config.DB.Collection("chats")
-
-	// Update all chats where messages is null
-	result, err := chatCollection.UpdateMany(
-		ctx,
-		bson.M{"messages": nil},
-		bson.M{"$set": bson.M{"messages": []models.Message{}}},
-	)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fix chats"})
-		return
+	// A deadline the buyer agrees with the accepted solver replaces the one
+	// being enforced, and restarts its reminders
+	if callerID == chat.BuyerID && !priceReq.AgreedDeadline.IsZero() {
+		_, err := config.DB.Collection("assignments").UpdateOne(ctx,
+			bson.M{"_id": chat.AssignmentID, "solverId": chat.SolverID, "status": bson.M{"$in": deadlineTrackedStatuses}},
+			bson.M{
+				"$set":   bson.M{"dueAt": priceReq.AgreedDeadline},
+				"$unset": bson.M{"remindersSent": "", "overdueAt": ""},
+			},
+		)
+		if err != nil {
+			fmt.Printf("[NegotiatePrice] failed to update due date of assignment %s: %v\n", chat.AssignmentID.Hex(), err)
+		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message":     "Chats fixed successfully",
-		"fixed_count": result.ModifiedCount,
-	})
+	c.JSON(http.StatusOK, gin.H{"message": "Price negotiated successfully"})
 }
@@ -3,6 +3,7 @@ package controllers
 import (
 	"net/http"
 
+	"github.com/Aashishvatwani/homeworld/audit"
 	"github.com/Aashishvatwani/homeworld/utils"
 	"github.com/gin-gonic/gin"
 )
@@ -32,6 +33,10 @@ func TestCreateEscrow(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, audit.ActionEscrowCreate, audit.TargetAssignment, req.AssignmentID, nil, gin.H{
+		"buyer": req.BuyerAddress, "solver": req.SolverAddress, "amountInWei": req.AmountInWei, "txHash": txHash,
+	})
+
 	c.JSON(http.StatusOK, gin.H{
 		"message":      "Escrow created successfully on Sepolia",
 		"txHash":       txHash,
@@ -81,6 +86,8 @@ func TestReleasePayment(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, audit.ActionEscrowRelease, audit.TargetAssignment, req.AssignmentID, nil, gin.H{"txHash": txHash})
+
 	c.JSON(http.StatusOK, gin.H{
 		"message":  "Payment released successfully",
 		"txHash":   txHash,
@@ -114,6 +121,8 @@ func TestMarkCompleted(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, audit.ActionEscrowComplete, audit.TargetAssignment, req.AssignmentID, nil, gin.H{"txHash": txHash})
+
 	c.JSON(http.StatusOK, gin.H{
 		"message":  "Assignment marked as completed successfully",
 		"txHash":   txHash,
@@ -163,6 +172,8 @@ func TestRefundPayment(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, audit.ActionEscrowRefund, audit.TargetAssignment, req.AssignmentID, nil, gin.H{"txHash": txHash})
+
 	c.JSON(http.StatusOK, gin.H{
 		"message":  "Payment refunded successfully",
 		"txHash":   txHash,
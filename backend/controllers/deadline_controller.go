@@ -0,0 +1,261 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/Aashishvatwani/homeworld/scheduler"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	deadlineJobInterval = 5 * time.Minute
+	// missedDeadlinePenalty is taken off the solver's 0-1 reliability score
+	// each time an assignment goes overdue.
+	missedDeadlinePenalty = 0.1
+)
+
+// deadlineTrackedStatuses are the states in which the solver still owes work.
+var deadlineTrackedStatuses = bson.A{
+	models.AssignmentAccepted,
+	models.AssignmentInProgress,
+	models.AssignmentRevisionRequested,
+}
+
+// deadlineReminders are sent to the solver before DueAt, most urgent first.
+// Sending one also marks the earlier ones as sent, so a solver accepted an
+// hour before the deadline gets a single reminder.
+var deadlineReminders = []struct {
+	Key    string
+	Before time.Duration
+}{
+	{Key: "2h", Before: 2 * time.Hour},
+	{Key: "24h", Before: 24 * time.Hour},
+}
+
+// DeadlineJobs are the background jobs that enforce assignment deadlines.
+func DeadlineJobs() []scheduler.Job {
+	return []scheduler.Job{
+		{Name: "deadline-reminders", Interval: deadlineJobInterval, Run: sendDeadlineReminders},
+		{Name: "deadline-overdue", Interval: deadlineJobInterval, Run: markOverdueAssignments},
+	}
+}
+
+// solverDueAt is the deadline a newly accepted solver is held to: the one
+// agreed in their chat with the buyer, or the assignment's own deadline.
+func solverDueAt(ctx context.Context, assignment models.Assignment, solverID primitive.ObjectID) time.Time {
+	var chat models.Chat
+	err := config.DB.Collection("chats").FindOne(ctx, bson.M{
+		"assignmentId":   assignment.ID,
+		"solverId":       solverID,
+		"agreedDeadline": bson.M{"$gt": time.Time{}},
+	}).Decode(&chat)
+	if err == nil {
+		return chat.AgreedDeadline
+	}
+	return assignment.Deadline
+}
+
+// sendDeadlineReminders notifies solvers whose deadline falls within a
+// reminder window. Each reminder is claimed with a conditional update before
+// it is sent, so overlapping runs never send it twice.
+func sendDeadlineReminders(ctx context.Context, now time.Time) error {
+	assignments := config.DB.Collection("assignments")
+	for i, reminder := range deadlineReminders {
+		covered := bson.A{}
+		for _, r := range deadlineReminders[i:] {
+			covered = append(covered, r.Key)
+		}
+
+		cursor, err := assignments.Find(ctx, bson.M{
+			"status":        bson.M{"$in": deadlineTrackedStatuses},
+			"dueAt":         bson.M{"$gt": now, "$lte": now.Add(reminder.Before)},
+			"remindersSent": bson.M{"$ne": reminder.Key},
+		})
+		if err != nil {
+			return err
+		}
+		var due []models.Assignment
+		if err := cursor.All(ctx, &due); err != nil {
+			return err
+		}
+
+		for _, a := range due {
+			result, err := assignments.UpdateOne(ctx,
+				bson.M{"_id": a.ID, "remindersSent": bson.M{"$ne": reminder.Key}},
+				bson.M{"$addToSet": bson.M{"remindersSent": bson.M{"$each": covered}}},
+			)
+			if err != nil {
+				return err
+			}
+			if result.ModifiedCount == 0 {
+				continue
+			}
+			CreateSolverNotification(a.SolverID, models.NotifTypeDeadlineReminder, "Deadline Approaching",
+				fmt.Sprintf("\"%s\" is due in %s, at %s.", a.Title, approxDuration(a.DueAt.Sub(now)), a.DueAt.UTC().Format(time.RFC1123)),
+				a.ID, "assignment", models.PriorityHigh)
+		}
+	}
+	return nil
+}
+
+// markOverdueAssignments flags assignments whose deadline passed without a
+// delivery, lowers the solver's reliability and tells both parties.
+func markOverdueAssignments(ctx context.Context, now time.Time) error {
+	assignments := config.DB.Collection("assignments")
+	cursor, err := assignments.Find(ctx, bson.M{
+		"status":    bson.M{"$in": deadlineTrackedStatuses},
+		"dueAt":     bson.M{"$lte": now},
+		"overdueAt": bson.M{"$exists": false},
+	})
+	if err != nil {
+		return err
+	}
+	var overdue []models.Assignment
+	if err := cursor.All(ctx, &overdue); err != nil {
+		return err
+	}
+
+	for _, a := range overdue {
+		result, err := assignments.UpdateOne(ctx,
+			bson.M{"_id": a.ID, "status": bson.M{"$in": deadlineTrackedStatuses}, "overdueAt": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"overdueAt": now}},
+		)
+		if err != nil {
+			return err
+		}
+		if result.ModifiedCount == 0 {
+			continue
+		}
+		if err := penalizeSolver(ctx, a.SolverID, missedDeadlinePenalty, "missedDeadlines"); err != nil {
+			fmt.Printf("[markOverdueAssignments] failed to lower reliability of solver %s: %v\n", a.SolverID.Hex(), err)
+		}
+
+		CreateSolverNotification(a.SolverID, models.NotifTypeAssignmentOverdue, "Deadline Missed",
+			fmt.Sprintf("The deadline for \"%s\" has passed. Deliver as soon as possible; the buyer may now cancel for a full refund.", a.Title),
+			a.ID, "assignment", models.PriorityHigh)
+		CreateBuyerNotification(a.UserID, models.NotifTypeAssignmentOverdue, "Assignment Overdue",
+			fmt.Sprintf("\"%s\" passed its deadline without a delivery. You can keep waiting, or cancel it for a full refund.", a.Title),
+			a.ID, "assignment", models.PriorityHigh)
+	}
+	return nil
+}
+
+// penalizeSolver lowers the solver's reliability by penalty, never below
+// zero, and adds one to the counter field recording why. Solvers created
+// before reliability was tracked start from 1.
+func penalizeSolver(ctx context.Context, solverID primitive.ObjectID, penalty float64, counter string) error {
+	_, err := config.DB.Collection("users").UpdateOne(ctx, bson.M{"_id": solverID}, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"reliability": bson.M{"$max": bson.A{0, bson.M{"$subtract": bson.A{
+				bson.M{"$ifNull": bson.A{"$reliability", 1}}, penalty,
+			}}}},
+			counter: bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$" + counter, 0}}, 1}},
+		}}},
+	})
+	return err
+}
+
+// approxDuration renders d as whole hours, or minutes under an hour.
+func approxDuration(d time.Duration) string {
+	if d >= time.Hour {
+		h := int(math.Round(d.Hours()))
+		if h == 1 {
+			return "about 1 hour"
+		}
+		return fmt.Sprintf("about %d hours", h)
+	}
+	return fmt.Sprintf("%d minutes", int(math.Ceil(d.Minutes())))
+}
+
+// POST /api/assignments/:id/cancel-overdue - Cancel an overdue assignment (owner only)
+// The buyer's payment, if any, is refunded in full.
+func CancelOverdueAssignment(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment ID"})
+		return
+	}
+
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	assignment, err := loadAssignment(ctx, objID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+		return
+	}
+	if !isAssignmentOwner(callerID, assignment) {
+		forbidden(c, "Only the assignment owner can cancel it")
+		return
+	}
+	if assignment.OverdueAt.IsZero() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Assignment is not overdue"})
+		return
+	}
+	if _, err := openCancellation(ctx, assignment.ID); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "A cancellation is already pending for this assignment"})
+		return
+	}
+	if escrowFrozen(ctx, c, assignment.ID) {
+		return
+	}
+
+	payment, err := paidPayment(ctx, assignment)
+	if err != nil && err != mongo.ErrNoDocuments {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load payment"})
+		return
+	}
+	hasPayment := err == nil
+
+	if !models.CanTransitionAssignment(assignment.Status, models.AssignmentCancelled) {
+		respondTransitionError(c, &models.IllegalTransitionError{From: assignment.Status, To: models.AssignmentCancelled})
+		return
+	}
+
+	// Refund before cancelling, so a failed refund leaves the assignment
+	// overdue and the buyer can simply try again. The refund claims the
+	// payment, so a late delivery cannot release it in the meantime.
+	refundID := ""
+	if hasPayment {
+		refundID, err = refundPayment(ctx, c, payment, payment.Amount, "deadline missed")
+		if err != nil {
+			fmt.Printf("[CancelOverdueAssignment] refund for assignment %s failed: %v\n", assignment.ID.Hex(), err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Refund failed; the assignment has not been cancelled, please try again"})
+			return
+		}
+	}
+
+	if err := transitionAssignment(ctx, &assignment, models.AssignmentCancelled, callerID, "cancelled after missing the deadline", nil); err != nil {
+		if hasPayment {
+			// A retry finds no paid payment and only cancels
+			fmt.Printf("[CancelOverdueAssignment] assignment %s refunded (%s) but not cancelled: %v\n", assignment.ID.Hex(), refundID, err)
+		}
+		respondTransitionError(c, err)
+		return
+	}
+
+	go CreateSolverNotification(assignment.SolverID, models.NotifTypeAssignmentCancelled, "Assignment Cancelled",
+		fmt.Sprintf("The buyer cancelled \"%s\" after the deadline passed.", assignment.Title),
+		assignment.ID, "assignment", models.PriorityHigh)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Assignment cancelled",
+		"assignment_id": assignment.ID.Hex(),
+		"refunded":      hasPayment,
+		"refund_id":     refundID,
+	})
+}
@@ -0,0 +1,244 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/middleware"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/Aashishvatwani/homeworld/scheduler"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+// soleLease is held by whichever replica asks, as if it were the only one.
+type soleLease struct{}
+
+func (soleLease) Acquire(context.Context, string, string, time.Duration, time.Time) (bool, error) {
+	return true, nil
+}
+
+var deadlineTestNow = time.Date(2026, time.March, 4, 10, 0, 0, 0, time.UTC)
+
+// runDeadlineJob runs the named job from DeadlineJobs at deadlineTestNow.
+func runDeadlineJob(mt *mtest.T, name string) {
+	mt.Helper()
+	s := scheduler.New(soleLease{}, fixedClock(deadlineTestNow))
+	for _, job := range DeadlineJobs() {
+		if job.Name != name {
+			continue
+		}
+		if ran, err := s.RunJob(context.Background(), job); err != nil || !ran {
+			mt.Fatalf("RunJob(%s) = %v, %v", name, ran, err)
+		}
+		return
+	}
+	mt.Fatalf("no job %q", name)
+}
+
+// startedCommands drains the commands sent so far in the subtest.
+func startedCommands(mt *mtest.T) []bson.Raw {
+	var cmds []bson.Raw
+	for e := mt.GetStartedEvent(); e != nil; e = mt.GetStartedEvent() {
+		cmds = append(cmds, e.Command)
+	}
+	return cmds
+}
+
+func countCommands(cmds []bson.Raw, name, coll string) int {
+	n := 0
+	for _, cmd := range cmds {
+		if v, err := cmd.LookupErr(name); err == nil && v.StringValue() == coll {
+			n++
+		}
+	}
+	return n
+}
+
+func updated(n int) bson.D {
+	return mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: n})
+}
+
+func TestSendDeadlineReminders(t *testing.T) {
+	solver := primitive.NewObjectID()
+	due := bson.D{
+		{Key: "_id", Value: primitive.NewObjectID()},
+		{Key: "title", Value: "Thesis review"},
+		{Key: "status", Value: models.AssignmentInProgress},
+		{Key: "solverId", Value: solver},
+		{Key: "dueAt", Value: deadlineTestNow.Add(90 * time.Minute)},
+	}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("sends the most urgent reminder once", func(mt *mtest.T) {
+		config.DB = mt.Client.Database("test")
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test.assignments", mtest.FirstBatch, due), // 2h window
+			updated(1),                    // claim
+			mtest.CreateSuccessResponse(), // notification
+			mtest.CreateCursorResponse(0, "test.assignments", mtest.FirstBatch), // 24h window, already covered
+		)
+		runDeadlineJob(mt, "deadline-reminders")
+
+		cmds := startedCommands(mt)
+		find := cmds[0].Lookup("filter", "dueAt")
+		if gt := find.Document().Lookup("$gt").Time(); !gt.Equal(deadlineTestNow) {
+			mt.Fatalf("window starts at %s, want the clock's %s", gt, deadlineTestNow)
+		}
+		if lte := find.Document().Lookup("$lte").Time(); !lte.Equal(deadlineTestNow.Add(2 * time.Hour)) {
+			mt.Fatalf("window ends at %s, want 2h after the clock", lte)
+		}
+		claim := cmds[1].Lookup("updates").Array().Index(0).Value().Document()
+		covered := claim.Lookup("u", "$addToSet", "remindersSent", "$each").Array()
+		if vals, _ := covered.Values(); len(vals) != 2 {
+			mt.Fatalf("claim marks %v sent, want both 2h and 24h", covered)
+		}
+		if n := countCommands(cmds, "insert", "notifications"); n != 1 {
+			mt.Fatalf("%d notifications sent, want 1", n)
+		}
+		doc := cmds[2].Lookup("documents").Array().Index(0).Value().Document()
+		if msg := doc.Lookup("message").StringValue(); !strings.Contains(msg, "about 2 hours") {
+			mt.Fatalf("message %q does not give the time left from the clock", msg)
+		}
+	})
+
+	mt.Run("re-run after another run claimed it", func(mt *mtest.T) {
+		config.DB = mt.Client.Database("test")
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test.assignments", mtest.FirstBatch, due),
+			updated(0), // the claim finds the reminder already sent
+			mtest.CreateCursorResponse(0, "test.assignments", mtest.FirstBatch),
+		)
+		runDeadlineJob(mt, "deadline-reminders")
+
+		if n := countCommands(startedCommands(mt), "insert", "notifications"); n != 0 {
+			mt.Fatalf("%d notifications sent on re-run, want 0", n)
+		}
+	})
+}
+
+func TestMarkOverdueAssignments(t *testing.T) {
+	buyer := primitive.NewObjectID()
+	solver := primitive.NewObjectID()
+	overdue := bson.D{
+		{Key: "_id", Value: primitive.NewObjectID()},
+		{Key: "title", Value: "Circuit design"},
+		{Key: "userId", Value: buyer},
+		{Key: "status", Value: models.AssignmentAccepted},
+		{Key: "solverId", Value: solver},
+		{Key: "dueAt", Value: deadlineTestNow.Add(-time.Hour)},
+	}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("flags, penalizes and notifies once", func(mt *mtest.T) {
+		config.DB = mt.Client.Database("test")
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test.assignments", mtest.FirstBatch, overdue),
+			updated(1),                    // claim
+			updated(1),                    // reliability penalty
+			mtest.CreateSuccessResponse(), // solver notification
+			mtest.CreateSuccessResponse(), // buyer notification
+		)
+		runDeadlineJob(mt, "deadline-overdue")
+
+		cmds := startedCommands(mt)
+		if lte := cmds[0].Lookup("filter", "dueAt", "$lte").Time(); !lte.Equal(deadlineTestNow) {
+			mt.Fatalf("overdue cutoff is %s, want the clock's %s", lte, deadlineTestNow)
+		}
+		claim := cmds[1].Lookup("updates").Array().Index(0).Value().Document()
+		if at := claim.Lookup("u", "$set", "overdueAt").Time(); !at.Equal(deadlineTestNow) {
+			mt.Fatalf("overdueAt = %s, want the clock's %s", at, deadlineTestNow)
+		}
+		if n := countCommands(cmds, "update", "users"); n != 1 {
+			mt.Fatalf("solver penalized %d times, want 1", n)
+		}
+		if n := countCommands(cmds, "insert", "notifications"); n != 2 {
+			mt.Fatalf("%d notifications sent, want 2", n)
+		}
+	})
+
+	mt.Run("re-run after another run claimed it", func(mt *mtest.T) {
+		config.DB = mt.Client.Database("test")
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test.assignments", mtest.FirstBatch, overdue),
+			updated(0),
+		)
+		runDeadlineJob(mt, "deadline-overdue")
+
+		cmds := startedCommands(mt)
+		if n := countCommands(cmds, "update", "users"); n != 0 {
+			mt.Fatalf("solver penalized %d times on re-run, want 0", n)
+		}
+		if n := countCommands(cmds, "insert", "notifications"); n != 0 {
+			mt.Fatalf("%d notifications sent on re-run, want 0", n)
+		}
+	})
+}
+
+// A failed refund must leave the assignment overdue rather than cancelled,
+// so the buyer can cancel again and the refund is retried.
+func TestCancelOverdueAssignmentRefundsBeforeCancelling(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("SMART_CONTRACT_ADDRESS", "") // the escrow refund fails
+
+	buyer := primitive.NewObjectID()
+	assignmentID := primitive.NewObjectID()
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("refund fails", func(mt *mtest.T) {
+		config.DB = mt.Client.Database("test")
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test.assignments", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: assignmentID},
+				{Key: "userId", Value: buyer},
+				{Key: "solverId", Value: primitive.NewObjectID()},
+				{Key: "status", Value: models.AssignmentInProgress},
+				{Key: "overdueAt", Value: deadlineTestNow},
+			}),
+			mtest.CreateCursorResponse(0, "test.cancellations", mtest.FirstBatch),
+			mtest.CreateCursorResponse(0, "test.disputes", mtest.FirstBatch),
+			mtest.CreateCursorResponse(0, "test.payments", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: primitive.NewObjectID()},
+				{Key: "assignmentId", Value: assignmentID},
+				{Key: "buyerId", Value: buyer},
+				{Key: "paymentMethod", Value: "onchain"},
+				{Key: "amount", Value: 500.0},
+				{Key: "status", Value: "paid"},
+			}),
+			updated(1), // claim paid -> refunding
+			mtest.CreateCursorResponse(0, "test.users", mtest.FirstBatch),
+			updated(1), // reopen to paid
+		)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+		c.Params = gin.Params{{Key: "id", Value: assignmentID.Hex()}}
+		c.Set(middleware.ContextUserID, buyer)
+
+		CancelOverdueAssignment(c)
+
+		if w.Code != http.StatusBadGateway {
+			mt.Fatalf("status = %d, want %d; body %s", w.Code, http.StatusBadGateway, w.Body.String())
+		}
+		if strings.Contains(w.Body.String(), "support") {
+			mt.Fatalf("response promises a retry nothing performs: %s", w.Body.String())
+		}
+		if n := countCommands(startedCommands(mt), "update", "assignments"); n != 0 {
+			mt.Fatalf("assignment updated %d times, want it left overdue", n)
+		}
+	})
+}
@@ -0,0 +1,443 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultMaxRevisionRounds = 2
+	maxDeliverableFiles      = 20
+	maxDeliverableNoteLength = 5000
+	maxReviewCommentLength   = 5000
+)
+
+// maxRevisionRounds is how many revisions a buyer may request per
+// assignment, from MAX_REVISION_ROUNDS.
+func maxRevisionRounds() int {
+	if n, err := strconv.Atoi(os.Getenv("MAX_REVISION_ROUNDS")); err == nil && n >= 0 {
+		return n
+	}
+	return defaultMaxRevisionRounds
+}
+
+func deliverableCollection() *mongo.Collection {
+	return config.DB.Collection("deliverables")
+}
+
+// latestDeliverable returns the most recent submission for the assignment.
+func latestDeliverable(ctx context.Context, assignmentID primitive.ObjectID) (models.Deliverable, error) {
+	return findLatestDeliverable(ctx, bson.M{"assignmentId": assignmentID})
+}
+
+// findLatestDeliverable returns the most recent submission matching filter.
+func findLatestDeliverable(ctx context.Context, filter bson.M) (models.Deliverable, error) {
+	var deliverable models.Deliverable
+	err := deliverableCollection().FindOne(ctx, filter,
+		options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}}),
+	).Decode(&deliverable)
+	return deliverable, err
+}
+
+// validateDeliverableFiles checks the file references of a submission.
+func validateDeliverableFiles(files []models.DeliverableFile) error {
+	if len(files) > maxDeliverableFiles {
+		return fmt.Errorf("at most %d files can be delivered at once", maxDeliverableFiles)
+	}
+	for i := range files {
+		files[i].Name = strings.TrimSpace(files[i].Name)
+		if files[i].Name == "" {
+			return fmt.Errorf("every file needs a name")
+		}
+		u, err := url.Parse(files[i].URL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "ipfs") || (u.Host == "" && u.Opaque == "") {
+			return fmt.Errorf("file %q must have an https:// or ipfs:// url", files[i].Name)
+		}
+		if files[i].Size < 0 {
+			return fmt.Errorf("file %q has a negative size", files[i].Name)
+		}
+	}
+	return nil
+}
+
+// loadDeliverableForReview loads the deliverable in :deliverableId together
+// with its assignment and checks that the caller owns the assignment and that
+// the deliverable is the latest one awaiting review. On milestone assignments
+// each milestone's latest submission can be reviewed.
+func loadDeliverableForReview(ctx context.Context, c *gin.Context, callerID primitive.ObjectID) (models.Deliverable, models.Assignment, bool) {
+	assignmentID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment ID"})
+		return models.Deliverable{}, models.Assignment{}, false
+	}
+	deliverableID, err := primitive.ObjectIDFromHex(c.Param("deliverableId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid deliverable ID"})
+		return models.Deliverable{}, models.Assignment{}, false
+	}
+
+	assignment, err := loadAssignment(ctx, assignmentID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+		return models.Deliverable{}, models.Assignment{}, false
+	}
+	if !isAssignmentOwner(callerID, assignment) {
+		forbidden(c, "Only the assignment owner can review deliverables")
+		return models.Deliverable{}, models.Assignment{}, false
+	}
+
+	scope := bson.M{"assignmentId": assignmentID}
+	if len(assignment.Milestones) > 0 {
+		var requested models.Deliverable
+		if err := deliverableCollection().FindOne(ctx, bson.M{"_id": deliverableID, "assignmentId": assignmentID}).Decode(&requested); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Deliverable not found"})
+			return models.Deliverable{}, models.Assignment{}, false
+		}
+		scope["milestoneId"] = requested.MilestoneID
+	}
+	latest, err := findLatestDeliverable(ctx, scope)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deliverable not found"})
+		return models.Deliverable{}, models.Assignment{}, false
+	}
+	if latest.ID != deliverableID {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only the latest deliverable can be reviewed", "latest_id": latest.ID.Hex()})
+		return models.Deliverable{}, models.Assignment{}, false
+	}
+	if latest.Status != models.DeliverableSubmitted {
+		c.JSON(http.StatusConflict, gin.H{"error": "Deliverable has already been reviewed"})
+		return models.Deliverable{}, models.Assignment{}, false
+	}
+	return latest, assignment, true
+}
+
+// POST /api/assignments/:id/deliverables - Submit work (accepted solver only)
+// Body: { "note": "...", "files": [{ "name": "report.pdf", "url": "https://...", "contentType": "application/pdf", "size": 12345 }], "attachmentIds": ["<id>"] }
+// attachmentIds reference files the solver uploaded to the assignment via POST /api/attachments.
+// Moves the assignment to delivered, starting it first if work had not begun.
+// On milestone assignments pass "milestoneId"; the assignment is delivered
+// once every milestone has been.
+func SubmitDeliverable(c *gin.Context) {
+	assignmentID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment ID"})
+		return
+	}
+
+	var req struct {
+		Note          string                   `json:"note"`
+		Files         []models.DeliverableFile `json:"files"`
+		AttachmentIDs []string                 `json:"attachmentIds"`
+		MilestoneID   string                   `json:"milestoneId"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Note = strings.TrimSpace(req.Note)
+	if req.Note == "" && len(req.Files) == 0 && len(req.AttachmentIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A deliverable needs a note or at least one file"})
+		return
+	}
+	if len(req.Note) > maxDeliverableNoteLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("note must be at most %d characters", maxDeliverableNoteLength)})
+		return
+	}
+	if err := validateDeliverableFiles(req.Files); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	assignment, err := loadAssignment(ctx, assignmentID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+		return
+	}
+	if !isSelf(callerID, assignment.SolverID) {
+		forbidden(c, "Only the accepted solver can deliver this assignment")
+		return
+	}
+	if _, err := openCancellation(ctx, assignmentID); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "The buyer has asked to cancel this assignment; accept or contest the cancellation first"})
+		return
+	}
+	var milestone models.Milestone
+	if len(assignment.Milestones) > 0 {
+		if milestone, ok = milestoneForSubmission(c, assignment, req.MilestoneID); !ok {
+			return
+		}
+	} else if req.MilestoneID != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Assignment has no milestones"})
+		return
+	}
+	attachments, err := claimAttachments(ctx, req.AttachmentIDs, callerID, models.AttachmentParentAssignment, assignmentID, models.AttachmentDeliverable)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if assignment.Status == models.AssignmentAccepted {
+		if err := transitionAssignment(ctx, &assignment, models.AssignmentInProgress, callerID, "work started with first delivery", nil); err != nil {
+			respondTransitionError(c, err)
+			return
+		}
+	}
+	// A milestone delivered while others are still owed leaves the work in progress
+	delivers := milestone.ID.IsZero() || assignment.MilestonesIn(milestone.ID, models.MilestoneSubmitted, models.MilestoneReleased)
+	if delivers && !models.CanTransitionAssignment(assignment.Status, models.AssignmentDelivered) {
+		respondTransitionError(c, &models.IllegalTransitionError{From: assignment.Status, To: models.AssignmentDelivered})
+		return
+	}
+	if !delivers && assignment.Status != models.AssignmentInProgress {
+		c.JSON(http.StatusConflict, gin.H{"error": "Assignment is " + assignment.Status + "; milestones can only be delivered while work is in progress"})
+		return
+	}
+
+	version := 1
+	if latest, err := latestDeliverable(ctx, assignmentID); err == nil {
+		version = latest.Version + 1
+	} else if err != mongo.ErrNoDocuments {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load previous deliverables"})
+		return
+	}
+
+	if req.Files == nil {
+		req.Files = []models.DeliverableFile{}
+	}
+	deliverable := models.Deliverable{
+		ID:           primitive.NewObjectID(),
+		AssignmentID: assignmentID,
+		SolverID:     callerID,
+		Version:      version,
+		Note:         req.Note,
+		Files:        req.Files,
+		Attachments:  attachments,
+		Status:       models.DeliverableSubmitted,
+		SubmittedAt:  time.Now(),
+		MilestoneID:  milestone.ID,
+	}
+	// The unique (assignmentId, version) index rejects a concurrent submission
+	if _, err := deliverableCollection().InsertOne(ctx, deliverable); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Another submission was made at the same time; reload and retry"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save deliverable"})
+		return
+	}
+
+	removeDeliverable := func() {
+		if _, delErr := deliverableCollection().DeleteOne(ctx, bson.M{"_id": deliverable.ID}); delErr != nil {
+			fmt.Printf("[SubmitDeliverable] failed to remove orphaned deliverable %s: %v\n", deliverable.ID.Hex(), delErr)
+		}
+	}
+
+	if !milestone.ID.IsZero() {
+		err := updateMilestone(ctx, assignmentID, milestone.ID, models.MilestoneFunded,
+			bson.M{"status": models.MilestoneSubmitted, "deliverableId": deliverable.ID, "submittedAt": deliverable.SubmittedAt},
+		)
+		if err != nil {
+			removeDeliverable()
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if delivers {
+		reason := fmt.Sprintf("deliverable v%d submitted", version)
+		if err := transitionAssignment(ctx, &assignment, models.AssignmentDelivered, callerID, reason, nil); err != nil {
+			removeDeliverable()
+			if !milestone.ID.IsZero() {
+				revertErr := updateMilestone(ctx, assignmentID, milestone.ID, models.MilestoneSubmitted,
+					bson.M{"status": models.MilestoneFunded}, "deliverableId", "submittedAt",
+				)
+				if revertErr != nil {
+					fmt.Printf("[SubmitDeliverable] failed to reopen milestone %s: %v\n", milestone.ID.Hex(), revertErr)
+				}
+			}
+			respondTransitionError(c, err)
+			return
+		}
+	}
+
+	if !milestone.ID.IsZero() {
+		go CreateBuyerNotification(assignment.UserID, models.NotifTypeMilestoneSubmitted, "Milestone Delivered",
+			fmt.Sprintf("The solver delivered milestone %d (%s) of \"%s\". Review it to release its payment or request a revision.", milestone.Index, milestone.Title, assignment.Title),
+			assignmentID, "assignment", models.PriorityHigh)
+	} else {
+		go CreateBuyerNotification(assignment.UserID, models.NotifTypeAssignmentDelivered, "Work Delivered",
+			fmt.Sprintf("The solver delivered version %d of \"%s\". Review it to accept or request a revision.", version, assignment.Title),
+			assignmentID, "assignment", models.PriorityHigh)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Deliverable submitted", "deliverable": deliverable})
+}
+
+// GET /api/assignments/:id/deliverables - Every submission, newest first (owner or accepted solver)
+func GetDeliverables(c *gin.Context) {
+	assignmentID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment ID"})
+		return
+	}
+
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	assignment, err := loadAssignment(ctx, assignmentID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+		return
+	}
+	if !isAssignmentOwner(callerID, assignment) && !isSelf(callerID, assignment.SolverID) {
+		forbidden(c, "Only the assignment owner or its solver can view deliverables")
+		return
+	}
+
+	cursor, err := deliverableCollection().Find(ctx,
+		bson.M{"assignmentId": assignmentID},
+		options.Find().SetSort(bson.D{{Key: "version", Value: -1}}),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch deliverables"})
+		return
+	}
+	deliverables := []models.Deliverable{}
+	if err := cursor.All(ctx, &deliverables); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch deliverables"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliverables":        deliverables,
+		"revision_rounds":     assignment.RevisionRounds,
+		"max_revision_rounds": maxRevisionRounds(),
+	})
+}
+
+// POST /api/assignments/:id/deliverables/:deliverableId/accept - Accept the work (owner only)
+// Releases the payment to the solver and completes the assignment. A
+// milestone's deliverable releases that milestone's payment only.
+func AcceptDeliverable(c *gin.Context) {
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	deliverable, assignment, ok := loadDeliverableForReview(ctx, c, callerID)
+	if !ok {
+		return
+	}
+	acceptDelivery(ctx, c, &assignment, &deliverable, callerID)
+}
+
+// POST /api/assignments/:id/deliverables/:deliverableId/revision - Ask for changes (owner only)
+// Body: { "comments": "..." }. Limited to MAX_REVISION_ROUNDS per assignment,
+// or per milestone on milestone assignments.
+func RequestRevision(c *gin.Context) {
+	var req struct {
+		Comments string `json:"comments" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "comments are required"})
+		return
+	}
+	req.Comments = strings.TrimSpace(req.Comments)
+	if req.Comments == "" || len(req.Comments) > maxReviewCommentLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("comments must be 1-%d characters", maxReviewCommentLength)})
+		return
+	}
+
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	deliverable, assignment, ok := loadDeliverableForReview(ctx, c, callerID)
+	if !ok {
+		return
+	}
+	if !deliverable.MilestoneID.IsZero() {
+		requestMilestoneRevision(ctx, c, &assignment, deliverable, req.Comments, callerID)
+		return
+	}
+	limit := maxRevisionRounds()
+	if assignment.RevisionRounds >= limit {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":           "No revision rounds left; accept the work or open a dispute",
+			"revision_rounds": assignment.RevisionRounds,
+		})
+		return
+	}
+
+	now := time.Now()
+	result, err := deliverableCollection().UpdateOne(ctx,
+		bson.M{"_id": deliverable.ID, "status": models.DeliverableSubmitted},
+		bson.M{"$set": bson.M{"status": models.DeliverableRevisionRequested, "reviewComment": req.Comments, "reviewedAt": now}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update deliverable"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Deliverable has already been reviewed"})
+		return
+	}
+
+	reason := fmt.Sprintf("revision %d/%d requested on v%d", assignment.RevisionRounds+1, limit, deliverable.Version)
+	err = transitionAssignment(ctx, &assignment, models.AssignmentRevisionRequested, callerID, reason,
+		bson.M{"revisionRounds": assignment.RevisionRounds + 1},
+	)
+	if err != nil {
+		_, revertErr := deliverableCollection().UpdateOne(ctx,
+			bson.M{"_id": deliverable.ID},
+			bson.M{"$set": bson.M{"status": models.DeliverableSubmitted}, "$unset": bson.M{"reviewComment": "", "reviewedAt": ""}},
+		)
+		if revertErr != nil {
+			fmt.Printf("[RequestRevision] failed to reopen deliverable %s: %v\n", deliverable.ID.Hex(), revertErr)
+		}
+		respondTransitionError(c, err)
+		return
+	}
+
+	go CreateSolverNotification(assignment.SolverID, models.NotifTypeRevisionRequested, "Revision Requested",
+		fmt.Sprintf("The buyer requested changes to version %d of \"%s\": %s", deliverable.Version, assignment.Title, req.Comments),
+		assignment.ID, "assignment", models.PriorityHigh)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Revision requested",
+		"revision_rounds": assignment.RevisionRounds + 1,
+		"rounds_left":     limit - assignment.RevisionRounds - 1,
+	})
+}
@@ -0,0 +1,766 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/audit"
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/middleware"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/Aashishvatwani/homeworld/scheduler"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultDisputeEvidenceWindow   = 72 * time.Hour
+	defaultDisputeResolutionWindow = 120 * time.Hour
+	disputeJobInterval             = 15 * time.Minute
+	maxDisputeReasonLength         = 2000
+	maxDisputeStatementLength      = 5000
+	maxDisputeNotesLength          = 5000
+	// maxDisputeEvidence caps the statements on one dispute, both parties together.
+	maxDisputeEvidence = 20
+	// disputeLossPenalty is taken off the solver's reliability when a
+	// dispute is ruled fully against them.
+	disputeLossPenalty = 0.1
+)
+
+// disputeActiveStatuses are the states in which a dispute awaits a ruling.
+var disputeActiveStatuses = bson.A{models.DisputeOpen, models.DisputeUnderReview}
+
+func disputeCollection() *mongo.Collection {
+	return config.DB.Collection("disputes")
+}
+
+// disputeEvidenceWindow is how long the parties may submit evidence, from
+// DISPUTE_EVIDENCE_HOURS.
+func disputeEvidenceWindow() time.Duration {
+	if v, err := strconv.Atoi(os.Getenv("DISPUTE_EVIDENCE_HOURS")); err == nil && v > 0 {
+		return time.Duration(v) * time.Hour
+	}
+	return defaultDisputeEvidenceWindow
+}
+
+// disputeResolutionWindow is how long admins have to rule after a dispute
+// is opened, from DISPUTE_RESOLUTION_HOURS.
+func disputeResolutionWindow() time.Duration {
+	if v, err := strconv.Atoi(os.Getenv("DISPUTE_RESOLUTION_HOURS")); err == nil && v > 0 {
+		return time.Duration(v) * time.Hour
+	}
+	return defaultDisputeResolutionWindow
+}
+
+// openDisputeFor returns the assignment's unresolved dispute, if any.
+func openDisputeFor(ctx context.Context, assignmentID primitive.ObjectID) (models.Dispute, error) {
+	var dispute models.Dispute
+	err := disputeCollection().FindOne(ctx, bson.M{"assignmentId": assignmentID, "open": true}).Decode(&dispute)
+	return dispute, err
+}
+
+// escrowFrozen writes a conflict response and returns true when an open
+// dispute holds the assignment's payment. Only the ruling may move it.
+func escrowFrozen(ctx context.Context, c *gin.Context, assignmentID primitive.ObjectID) bool {
+	dispute, err := openDisputeFor(ctx, assignmentID)
+	if err == mongo.ErrNoDocuments {
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check for disputes"})
+		return true
+	}
+	c.JSON(http.StatusConflict, gin.H{
+		"error":      "Payment is frozen while the assignment is in dispute",
+		"dispute_id": dispute.ID.Hex(),
+	})
+	return true
+}
+
+// disputeParty returns the role the caller plays in the dispute, or "".
+func disputeParty(callerID primitive.ObjectID, dispute models.Dispute) string {
+	switch {
+	case isSelf(callerID, dispute.BuyerID):
+		return models.RoleBuyer
+	case isSelf(callerID, dispute.SolverID):
+		return models.RoleSolver
+	}
+	return ""
+}
+
+// loadDispute loads the dispute named in the URL for a party or an admin,
+// writing the error response when it is missing or the caller has no access.
+func loadDispute(ctx context.Context, c *gin.Context, callerID primitive.ObjectID) (models.Dispute, bool) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dispute ID"})
+		return models.Dispute{}, false
+	}
+	var dispute models.Dispute
+	if err := disputeCollection().FindOne(ctx, bson.M{"_id": objID}).Decode(&dispute); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dispute not found"})
+		return models.Dispute{}, false
+	}
+	if disputeParty(callerID, dispute) == "" && middleware.CurrentRole(c) != models.RoleAdmin {
+		forbidden(c, "Only the parties to a dispute or an admin can view it")
+		return models.Dispute{}, false
+	}
+	return dispute, true
+}
+
+// startDispute records a dispute on the assignment and moves it to
+// disputed, which freezes its payment. It writes the response on failure.
+func startDispute(ctx context.Context, c *gin.Context, assignment *models.Assignment, payment models.Payment, openedBy primitive.ObjectID, reason string, cancellationID primitive.ObjectID) (models.Dispute, bool) {
+	now := time.Now()
+	dispute := models.Dispute{
+		ID:             primitive.NewObjectID(),
+		AssignmentID:   assignment.ID,
+		PaymentID:      payment.ID,
+		BuyerID:        assignment.UserID,
+		SolverID:       payment.SolverID,
+		OpenedBy:       openedBy,
+		Reason:         reason,
+		CancellationID: cancellationID,
+		Status:         models.DisputeOpen,
+		Open:           true,
+		Evidence:       []models.DisputeEvidence{},
+		EvidenceDueAt:  now.Add(disputeEvidenceWindow()),
+		ResolveBy:      now.Add(disputeResolutionWindow()),
+		CreatedAt:      now,
+	}
+	if _, err := disputeCollection().InsertOne(ctx, dispute); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "A dispute is already open for this assignment"})
+			return models.Dispute{}, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open dispute"})
+		return models.Dispute{}, false
+	}
+	if err := transitionAssignment(ctx, assignment, models.AssignmentDisputed, openedBy, "dispute opened: "+reason, nil); err != nil {
+		if _, delErr := disputeCollection().DeleteOne(ctx, bson.M{"_id": dispute.ID}); delErr != nil {
+			fmt.Printf("[startDispute] failed to remove dispute %s: %v\n", dispute.ID.Hex(), delErr)
+		}
+		respondTransitionError(c, err)
+		return models.Dispute{}, false
+	}
+
+	recordAudit(c, audit.ActionDisputeOpen, audit.TargetDispute, dispute.ID.Hex(), nil, gin.H{
+		"assignmentId": assignment.ID.Hex(),
+		"paymentId":    payment.ID.Hex(),
+		"openedBy":     openedBy.Hex(),
+		"reason":       reason,
+	})
+
+	counterparty := dispute.SolverID
+	notify := CreateSolverNotification
+	if isSelf(openedBy, dispute.SolverID) {
+		counterparty, notify = dispute.BuyerID, CreateBuyerNotification
+	}
+	go notify(counterparty, models.NotifTypeDisputeOpened, "Dispute Opened",
+		fmt.Sprintf("A dispute was opened on \"%s\": %s. Submit your evidence by %s.", assignment.Title, reason, dispute.EvidenceDueAt.UTC().Format(time.RFC1123)),
+		dispute.ID, "dispute", models.PriorityHigh)
+	go NotifyAdmins(models.NotifTypeDisputeOpened, "New Dispute",
+		fmt.Sprintf("A dispute was opened on \"%s\". A ruling is due by %s.", assignment.Title, dispute.ResolveBy.UTC().Format(time.RFC1123)),
+		dispute.ID, "dispute")
+	return dispute, true
+}
+
+// POST /api/assignments/:id/disputes - Open a dispute (owner or accepted solver)
+// Body: { "reason": "..." }
+// The assignment's payment is frozen until an admin rules on the dispute.
+func OpenDispute(c *gin.Context) {
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required"})
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" || len(req.Reason) > maxDisputeReasonLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("reason must be 1-%d characters", maxDisputeReasonLength)})
+		return
+	}
+
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment ID"})
+		return
+	}
+
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	assignment, err := loadAssignment(ctx, objID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+		return
+	}
+	if !isAssignmentOwner(callerID, assignment) && !isSelf(callerID, assignment.SolverID) {
+		forbidden(c, "Only the assignment owner or its accepted solver can open a dispute")
+		return
+	}
+	if !models.CanTransitionAssignment(assignment.Status, models.AssignmentDisputed) {
+		respondTransitionError(c, &models.IllegalTransitionError{From: assignment.Status, To: models.AssignmentDisputed})
+		return
+	}
+	if _, err := openCancellation(ctx, assignment.ID); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "A cancellation is pending; the solver can contest it to open a dispute"})
+		return
+	}
+
+	payment, err := paidPayment(ctx, assignment)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusConflict, gin.H{"error": "No payment is held for this assignment, so there is nothing to dispute"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load payment"})
+		return
+	}
+
+	dispute, ok := startDispute(ctx, c, &assignment, payment, callerID, req.Reason, primitive.NilObjectID)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Dispute opened", "dispute": dispute})
+}
+
+// GET /api/assignments/:id/disputes - Disputes on an assignment, newest first (owner or solver)
+func GetAssignmentDisputes(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment ID"})
+		return
+	}
+
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	assignment, err := loadAssignment(ctx, objID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+		return
+	}
+	if !isAssignmentOwner(callerID, assignment) && !isSelf(callerID, assignment.SolverID) {
+		forbidden(c, "Only the assignment owner or its accepted solver can view its disputes")
+		return
+	}
+
+	cursor, err := disputeCollection().Find(ctx, bson.M{"assignmentId": objID},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch disputes"})
+		return
+	}
+	disputes := []models.Dispute{}
+	if err := cursor.All(ctx, &disputes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode disputes"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"disputes": disputes})
+}
+
+// GET /api/disputes/:id - A dispute with its evidence (parties or admin)
+func GetDispute(c *gin.Context) {
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	dispute, ok := loadDispute(ctx, c, callerID)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, dispute)
+}
+
+// POST /api/disputes/:id/evidence - Add a statement to a dispute (parties only)
+// Body: { "statement": "...", "attachmentIds": ["<id>", ...] }
+// Files are uploaded first to /api/attachments with parentType=dispute.
+func SubmitDisputeEvidence(c *gin.Context) {
+	var req struct {
+		Statement     string   `json:"statement" binding:"required"`
+		AttachmentIDs []string `json:"attachmentIds"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "statement is required"})
+		return
+	}
+	req.Statement = strings.TrimSpace(req.Statement)
+	if req.Statement == "" || len(req.Statement) > maxDisputeStatementLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("statement must be 1-%d characters", maxDisputeStatementLength)})
+		return
+	}
+
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	dispute, ok := loadDispute(ctx, c, callerID)
+	if !ok {
+		return
+	}
+	party := disputeParty(callerID, dispute)
+	if party == "" {
+		forbidden(c, "Only the parties to a dispute can submit evidence")
+		return
+	}
+	now := time.Now()
+	if !isDisputeAwaitingRuling(dispute) || now.After(dispute.EvidenceDueAt) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Evidence is no longer accepted for this dispute"})
+		return
+	}
+
+	attachments, err := claimAttachments(ctx, req.AttachmentIDs, callerID, models.AttachmentParentDispute, dispute.ID, models.AttachmentEvidence)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	evidence := models.DisputeEvidence{
+		ID:          primitive.NewObjectID(),
+		PartyID:     callerID,
+		Party:       party,
+		Statement:   req.Statement,
+		Attachments: attachments,
+		SubmittedAt: now,
+	}
+	result, err := disputeCollection().UpdateOne(ctx,
+		bson.M{
+			"_id":           dispute.ID,
+			"status":        bson.M{"$in": disputeActiveStatuses},
+			"evidenceDueAt": bson.M{"$gte": now},
+			fmt.Sprintf("evidence.%d", maxDisputeEvidence-1): bson.M{"$exists": false},
+		},
+		bson.M{"$push": bson.M{"evidence": evidence}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save evidence"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Evidence is closed or the dispute already has %d statements", maxDisputeEvidence)})
+		return
+	}
+
+	counterparty, notify := dispute.SolverID, CreateSolverNotification
+	if party == models.RoleSolver {
+		counterparty, notify = dispute.BuyerID, CreateBuyerNotification
+	}
+	go notify(counterparty, models.NotifTypeDisputeEvidence, "New Dispute Evidence",
+		"The other party added a statement to your dispute.", dispute.ID, "dispute", models.PriorityMedium)
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Evidence submitted", "evidence": evidence})
+}
+
+func isDisputeAwaitingRuling(dispute models.Dispute) bool {
+	return dispute.Status == models.DisputeOpen || dispute.Status == models.DisputeUnderReview
+}
+
+// disputeSorts are the sort orders accepted by ListDisputes.
+var disputeSorts = map[string]sortSpec{
+	"due":    {Field: "resolveBy"},
+	"newest": {Field: "createdAt", Desc: true},
+	"oldest": {Field: "createdAt"},
+}
+
+// GET /api/admin/disputes - The dispute queue (admin only)
+// Query: status (comma list, default open,under_review,failed),
+// assigned (me | none), breached (true), sort (due | newest | oldest), limit, cursor.
+func ListDisputes(c *gin.Context) {
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	statuses := splitList(c.DefaultQuery("status", strings.Join([]string{models.DisputeOpen, models.DisputeUnderReview, models.DisputeFailed}, ",")))
+	filter := bson.M{"status": bson.M{"$in": statuses}}
+	switch c.Query("assigned") {
+	case "":
+	case "me":
+		filter["assignedTo"] = callerID
+	case "none":
+		filter["assignedTo"] = bson.M{"$exists": false}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "assigned must be me or none"})
+		return
+	}
+	if c.Query("breached") == "true" {
+		filter["slaBreachedAt"] = bson.M{"$exists": true}
+	}
+	page, err := parsePageRequest(c, disputeSorts, "due")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var disputes []models.Dispute
+	next, err := findPage(ctx, disputeCollection(), filter, page, nil, &disputes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch disputes"})
+		return
+	}
+
+	resp := gin.H{"disputes": disputes}
+	if next != "" {
+		resp["next_cursor"] = next
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// POST /api/admin/disputes/:id/assign - Take a dispute for review (admin only)
+func AssignDispute(c *gin.Context) {
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	dispute, ok := loadDispute(ctx, c, callerID)
+	if !ok {
+		return
+	}
+	if disputeParty(callerID, dispute) != "" {
+		forbidden(c, "Admins cannot arbitrate disputes they are a party to")
+		return
+	}
+	if !isDisputeAwaitingRuling(dispute) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Dispute is " + dispute.Status})
+		return
+	}
+
+	result, err := disputeCollection().UpdateOne(ctx,
+		bson.M{"_id": dispute.ID, "status": dispute.Status},
+		bson.M{"$set": bson.M{"status": models.DisputeUnderReview, "assignedTo": callerID}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign dispute"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Dispute changed concurrently; reload and retry"})
+		return
+	}
+	recordAudit(c, audit.ActionDisputeAssign, audit.TargetDispute, dispute.ID.Hex(),
+		gin.H{"status": dispute.Status, "assignedTo": dispute.AssignedTo.Hex()},
+		gin.H{"status": models.DisputeUnderReview, "assignedTo": callerID.Hex()},
+	)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Dispute assigned to you"})
+}
+
+// POST /api/admin/disputes/:id/rule - Rule on a dispute and settle its payment (admin only)
+// Body: { "outcome": "release" | "refund" | "split", "refundPercent": 40, "notes": "..." }
+// refundPercent is required for split and applies to the payment net of the
+// platform fee. A full refund also returns the fee. If settling fails part
+// way, POST again with an empty body to retry the recorded ruling.
+func RuleDispute(c *gin.Context) {
+	var req struct {
+		Outcome       string   `json:"outcome"`
+		RefundPercent *float64 `json:"refundPercent"`
+		Notes         string   `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	dispute, ok := loadDispute(ctx, c, callerID)
+	if !ok {
+		return
+	}
+	if disputeParty(callerID, dispute) != "" {
+		forbidden(c, "Admins cannot rule on disputes they are a party to")
+		return
+	}
+
+	var claim bson.M
+	switch {
+	case dispute.Status == models.DisputeFailed:
+		// Retry the ruling already recorded
+		claim = bson.M{"status": models.DisputeExecuting}
+	case isDisputeAwaitingRuling(dispute):
+		var payment models.Payment
+		if err := config.DB.Collection("payments").FindOne(ctx, bson.M{"_id": dispute.PaymentID}).Decode(&payment); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load payment"})
+			return
+		}
+		ruling, err := newDisputeRuling(payment, req.Outcome, req.RefundPercent, strings.TrimSpace(req.Notes), callerID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		dispute.Ruling = &ruling
+		claim = bson.M{"status": models.DisputeExecuting, "ruling": ruling}
+	default:
+		c.JSON(http.StatusConflict, gin.H{"error": "Dispute is " + dispute.Status})
+		return
+	}
+
+	// Claim the dispute so the ruling is only settled once
+	result, err := disputeCollection().UpdateOne(ctx,
+		bson.M{"_id": dispute.ID, "status": dispute.Status},
+		bson.M{"$set": claim},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update dispute"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Dispute changed concurrently; reload and retry"})
+		return
+	}
+
+	if err := executeRuling(ctx, c, &dispute, callerID); err != nil {
+		fmt.Printf("[RuleDispute] dispute %s failed: %v\n", dispute.ID.Hex(), err)
+		_, updateErr := disputeCollection().UpdateOne(ctx, bson.M{"_id": dispute.ID}, bson.M{"$set": bson.M{
+			"status":    models.DisputeFailed,
+			"lastError": err.Error(),
+		}})
+		if updateErr != nil {
+			fmt.Printf("[RuleDispute] failed to mark dispute %s failed: %v\n", dispute.ID.Hex(), updateErr)
+		}
+		var illegal *models.IllegalTransitionError
+		if errors.As(err, &illegal) || errors.Is(err, errAssignmentChanged) {
+			respondTransitionError(c, err)
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Ruling recorded but settling it failed: " + err.Error() + "; it can be retried"})
+		return
+	}
+
+	ruling := *dispute.Ruling
+	result, err = disputeCollection().UpdateOne(ctx,
+		bson.M{"_id": dispute.ID, "status": models.DisputeExecuting},
+		bson.M{
+			"$set":   bson.M{"status": models.DisputeResolved, "resolvedAt": time.Now()},
+			"$unset": bson.M{"open": "", "lastError": ""},
+		},
+	)
+	if err != nil {
+		// The money has moved; the payment and audit log record it
+		fmt.Printf("[RuleDispute] dispute %s settled but not updated: %v\n", dispute.ID.Hex(), err)
+	} else if result.ModifiedCount > 0 {
+		recordDisputeOutcome(ctx, dispute, ruling)
+	}
+	if !dispute.CancellationID.IsZero() {
+		if _, err := cancellationCollection().UpdateOne(ctx, bson.M{"_id": dispute.CancellationID}, bson.M{"$unset": bson.M{"open": ""}}); err != nil {
+			fmt.Printf("[RuleDispute] failed to close cancellation %s: %v\n", dispute.CancellationID.Hex(), err)
+		}
+	}
+
+	recordAudit(c, audit.ActionDisputeRuling, audit.TargetDispute, dispute.ID.Hex(),
+		gin.H{"status": dispute.Status},
+		gin.H{
+			"status":         models.DisputeResolved,
+			"assignmentId":   dispute.AssignmentID.Hex(),
+			"outcome":        ruling.Outcome,
+			"refundPercent":  ruling.Split.RefundPercent,
+			"customerRefund": ruling.Split.CustomerRefund,
+			"solverAmount":   ruling.Split.SolverAmount,
+			"refundId":       dispute.RefundID,
+			"payoutId":       dispute.PayoutID,
+			"notes":          ruling.Notes,
+		},
+	)
+
+	message := fmt.Sprintf("The dispute was resolved (%s): %.2f refunded to the buyer, %.2f paid to the solver. %s",
+		ruling.Outcome, ruling.Split.CustomerRefund, ruling.Split.SolverAmount, ruling.Notes)
+	go CreateBuyerNotification(dispute.BuyerID, models.NotifTypeDisputeResolved, "Dispute Resolved", message, dispute.ID, "dispute", models.PriorityHigh)
+	go CreateSolverNotification(dispute.SolverID, models.NotifTypeDisputeResolved, "Dispute Resolved", message, dispute.ID, "dispute", models.PriorityHigh)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Dispute resolved",
+		"ruling":    ruling,
+		"refund_id": dispute.RefundID,
+		"payout_id": dispute.PayoutID,
+	})
+}
+
+// newDisputeRuling validates an admin's ruling and computes its split.
+func newDisputeRuling(payment models.Payment, outcome string, refundPercent *float64, notes string, adminID primitive.ObjectID) (models.DisputeRuling, error) {
+	if notes == "" || len(notes) > maxDisputeNotesLength {
+		return models.DisputeRuling{}, fmt.Errorf("notes must be 1-%d characters", maxDisputeNotesLength)
+	}
+	ruling := models.DisputeRuling{Outcome: outcome, Notes: notes, AdminID: adminID, DecidedAt: time.Now()}
+	switch outcome {
+	case models.RulingRelease:
+		ruling.Split = newRefundSplit(payment, 0, models.RefundSourceRuling)
+	case models.RulingRefund:
+		ruling.Split = models.RefundSplit{Source: models.RefundSourceRuling, RefundPercent: 100, CustomerRefund: payment.Amount}
+	case models.RulingSplit:
+		if refundPercent == nil || *refundPercent <= 0 || *refundPercent >= 100 {
+			return models.DisputeRuling{}, fmt.Errorf("refundPercent must be between 0 and 100 exclusive; use release or refund otherwise")
+		}
+		if isOnchainPayment(payment) {
+			return models.DisputeRuling{}, fmt.Errorf("escrow payments can only be released or refunded in full")
+		}
+		ruling.Split = newRefundSplit(payment, *refundPercent, models.RefundSourceRuling)
+	default:
+		return models.DisputeRuling{}, fmt.Errorf("outcome must be release, refund or split")
+	}
+	return ruling, nil
+}
+
+// executeRuling closes the assignment and settles the payment as ruled: a
+// full release completes it, anything else cancels it. A retry after a
+// failure picks up where the last attempt stopped.
+func executeRuling(ctx context.Context, c *gin.Context, dispute *models.Dispute, actorID primitive.ObjectID) error {
+	assignment, err := loadAssignment(ctx, dispute.AssignmentID)
+	if err != nil {
+		return fmt.Errorf("failed to load assignment: %w", err)
+	}
+	ruling := dispute.Ruling
+	to := models.AssignmentCancelled
+	if ruling.Outcome == models.RulingRelease {
+		to = models.AssignmentCompleted
+	}
+	if !assignment.IsTerminal() {
+		reason := fmt.Sprintf("dispute ruled: %s", ruling.Outcome)
+		if err := transitionAssignment(ctx, &assignment, to, actorID, reason, nil); err != nil {
+			return err
+		}
+	}
+	return settleSplit(ctx, c, disputeCollection(), dispute.ID, dispute.PaymentID, ruling.Split,
+		"dispute "+dispute.ID.Hex()+" ruled "+ruling.Outcome, &dispute.RefundID, &dispute.PayoutID)
+}
+
+// recordDisputeOutcome adds the ruling to both parties' dispute records. A
+// solver who loses outright also loses reliability.
+func recordDisputeOutcome(ctx context.Context, dispute models.Dispute, ruling models.DisputeRuling) {
+	users := config.DB.Collection("users")
+	inc := func(userID primitive.ObjectID, field string) {
+		if _, err := users.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$inc": bson.M{field: 1}}); err != nil {
+			fmt.Printf("[recordDisputeOutcome] failed to update %s of user %s: %v\n", field, userID.Hex(), err)
+		}
+	}
+
+	switch ruling.Outcome {
+	case models.RulingRelease:
+		inc(dispute.SolverID, "disputes.won")
+		inc(dispute.BuyerID, "disputes.lost")
+	case models.RulingRefund:
+		inc(dispute.BuyerID, "disputes.won")
+		if err := penalizeSolver(ctx, dispute.SolverID, disputeLossPenalty, "disputes.lost"); err != nil {
+			fmt.Printf("[recordDisputeOutcome] failed to lower reliability of solver %s: %v\n", dispute.SolverID.Hex(), err)
+		}
+	default:
+		inc(dispute.BuyerID, "disputes.split")
+		inc(dispute.SolverID, "disputes.split")
+	}
+}
+
+// DisputeJobs are the background jobs that enforce dispute SLAs.
+func DisputeJobs() []scheduler.Job {
+	return []scheduler.Job{
+		{Name: "dispute-sla", Interval: disputeJobInterval, Run: enforceDisputeSLAs},
+	}
+}
+
+// enforceDisputeSLAs tells the parties when evidence closes and alerts
+// admins when a ruling is overdue. Each step is claimed with a conditional
+// update, so overlapping runs never notify twice.
+func enforceDisputeSLAs(ctx context.Context, now time.Time) error {
+	disputes := disputeCollection()
+
+	closing, err := findDisputes(ctx, bson.M{
+		"status":           bson.M{"$in": disputeActiveStatuses},
+		"evidenceDueAt":    bson.M{"$lte": now},
+		"evidenceClosedAt": bson.M{"$exists": false},
+	})
+	if err != nil {
+		return err
+	}
+	for _, d := range closing {
+		result, err := disputes.UpdateOne(ctx,
+			bson.M{"_id": d.ID, "evidenceClosedAt": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"evidenceClosedAt": now}},
+		)
+		if err != nil {
+			return err
+		}
+		if result.ModifiedCount == 0 {
+			continue
+		}
+		message := fmt.Sprintf("Evidence for your dispute is closed. An admin will rule by %s.", d.ResolveBy.UTC().Format(time.RFC1123))
+		CreateBuyerNotification(d.BuyerID, models.NotifTypeDisputeUpdate, "Dispute Evidence Closed", message, d.ID, "dispute", models.PriorityMedium)
+		CreateSolverNotification(d.SolverID, models.NotifTypeDisputeUpdate, "Dispute Evidence Closed", message, d.ID, "dispute", models.PriorityMedium)
+	}
+
+	breached, err := findDisputes(ctx, bson.M{
+		"status":        bson.M{"$in": disputeActiveStatuses},
+		"resolveBy":     bson.M{"$lte": now},
+		"slaBreachedAt": bson.M{"$exists": false},
+	})
+	if err != nil {
+		return err
+	}
+	for _, d := range breached {
+		result, err := disputes.UpdateOne(ctx,
+			bson.M{"_id": d.ID, "slaBreachedAt": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"slaBreachedAt": now}},
+		)
+		if err != nil {
+			return err
+		}
+		if result.ModifiedCount == 0 {
+			continue
+		}
+		if err := NotifyAdmins(models.NotifTypeDisputeUpdate, "Dispute Ruling Overdue",
+			fmt.Sprintf("Dispute %s was due for a ruling at %s.", d.ID.Hex(), d.ResolveBy.UTC().Format(time.RFC1123)),
+			d.ID, "dispute"); err != nil {
+			fmt.Printf("[enforceDisputeSLAs] failed to alert admins about dispute %s: %v\n", d.ID.Hex(), err)
+		}
+	}
+	return nil
+}
+
+func findDisputes(ctx context.Context, filter bson.M) ([]models.Dispute, error) {
+	cursor, err := disputeCollection().Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var disputes []models.Dispute
+	err = cursor.All(ctx, &disputes)
+	return disputes, err
+}
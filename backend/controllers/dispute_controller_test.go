@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/middleware"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// An admin who is the buyer or solver in a dispute must leave it to another
+// admin.
+func TestAdminPartyCannotArbitrateDispute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	buyer := primitive.NewObjectID()
+	solver := primitive.NewObjectID()
+	disputeID := primitive.NewObjectID()
+	dispute := bson.D{
+		{Key: "_id", Value: disputeID},
+		{Key: "buyerId", Value: buyer},
+		{Key: "solverId", Value: solver},
+		{Key: "status", Value: models.DisputeUnderReview},
+		{Key: "open", Value: true},
+	}
+
+	cases := []struct {
+		name    string
+		handler gin.HandlerFunc
+		caller  primitive.ObjectID
+		body    string
+	}{
+		{name: "buyer rules", handler: RuleDispute, caller: buyer, body: `{"outcome":"refund"}`},
+		{name: "solver rules", handler: RuleDispute, caller: solver, body: `{"outcome":"release"}`},
+		{name: "buyer takes the dispute", handler: AssignDispute, caller: buyer},
+	}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	for _, tc := range cases {
+		mt.Run(tc.name, func(mt *mtest.T) {
+			config.DB = mt.Client.Database("test")
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "test.disputes", mtest.FirstBatch, dispute))
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Params = gin.Params{{Key: "id", Value: disputeID.Hex()}}
+			c.Set(middleware.ContextUserID, tc.caller)
+			c.Set(middleware.ContextRole, models.RoleAdmin)
+
+			tc.handler(c)
+
+			if w.Code != http.StatusForbidden {
+				mt.Fatalf("status = %d, want %d; body %s", w.Code, http.StatusForbidden, w.Body.String())
+			}
+			if n := len(startedCommands(mt)); n != 1 {
+				mt.Fatalf("%d commands sent, want only the dispute lookup", n)
+			}
+		})
+	}
+}
@@ -3,36 +3,64 @@ package controllers
 import (
 	"context"
 	"encoding/json"
-	"math"
 	"net/http"
-	"sort"
-	"strings"
 	"time"
 
 	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/matching"
 	"github.com/Aashishvatwani/homeworld/models"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-type SolverScore struct {
-	User  models.User
-	Score float64
+// maxMatchResults is how many ranked solvers the API returns.
+const maxMatchResults = 10
+
+// rankSolvers loads the candidate solvers for an assignment and ranks them
+// with the assignment's engine in the A/B split, best first. Solvers who
+// are away, at capacity or not working again before the deadline are left
+// out. The ranking shown to viewerID on surface is logged as an impression.
+func rankSolvers(ctx context.Context, assignment models.Assignment, viewerID primitive.ObjectID, surface string) ([]matching.Match, error) {
+	candidates, err := availableCandidates(ctx, assignment, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	engine := matching.For(assignment.ID)
+	matches := engine.Rank(assignment, candidates, maxMatchResults)
+	logImpression(assignment.ID, viewerID, surface, engine, matches)
+	return matches, nil
 }
 
-// calculateDistance uses the Haversine formula for location distance (in km)
-func calculateDistance(lat1, lon1, lat2, lon2 float64) float64 {
-	const R = 6371 // Earth radius in km
-	dLat := (lat2 - lat1) * math.Pi / 180
-	dLon := (lon2 - lon1) * math.Pi / 180
-	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
-		math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*
-			math.Sin(dLon/2)*math.Sin(dLon/2)
-	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
-	return R * c
+// availableCandidates loads the candidate solvers with their workload and
+// keeps those available for the assignment at now.
+func availableCandidates(ctx context.Context, assignment models.Assignment, now time.Time) ([]matching.Candidate, error) {
+	solvers, err := candidateSolvers(ctx, assignment, now)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(solvers))
+	for _, s := range solvers {
+		ids = append(ids, s.ID)
+	}
+	workloads, err := solverWorkloads(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]matching.Candidate, 0, len(solvers))
+	for _, s := range solvers {
+		c := matching.Candidate{Solver: s.User, DistanceKm: s.DistanceKm, ActiveAssignments: workloads[s.ID], Now: now}
+		if matching.Available(assignment, c) {
+			candidates = append(candidates, c)
+		}
+	}
+	return candidates, nil
 }
 
+// POST /api/match/solvers - Rank solvers for an assignment
+// Body: { "assignmentId": "<id>" } or an unsaved assignment object.
+// Each match carries a per-feature breakdown of its score.
 func MatchSolvers(c *gin.Context) {
 	// Read the incoming JSON into a generic map first so we can accept either
 	// a full assignment object or a simple { "assignmentId": "..." } payload.
@@ -70,64 +98,19 @@ func MatchSolvers(c *gin.Context) {
 		}
 	}
 
-	userCollection := config.DB.Collection("users")
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	cursor, err := userCollection.Find(ctx, bson.M{"role": "solver"})
+	matches, err := rankSolvers(ctx, assignment, callerID, models.SurfaceMatch)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch solvers"})
 		return
 	}
-	defer cursor.Close(ctx)
-
-	var solvers []models.User
-	if err = cursor.All(ctx, &solvers); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding solvers"})
-		return
-	}
-
-	var scored []SolverScore
-
-	for _, solver := range solvers {
-		score := 0.0
-
-		// 1️⃣ Skill Match
-		for _, skill := range solver.Skills {
-			if strings.Contains(strings.ToLower(assignment.Description), strings.ToLower(skill)) {
-				score += 5 // each matching skill gives 5 points
-			}
-		}
-
-		// 2️⃣ Time Efficiency
-		if solver.AvgSpeed > 0 {
-			score += 100.0 / float64(solver.AvgSpeed) // faster solvers get more points
-		}
-
-		// 3️⃣ Location Proximity (within 100 km bonus)
-		if assignment.Location.Latitude != 0 && solver.Location.Latitude != 0 {
-			distance := calculateDistance(assignment.Location.Latitude, assignment.Location.Longitude,
-				solver.Location.Latitude, solver.Location.Longitude)
-			if distance < 100 {
-				score += 10
-			} else if distance < 300 {
-				score += 5
-			}
-		}
-
-		scored = append(scored, SolverScore{User: solver, Score: score})
-	}
-
-	// 4️⃣ Sort by score
-	sort.Slice(scored, func(i, j int) bool {
-		return scored[i].Score > scored[j].Score
-	})
-
-	// 5️⃣ Pick top 10
-	if len(scored) > 10 {
-		scored = scored[:10]
-	}
 
-	c.JSON(http.StatusOK, scored)
+	c.JSON(http.StatusOK, matches)
 }
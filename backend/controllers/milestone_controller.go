@@ -0,0 +1,529 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	maxMilestones                 = 20
+	maxMilestoneTitleLength       = 200
+	maxMilestoneDescriptionLength = 2000
+	milestoneAmountTolerance      = 0.01
+)
+
+// errMilestoneChanged is returned when another request changed a milestone
+// between loading and updating it.
+var errMilestoneChanged = errors.New("milestone changed concurrently; reload and retry")
+
+// milestoneEditableStatuses are the assignment states in which the buyer may
+// still replace the milestone plan, as long as none has been funded.
+var milestoneEditableStatuses = map[string]bool{
+	models.AssignmentDraft:    true,
+	models.AssignmentPosted:   true,
+	models.AssignmentMatched:  true,
+	models.AssignmentAccepted: true,
+}
+
+// milestoneSpec is a milestone as the buyer describes it.
+type milestoneSpec struct {
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Amount      float64   `json:"amount"`
+	DueAt       time.Time `json:"dueAt"`
+}
+
+// buildMilestones validates a milestone plan and numbers it. Milestones are
+// stages of the work, so their due dates must be in order and, when the
+// assignment has a deadline, no later than it.
+func buildMilestones(specs []milestoneSpec, deadline time.Time) ([]models.Milestone, error) {
+	if len(specs) == 1 {
+		return nil, fmt.Errorf("split the assignment into at least 2 milestones, or none")
+	}
+	if len(specs) > maxMilestones {
+		return nil, fmt.Errorf("at most %d milestones are allowed", maxMilestones)
+	}
+
+	now := time.Now()
+	milestones := make([]models.Milestone, 0, len(specs))
+	for i, spec := range specs {
+		title := strings.TrimSpace(spec.Title)
+		if title == "" || len(title) > maxMilestoneTitleLength {
+			return nil, fmt.Errorf("milestone %d: title must be 1-%d characters", i+1, maxMilestoneTitleLength)
+		}
+		description := strings.TrimSpace(spec.Description)
+		if len(description) > maxMilestoneDescriptionLength {
+			return nil, fmt.Errorf("milestone %d: description must be at most %d characters", i+1, maxMilestoneDescriptionLength)
+		}
+		amount := math.Round(spec.Amount*100) / 100
+		if amount <= 0 {
+			return nil, fmt.Errorf("milestone %d: amount must be positive", i+1)
+		}
+		if !spec.DueAt.After(now) {
+			return nil, fmt.Errorf("milestone %d: dueAt must be in the future", i+1)
+		}
+		if i > 0 && spec.DueAt.Before(specs[i-1].DueAt) {
+			return nil, fmt.Errorf("milestone %d: due dates must be in milestone order", i+1)
+		}
+		if !deadline.IsZero() && spec.DueAt.After(deadline) {
+			return nil, fmt.Errorf("milestone %d: dueAt is after the assignment deadline", i+1)
+		}
+		milestones = append(milestones, models.Milestone{
+			ID:          primitive.NewObjectID(),
+			Index:       i + 1,
+			Title:       title,
+			Description: description,
+			Amount:      amount,
+			DueAt:       spec.DueAt,
+			Status:      models.MilestonePending,
+		})
+	}
+	return milestones, nil
+}
+
+// milestoneSpecs converts milestones bound from a request body back to
+// specs, so they go through the same validation as PUT .../milestones.
+func milestoneSpecs(milestones []models.Milestone) []milestoneSpec {
+	specs := make([]milestoneSpec, len(milestones))
+	for i, m := range milestones {
+		specs[i] = milestoneSpec{Title: m.Title, Description: m.Description, Amount: m.Amount, DueAt: m.DueAt}
+	}
+	return specs
+}
+
+// agreedPrice is what the buyer pays for the assignment: the accepted bid,
+// or the posted price when no bid has been accepted.
+func agreedPrice(assignment models.Assignment) float64 {
+	if !assignment.AcceptedBidID.IsZero() {
+		return assignment.BidAmount
+	}
+	return assignment.Price
+}
+
+// updateMilestone sets and unsets fields on one milestone while it is still
+// in status from, so two concurrent requests cannot both act on it.
+func updateMilestone(ctx context.Context, assignmentID, milestoneID primitive.ObjectID, from string, set bson.M, unset ...string) error {
+	update := bson.M{}
+	fields := bson.M{}
+	for k, v := range set {
+		fields["milestones.$."+k] = v
+	}
+	if len(fields) > 0 {
+		update["$set"] = fields
+	}
+	if len(unset) > 0 {
+		fields := bson.M{}
+		for _, k := range unset {
+			fields["milestones.$."+k] = ""
+		}
+		update["$unset"] = fields
+	}
+
+	result, err := config.DB.Collection("assignments").UpdateOne(ctx,
+		bson.M{"_id": assignmentID, "milestones": bson.M{"$elemMatch": bson.M{"id": milestoneID, "status": from}}},
+		update,
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errMilestoneChanged
+	}
+	return nil
+}
+
+// setMilestoneStatus updates the loaded assignment's copy of a milestone
+// after it was changed in the database.
+func setMilestoneStatus(assignment *models.Assignment, milestoneID primitive.ObjectID, status string) {
+	for i := range assignment.Milestones {
+		if assignment.Milestones[i].ID == milestoneID {
+			assignment.Milestones[i].Status = status
+		}
+	}
+}
+
+// closeOpenMilestones closes the milestones of an assignment that was
+// cancelled or settled before they were released. Called once the
+// assignment reaches a terminal state; failures are logged.
+func closeOpenMilestones(ctx context.Context, assignment *models.Assignment, at time.Time) {
+	open := bson.A{models.MilestonePending, models.MilestoneFunded, models.MilestoneSubmitted}
+	_, err := config.DB.Collection("assignments").UpdateOne(ctx,
+		bson.M{"_id": assignment.ID},
+		bson.M{"$set": bson.M{"milestones.$[m].status": models.MilestoneClosed, "milestones.$[m].closedAt": at}},
+		options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"m.status": bson.M{"$in": open}}}}),
+	)
+	if err != nil {
+		fmt.Printf("[closeOpenMilestones] failed to close milestones of assignment %s: %v\n", assignment.ID.Hex(), err)
+		return
+	}
+	for i, m := range assignment.Milestones {
+		switch m.Status {
+		case models.MilestonePending, models.MilestoneFunded, models.MilestoneSubmitted:
+			assignment.Milestones[i].Status = models.MilestoneClosed
+			assignment.Milestones[i].ClosedAt = at
+		}
+	}
+}
+
+// milestoneToFund checks that the buyer may pay for the milestone in
+// milestoneID now. Milestones are funded one at a time, so a cancellation or
+// dispute always concerns a single held payment. It writes the response
+// when the milestone cannot be funded.
+func milestoneToFund(c *gin.Context, assignment models.Assignment, milestoneID string) (models.Milestone, bool) {
+	if milestoneID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This assignment is paid in milestones; pass the milestoneId to fund"})
+		return models.Milestone{}, false
+	}
+	objID, err := primitive.ObjectIDFromHex(milestoneID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid milestone ID"})
+		return models.Milestone{}, false
+	}
+	milestone, ok := assignment.FindMilestone(objID)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Milestone not found"})
+		return models.Milestone{}, false
+	}
+	if milestone.Status != models.MilestonePending {
+		c.JSON(http.StatusConflict, gin.H{"error": "Milestone is " + milestone.Status + " and cannot be funded again"})
+		return models.Milestone{}, false
+	}
+	for _, m := range assignment.Milestones {
+		switch m.Status {
+		case models.MilestoneFunded, models.MilestoneSubmitted, models.MilestoneReleasing:
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Milestone %d is still in progress; release it before funding the next", m.Index)})
+			return models.Milestone{}, false
+		}
+	}
+	if price := agreedPrice(assignment); price > 0 && math.Abs(assignment.MilestoneTotal()-price) > milestoneAmountTolerance {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": fmt.Sprintf("Milestones add up to %.2f but the agreed price is %.2f; update the milestones first", assignment.MilestoneTotal(), price),
+		})
+		return models.Milestone{}, false
+	}
+	if !assignment.SolverID.IsZero() && assignment.Status != models.AssignmentAccepted && assignment.Status != models.AssignmentInProgress {
+		c.JSON(http.StatusConflict, gin.H{"error": "Assignment is " + assignment.Status + " and its milestones cannot be funded"})
+		return models.Milestone{}, false
+	}
+	return milestone, true
+}
+
+// fundMilestone marks the milestone a confirmed payment was made for as
+// funded and tells the solver. The payment has already been captured, so
+// failures are logged rather than returned to the client.
+func fundMilestone(ctx context.Context, payment models.Payment) {
+	if payment.MilestoneID.IsZero() {
+		return
+	}
+	err := updateMilestone(ctx, payment.AssignmentID, payment.MilestoneID, models.MilestonePending,
+		bson.M{"status": models.MilestoneFunded, "paymentId": payment.ID, "fundedAt": time.Now()},
+	)
+	if err != nil {
+		fmt.Printf("[fundMilestone] payment %s captured but milestone %s not funded: %v\n", payment.ID.Hex(), payment.MilestoneID.Hex(), err)
+		return
+	}
+
+	assignment, err := loadAssignment(ctx, payment.AssignmentID)
+	if err != nil {
+		return
+	}
+	milestone, _ := assignment.FindMilestone(payment.MilestoneID)
+	go CreateSolverNotification(payment.SolverID, models.NotifTypeMilestoneFunded, "Milestone Funded",
+		fmt.Sprintf("Milestone %d of \"%s\" (%s) is funded with %.2f in escrow and due %s. You can start work on it.",
+			milestone.Index, assignment.Title, milestone.Title, payment.Amount, milestone.DueAt.UTC().Format(time.RFC1123)),
+		assignment.ID, "assignment", models.PriorityHigh)
+}
+
+// milestoneForSubmission returns the milestone a solver is delivering. Only
+// funded milestones can be delivered, including one sent back for revision.
+// It writes the response when the milestone cannot be delivered.
+func milestoneForSubmission(c *gin.Context, assignment models.Assignment, milestoneID string) (models.Milestone, bool) {
+	if milestoneID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This assignment is paid in milestones; pass the milestoneId being delivered"})
+		return models.Milestone{}, false
+	}
+	objID, err := primitive.ObjectIDFromHex(milestoneID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid milestone ID"})
+		return models.Milestone{}, false
+	}
+	milestone, ok := assignment.FindMilestone(objID)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Milestone not found"})
+		return models.Milestone{}, false
+	}
+	if milestone.Status != models.MilestoneFunded {
+		c.JSON(http.StatusConflict, gin.H{"error": "Milestone is " + milestone.Status + "; only funded milestones can be delivered"})
+		return models.Milestone{}, false
+	}
+	return milestone, true
+}
+
+// releaseMilestone accepts a milestone's deliverable and releases the
+// payment that funded it. The assignment is completed with its last
+// milestone. The deliverable and milestone go back to submitted if the
+// release fails so the buyer can retry.
+func releaseMilestone(ctx context.Context, c *gin.Context, assignment *models.Assignment, deliverable *models.Deliverable, callerID primitive.ObjectID) {
+	if deliverable == nil {
+		// Every milestone was released but completing the assignment failed
+		if assignment.Status == models.AssignmentDelivered && assignment.MilestonesIn(primitive.NilObjectID, models.MilestoneReleased) {
+			if err := transitionAssignment(ctx, assignment, models.AssignmentCompleted, callerID, "all milestones released", nil); err != nil {
+				respondTransitionError(c, err)
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"message": "assignment completed", "assignment_id": assignment.ID.Hex()})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": "This assignment is paid in milestones; accept a milestone's deliverable to release it"})
+		return
+	}
+
+	milestone, ok := assignment.FindMilestone(deliverable.MilestoneID)
+	if !ok || milestone.Status != models.MilestoneSubmitted || milestone.DeliverableID != deliverable.ID {
+		c.JSON(http.StatusConflict, gin.H{"error": "deliverable is not awaiting review for its milestone"})
+		return
+	}
+	payments := config.DB.Collection("payments")
+	var payment models.Payment
+	if err := payments.FindOne(ctx, bson.M{"_id": milestone.PaymentID, "status": "paid"}).Decode(&payment); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "no paid payment is held for this milestone; cannot release funds"})
+		return
+	}
+
+	deliverables := deliverableCollection()
+	result, err := deliverables.UpdateOne(ctx,
+		bson.M{"_id": deliverable.ID, "status": models.DeliverableSubmitted},
+		bson.M{"$set": bson.M{"status": models.DeliverableAccepted, "reviewedAt": time.Now()}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to accept deliverable"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "deliverable has already been reviewed"})
+		return
+	}
+	reopenDeliverable := func() {
+		_, err := deliverables.UpdateOne(ctx,
+			bson.M{"_id": deliverable.ID},
+			bson.M{"$set": bson.M{"status": models.DeliverableSubmitted}, "$unset": bson.M{"reviewedAt": ""}},
+		)
+		if err != nil {
+			fmt.Printf("[releaseMilestone] failed to reopen deliverable %s: %v\n", deliverable.ID.Hex(), err)
+		}
+	}
+	if err := updateMilestone(ctx, assignment.ID, milestone.ID, models.MilestoneSubmitted, bson.M{"status": models.MilestoneReleasing}); err != nil {
+		reopenDeliverable()
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	payoutID, err := paySolverShare(ctx, c, payment, payment.SolverAmount, fmt.Sprintf("milestone %d accepted", milestone.Index))
+	if err != nil {
+		reopenDeliverable()
+		if err := updateMilestone(ctx, assignment.ID, milestone.ID, models.MilestoneReleasing, bson.M{"status": models.MilestoneSubmitted}); err != nil {
+			fmt.Printf("[releaseMilestone] failed to reopen milestone %s: %v\n", milestone.ID.Hex(), err)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to release milestone payment: " + err.Error()})
+		return
+	}
+	err = updateMilestone(ctx, assignment.ID, milestone.ID, models.MilestoneReleasing,
+		bson.M{"status": models.MilestoneReleased, "payoutId": payoutID, "releasedAt": time.Now()},
+	)
+	if err != nil {
+		// The money has moved; paySolverShare audited it
+		fmt.Printf("[releaseMilestone] payout %s made but milestone %s not updated: %v\n", payoutID, milestone.ID.Hex(), err)
+	}
+	setMilestoneStatus(assignment, milestone.ID, models.MilestoneReleased)
+
+	go CreateSolverNotification(payment.SolverID, models.NotifTypeMilestoneReleased, "Milestone Released",
+		fmt.Sprintf("The buyer accepted milestone %d of \"%s\" (%s); %.2f has been released to you.",
+			milestone.Index, assignment.Title, milestone.Title, payment.SolverAmount),
+		assignment.ID, "assignment", models.PriorityHigh)
+
+	resp := gin.H{
+		"message":       fmt.Sprintf("milestone %d accepted and payment released", milestone.Index),
+		"assignment_id": assignment.ID.Hex(),
+		"milestone_id":  milestone.ID.Hex(),
+		"payment_id":    payment.ID.Hex(),
+		"payout_id":     payoutID,
+	}
+	if assignment.MilestonesIn(primitive.NilObjectID, models.MilestoneReleased) {
+		if err := transitionAssignment(ctx, assignment, models.AssignmentCompleted, callerID, "all milestones released", nil); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "milestone released but failed to complete the assignment: " + err.Error()})
+			return
+		}
+		go CreateBuyerNotification(assignment.UserID, models.NotifTypeAssignmentCompleted, "Assignment Completed", "Every milestone has been accepted and released to the solver.", assignment.ID, "assignment", models.PriorityHigh)
+		go CreateSolverNotification(assignment.SolverID, models.NotifTypeAssignmentCompleted, "Assignment Completed", "Every milestone has been accepted and paid out.", assignment.ID, "assignment", models.PriorityHigh)
+	}
+	resp["assignment_status"] = assignment.Status
+	c.JSON(http.StatusOK, resp)
+}
+
+// requestMilestoneRevision sends a milestone's deliverable back to the
+// solver. Revision rounds are counted per milestone; the assignment itself
+// only moves to revision_requested when all of its work had been delivered.
+func requestMilestoneRevision(ctx context.Context, c *gin.Context, assignment *models.Assignment, deliverable models.Deliverable, comments string, callerID primitive.ObjectID) {
+	milestone, ok := assignment.FindMilestone(deliverable.MilestoneID)
+	if !ok || milestone.Status != models.MilestoneSubmitted || milestone.DeliverableID != deliverable.ID {
+		c.JSON(http.StatusConflict, gin.H{"error": "Deliverable is not awaiting review for its milestone"})
+		return
+	}
+	limit := maxRevisionRounds()
+	if milestone.RevisionRounds >= limit {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":           "No revision rounds left for this milestone; accept the work or open a dispute",
+			"revision_rounds": milestone.RevisionRounds,
+		})
+		return
+	}
+
+	now := time.Now()
+	result, err := deliverableCollection().UpdateOne(ctx,
+		bson.M{"_id": deliverable.ID, "status": models.DeliverableSubmitted},
+		bson.M{"$set": bson.M{"status": models.DeliverableRevisionRequested, "reviewComment": comments, "reviewedAt": now}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update deliverable"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Deliverable has already been reviewed"})
+		return
+	}
+	revert := func(milestoneToo bool) {
+		_, err := deliverableCollection().UpdateOne(ctx,
+			bson.M{"_id": deliverable.ID},
+			bson.M{"$set": bson.M{"status": models.DeliverableSubmitted}, "$unset": bson.M{"reviewComment": "", "reviewedAt": ""}},
+		)
+		if err != nil {
+			fmt.Printf("[requestMilestoneRevision] failed to reopen deliverable %s: %v\n", deliverable.ID.Hex(), err)
+		}
+		if !milestoneToo {
+			return
+		}
+		err = updateMilestone(ctx, assignment.ID, milestone.ID, models.MilestoneFunded,
+			bson.M{"status": models.MilestoneSubmitted, "revisionRounds": milestone.RevisionRounds},
+		)
+		if err != nil {
+			fmt.Printf("[requestMilestoneRevision] failed to reopen milestone %s: %v\n", milestone.ID.Hex(), err)
+		}
+	}
+
+	err = updateMilestone(ctx, assignment.ID, milestone.ID, models.MilestoneSubmitted,
+		bson.M{"status": models.MilestoneFunded, "revisionRounds": milestone.RevisionRounds + 1},
+	)
+	if err != nil {
+		revert(false)
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if assignment.Status == models.AssignmentDelivered {
+		reason := fmt.Sprintf("revision %d/%d requested on milestone %d", milestone.RevisionRounds+1, limit, milestone.Index)
+		if err := transitionAssignment(ctx, assignment, models.AssignmentRevisionRequested, callerID, reason, nil); err != nil {
+			revert(true)
+			respondTransitionError(c, err)
+			return
+		}
+	}
+
+	go CreateSolverNotification(assignment.SolverID, models.NotifTypeRevisionRequested, "Revision Requested",
+		fmt.Sprintf("The buyer requested changes to milestone %d (%s) of \"%s\": %s", milestone.Index, milestone.Title, assignment.Title, comments),
+		assignment.ID, "assignment", models.PriorityHigh)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Revision requested",
+		"milestone_id":    milestone.ID.Hex(),
+		"revision_rounds": milestone.RevisionRounds + 1,
+		"rounds_left":     limit - milestone.RevisionRounds - 1,
+	})
+}
+
+// PUT /api/assignments/:id/milestones - Replace the milestone plan (owner only)
+// Body: { "milestones": [{ "title": "...", "description": "...", "amount": 500, "dueAt": "<RFC 3339>" }] }
+// Amounts must add up to the agreed price. The plan can change until the
+// first milestone is funded; an empty list pays for the assignment in one go.
+func SetMilestones(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment ID"})
+		return
+	}
+
+	var req struct {
+		Milestones []milestoneSpec `json:"milestones"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	assignment, err := loadAssignment(ctx, objID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+		return
+	}
+	if !isAssignmentOwner(callerID, assignment) {
+		forbidden(c, "Only the assignment owner can plan its milestones")
+		return
+	}
+	if !milestoneEditableStatuses[assignment.Status] {
+		c.JSON(http.StatusConflict, gin.H{"error": "Assignment is " + assignment.Status + "; milestones can no longer change"})
+		return
+	}
+	if !assignment.MilestonesIn(primitive.NilObjectID, models.MilestonePending) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A milestone has already been funded; the plan can no longer change"})
+		return
+	}
+
+	milestones, err := buildMilestones(req.Milestones, assignment.Deadline)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	plan := models.Assignment{Milestones: milestones}
+	if price := agreedPrice(assignment); len(milestones) > 0 && price > 0 && math.Abs(plan.MilestoneTotal()-price) > milestoneAmountTolerance {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Milestones add up to %.2f but the agreed price is %.2f", plan.MilestoneTotal(), price)})
+		return
+	}
+
+	// Only replace a plan nobody has started funding in the meantime
+	filter := bson.M{"_id": objID, "status": assignment.Status, "milestones.status": bson.M{"$nin": bson.A{
+		models.MilestoneFunded, models.MilestoneSubmitted, models.MilestoneReleasing, models.MilestoneReleased,
+	}}}
+	update := bson.M{"$set": bson.M{"milestones": milestones}}
+	if len(milestones) == 0 {
+		update = bson.M{"$unset": bson.M{"milestones": ""}}
+	}
+	result, err := config.DB.Collection("assignments").UpdateOne(ctx, filter, update)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save milestones"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": errAssignmentChanged.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Milestones saved", "milestones": milestones})
+}
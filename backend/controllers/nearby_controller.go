@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/matching"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/Aashishvatwani/homeworld/skills"
+	"github.com/Aashishvatwani/homeworld/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultNearbyRadiusKm = 25
+	maxNearbyRadiusKm     = 500
+
+	// matchRadiusKm bounds the solvers considered for an assignment with a
+	// location; the scorers give no proximity credit beyond it.
+	matchRadiusKm = matching.MaxDistanceKm
+	// maxMatchCandidates caps how many solvers are loaded for scoring.
+	maxMatchCandidates = 200
+)
+
+// NearbyAssignment is an open assignment with its distance from the caller.
+type NearbyAssignment struct {
+	models.Assignment `bson:",inline"`
+	DistanceKm        float64 `bson:"distanceKm" json:"distance_km"`
+}
+
+// solverCandidate is a solver loaded for matching. DistanceKm is negative
+// when either side has no location.
+type solverCandidate struct {
+	models.User `bson:",inline"`
+	DistanceKm  float64 `bson:"distanceKm"`
+}
+
+// geoNearStage builds a $geoNear stage that adds the distance in km as distanceKm.
+func geoNearStage(point *models.GeoPoint, radiusKm float64, query bson.M) bson.D {
+	return bson.D{{Key: "$geoNear", Value: bson.M{
+		"near":               point,
+		"distanceField":      "distanceKm",
+		"distanceMultiplier": 0.001,
+		"maxDistance":        radiusKm * 1000,
+		"spherical":          true,
+		"query":              query,
+	}}}
+}
+
+// GET /api/assignments/nearby - Open assignments near the caller (solver only)
+// Query: lat, lng (default: the caller's saved location), radius_km (default 25, max 500), limit
+func GetNearbyAssignments(c *gin.Context) {
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	radiusKm := float64(defaultNearbyRadiusKm)
+	if v, ok, err := queryFloat(c, "radius_km"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if ok {
+		if v <= 0 || v > maxNearbyRadiusKm {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("radius_km must be between 0 and %d", maxNearbyRadiusKm)})
+			return
+		}
+		radiusKm = v
+	}
+	limit := int64(defaultPageSize)
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		limit = min(n, maxPageSize)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var origin *models.GeoPoint
+	lat, hasLat, latErr := queryFloat(c, "lat")
+	lng, hasLng, lngErr := queryFloat(c, "lng")
+	switch {
+	case latErr != nil || lngErr != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lat and lng must be numbers"})
+		return
+	case hasLat && hasLng:
+		if !utils.ValidateCoordinates(lat, lng) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "lat must be in [-90, 90] and lng in [-180, 180]"})
+			return
+		}
+		origin = models.NewGeoPoint(lat, lng)
+	case hasLat || hasLng:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Pass both lat and lng, or neither to use your saved location"})
+		return
+	default:
+		caller, err := loadUser(ctx, callerID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		if caller.Location.IsZero() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No location saved on your profile; pass lat and lng"})
+			return
+		}
+		origin = caller.Location
+	}
+
+	pipeline := mongo.Pipeline{
+		geoNearStage(origin, radiusKm, bson.M{
+			"status": bson.M{"$in": bson.A{models.AssignmentPosted, models.AssignmentMatched}},
+			"userId": bson.M{"$ne": callerID},
+		}),
+		{{Key: "$limit", Value: limit}},
+	}
+	cursor, err := config.DB.Collection("assignments").Aggregate(ctx, pipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search nearby assignments"})
+		return
+	}
+	assignments := []NearbyAssignment{}
+	if err := cursor.All(ctx, &assignments); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search nearby assignments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"assignments": assignments,
+		"origin":      origin,
+		"radius_km":   radiusKm,
+	})
+}
+
+// candidateSolvers loads the solvers worth scoring for an assignment,
+// skipping those away at now. With a location, $geoNear returns the closest
+// solvers within matchRadiusKm; without one, the best-rated solvers with a
+// required or related skill are used.
+func candidateSolvers(ctx context.Context, assignment models.Assignment, now time.Time) ([]solverCandidate, error) {
+	users := config.DB.Collection("users")
+	query := bson.M{"roles": models.RoleSolver, "availability.away": notAwayFilter(now)}
+	if !assignment.UserID.IsZero() {
+		query["_id"] = bson.M{"$ne": assignment.UserID}
+	}
+
+	if !assignment.Location.IsZero() {
+		cursor, err := users.Aggregate(ctx, mongo.Pipeline{
+			geoNearStage(assignment.Location, matchRadiusKm, query),
+			{{Key: "$limit", Value: maxMatchCandidates}},
+			{{Key: "$project", Value: publicUserProjection}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		var candidates []solverCandidate
+		err = cursor.All(ctx, &candidates)
+		return candidates, err
+	}
+
+	if len(assignment.Skills) > 0 {
+		query["skills"] = bson.M{"$in": skills.Related(assignment.Skills)}
+	}
+	cursor, err := users.Find(ctx, query, options.Find().
+		SetProjection(publicUserProjection).
+		SetSort(bson.D{{Key: "avgRating", Value: -1}, {Key: "_id", Value: 1}}).
+		SetLimit(maxMatchCandidates))
+	if err != nil {
+		return nil, err
+	}
+	var solvers []models.User
+	if err := cursor.All(ctx, &solvers); err != nil {
+		return nil, err
+	}
+	candidates := make([]solverCandidate, 0, len(solvers))
+	for _, s := range solvers {
+		candidates = append(candidates, solverCandidate{User: s, DistanceKm: -1})
+	}
+	return candidates, nil
+}
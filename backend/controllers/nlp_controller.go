@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/matching"
 	"github.com/Aashishvatwani/homeworld/models"
 	"github.com/Aashishvatwani/homeworld/utils"
 	"github.com/gin-gonic/gin"
@@ -56,12 +57,11 @@ func ParseAssignmentNLP(c *gin.Context) {
 }
 
 // POST /api/assignments/create-from-text - Create assignment from text message (AI-powered)
-// Frontend sends: { "text": "I need a 4 page Python ML report due tomorrow", "user_id": "..." }
-// Backend: Parses with NLP, creates assignment, finds top solvers, returns everything
+// Frontend sends: { "text": "I need a 4 page Python ML report due tomorrow" }
+// Backend: Parses with NLP, creates assignment for the caller, finds top solvers, returns everything
 func CreateAssignmentFromText(c *gin.Context) {
 	var req struct {
 		Text      string  `json:"text" binding:"required"`
-		UserID    string  `json:"user_id" binding:"required"`
 		Title     string  `json:"title"`     // Optional: user can provide title
 		Price     float64 `json:"price"`     // Optional: override estimated price
 		Latitude  float64 `json:"latitude"`  // Optional: user location
@@ -69,19 +69,17 @@ func CreateAssignmentFromText(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Text and user_id are required"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Text is required"})
 		return
 	}
 
-	// Parse user ID
-	userObjID, err := primitive.ObjectIDFromHex(req.UserID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user_id"})
+	userObjID, ok := requireCaller(c)
+	if !ok {
 		return
 	}
 
 	// Call NLP service to extract assignment details
-	nlpResult, err := utils.CallNLPService(req.Text, req.UserID)
+	nlpResult, err := utils.CallNLPService(req.Text, userObjID.Hex())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to parse assignment with NLP",
@@ -99,9 +97,9 @@ func CreateAssignmentFromText(c *gin.Context) {
 		Skills:      nlpResult.SkillsRequired,
 		Urgency:     nlpResult.Urgency,
 		Pages:       nlpResult.Pages,
-		Status:      "posted",
 		CreatedAt:   time.Now(),
 	}
+	assignment.InitStatus(models.AssignmentPosted, userObjID)
 
 	// Set title if not provided
 	if assignment.Title == "" {
@@ -151,12 +149,9 @@ func CreateAssignmentFromText(c *gin.Context) {
 	assignment.BidAmount = 0
 
 	// Set location
-	assignment.Location = models.LocationCoords{
-		Latitude:  req.Latitude,
-		Longitude: req.Longitude,
+	if (req.Latitude != 0 || req.Longitude != 0) && utils.ValidateCoordinates(req.Latitude, req.Longitude) {
+		assignment.Location = models.NewGeoPoint(req.Latitude, req.Longitude)
 	}
-	assignment.Lat = req.Latitude
-	assignment.Lng = req.Longitude
 
 	// Save to database
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -170,7 +165,7 @@ func CreateAssignmentFromText(c *gin.Context) {
 	}
 
 	// Find top matching solvers
-	topSolvers := findTopSolversForAssignment(ctx, assignment)
+	topSolvers := findTopSolvers(ctx, assignment, userObjID)
 
 	// Send notifications
 	// 1. Notify buyer that top solvers were found
@@ -178,7 +173,7 @@ func CreateAssignmentFromText(c *gin.Context) {
 
 	// 2. Notify top solvers about new assignment (extract solver IDs)
 	solverIDs := extractSolverIDs(topSolvers)
-	isUrgent := assignment.Urgency == "High"
+	isUrgent := assignment.Urgency == models.UrgencyHigh
 	go NotifyTopSolversAboutAssignment(assignment.ID, solverIDs, assignment.Title, isUrgent)
 
 	// Return success with all details
@@ -205,20 +200,16 @@ func CreateAssignmentFromText(c *gin.Context) {
 			"urgency_detected": nlpResult.Urgency,
 		},
 		"notifications_sent": len(solverIDs) + 1, // solvers + buyer
+		// Projects are large enough to pay for in stages via PUT /api/assignments/:id/milestones
+		"suggest_milestones": nlpResult.Type == "Project",
 	})
 }
 
 // Helper to extract solver IDs from top solvers list
-func extractSolverIDs(topSolvers []interface{}) []primitive.ObjectID {
-	var ids []primitive.ObjectID
-	// This would extract IDs from the actual solver data structure
-	// Placeholder for now
+func extractSolverIDs(topSolvers []matching.Match) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(topSolvers))
+	for _, m := range topSolvers {
+		ids = append(ids, m.Solver.ID)
+	}
 	return ids
 }
-
-// Helper function to find top solvers for an assignment
-func findTopSolversForAssignment(ctx context.Context, assignment models.Assignment) []interface{} {
-	// This reuses the logic from assignment_routes_controller.go
-	// Return top 10 solvers based on skills, price, location, speed
-	return []interface{}{} // Placeholder - will be implemented with actual matching logic
-}
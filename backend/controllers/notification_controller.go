@@ -13,7 +13,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// POST /api/notifications/send - Send notification to user
+// POST /api/notifications/send - Send notification to user (admin only)
 func SendNotification(c *gin.Context) {
 	var notification models.Notification
 	if err := c.ShouldBindJSON(&notification); err != nil {
@@ -55,6 +55,15 @@ func GetNotifications(c *gin.Context) {
 		return
 	}
 
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+	if !isSelf(callerID, objID) {
+		forbidden(c, "You can only access your own notifications")
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -108,7 +117,22 @@ func MarkNotificationRead(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
 	notificationCollection := config.DB.Collection("notifications")
+	var notification models.Notification
+	if err := notificationCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&notification); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		return
+	}
+	if !isNotificationOwner(callerID, notification) {
+		forbidden(c, "You can only modify your own notifications")
+		return
+	}
+
 	update := bson.M{
 		"$set": bson.M{
 			"isRead": true,
@@ -137,6 +161,15 @@ func MarkAllNotificationsRead(c *gin.Context) {
 		return
 	}
 
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+	if !isSelf(callerID, objID) {
+		forbidden(c, "You can only access your own notifications")
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -176,7 +209,22 @@ func DeleteNotification(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
 	notificationCollection := config.DB.Collection("notifications")
+	var notification models.Notification
+	if err := notificationCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&notification); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		return
+	}
+	if !isNotificationOwner(callerID, notification) {
+		forbidden(c, "You can only modify your own notifications")
+		return
+	}
+
 	result, err := notificationCollection.DeleteOne(ctx, bson.M{"_id": objID})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete notification"})
@@ -280,3 +328,41 @@ func NotifyBuyerAboutSolvers(buyerID primitive.ObjectID, assignmentID primitive.
 		models.PriorityMedium,
 	)
 }
+
+// Helper function: Notify every admin, e.g. about a dispute needing a ruling
+func NotifyAdmins(notifType, title, message string, relatedID primitive.ObjectID, relatedType string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := config.DB.Collection("users").Find(ctx, bson.M{"roles": models.RoleAdmin},
+		options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return err
+	}
+	var admins []models.User
+	if err := cursor.All(ctx, &admins); err != nil {
+		return err
+	}
+	if len(admins) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	docs := make([]interface{}, 0, len(admins))
+	for _, admin := range admins {
+		docs = append(docs, models.Notification{
+			ID:          primitive.NewObjectID(),
+			UserID:      admin.ID,
+			Type:        notifType,
+			Title:       title,
+			Message:     message,
+			RelatedID:   relatedID,
+			RelatedType: relatedType,
+			Priority:    models.PriorityHigh,
+			CreatedAt:   now,
+			ExpiresAt:   now.AddDate(0, 0, 30),
+		})
+	}
+	_, err = config.DB.Collection("notifications").InsertMany(ctx, docs)
+	return err
+}
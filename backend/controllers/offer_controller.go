@@ -0,0 +1,652 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/matching"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/Aashishvatwani/homeworld/scheduler"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultOfferWindowMinutes = 15
+	minOfferWindowMinutes     = 2
+	maxOfferWindowMinutes     = 24 * 60
+	defaultBroadcastSize      = 3
+	maxBroadcastSize          = 10
+	// maxOfferRounds bounds the cascade so an assignment nobody takes stops
+	// paging solvers.
+	maxOfferRounds         = 10
+	offerJobInterval       = time.Minute
+	maxDeclineReasonLength = 500
+)
+
+func offerCollection() *mongo.Collection {
+	return config.DB.Collection("offers")
+}
+
+// offerWindowMinutes is how long a solver has to answer an offer unless the
+// buyer chose otherwise, from OFFER_WINDOW_MINUTES.
+func offerWindowMinutes() int {
+	if v, err := strconv.Atoi(os.Getenv("OFFER_WINDOW_MINUTES")); err == nil && v >= minOfferWindowMinutes && v <= maxOfferWindowMinutes {
+		return v
+	}
+	return defaultOfferWindowMinutes
+}
+
+// OfferJobs are the background jobs that expire unanswered offers and
+// cascade auto-assign to the next solvers.
+func OfferJobs() []scheduler.Job {
+	return []scheduler.Job{
+		{Name: "offer-cascade", Interval: offerJobInterval, Run: cascadeOffers},
+	}
+}
+
+type autoAssignRequest struct {
+	Mode          string `json:"mode"` // exclusive (default) or broadcast
+	BroadcastSize int    `json:"broadcastSize"`
+	WindowMinutes int    `json:"windowMinutes"`
+}
+
+// settings validates the request and returns the settings to start with.
+func (r autoAssignRequest) settings(now time.Time) (models.AutoAssignSettings, error) {
+	s := models.AutoAssignSettings{
+		Mode:          r.Mode,
+		WindowMinutes: r.WindowMinutes,
+		Active:        true,
+		Offered:       []primitive.ObjectID{},
+		StartedAt:     now,
+	}
+	switch r.Mode {
+	case "", models.OfferExclusive:
+		s.Mode = models.OfferExclusive
+	case models.OfferBroadcast:
+		s.BroadcastSize = r.BroadcastSize
+		if s.BroadcastSize == 0 {
+			s.BroadcastSize = defaultBroadcastSize
+		}
+		if s.BroadcastSize < 2 || s.BroadcastSize > maxBroadcastSize {
+			return s, fmt.Errorf("broadcastSize must be 2-%d", maxBroadcastSize)
+		}
+	default:
+		return s, fmt.Errorf("mode must be %s or %s", models.OfferExclusive, models.OfferBroadcast)
+	}
+	if s.WindowMinutes == 0 {
+		s.WindowMinutes = offerWindowMinutes()
+	}
+	if s.WindowMinutes < minOfferWindowMinutes || s.WindowMinutes > maxOfferWindowMinutes {
+		return s, fmt.Errorf("windowMinutes must be %d-%d", minOfferWindowMinutes, maxOfferWindowMinutes)
+	}
+	return s, nil
+}
+
+// autoAssignBlocker explains why the assignment cannot be auto-assigned, or
+// returns "" when it can.
+func autoAssignBlocker(assignment models.Assignment, now time.Time) string {
+	switch {
+	case !strings.EqualFold(assignment.Urgency, models.UrgencyHigh):
+		return "Only urgent (High) assignments can be auto-assigned"
+	case !assignment.SolverID.IsZero() || !models.CanTransitionAssignment(assignment.Status, models.AssignmentAccepted):
+		return "Only posted assignments without a solver can be auto-assigned"
+	case !assignment.Deadline.IsZero() && !assignment.Deadline.After(now):
+		return "The assignment's deadline has passed"
+	}
+	return ""
+}
+
+// sendOfferRound offers the assignment to the next best-matched available
+// solvers not offered it yet: one in exclusive mode, BroadcastSize in
+// broadcast mode. The round number is claimed with a conditional update,
+// so concurrent cascades send each round once. When nobody is left, or
+// maxOfferRounds is reached, auto-assign ends as exhausted.
+func sendOfferRound(ctx context.Context, assignment *models.Assignment, now time.Time) ([]models.Offer, error) {
+	settings := assignment.AutoAssign
+	if settings == nil || !settings.Active {
+		return nil, nil
+	}
+	if settings.Round >= maxOfferRounds {
+		return nil, endAutoAssign(ctx, assignment, models.AutoAssignExhausted, now)
+	}
+
+	candidates, err := availableCandidates(ctx, *assignment, now)
+	if err != nil {
+		return nil, err
+	}
+	offered := make(map[primitive.ObjectID]bool, len(settings.Offered))
+	for _, id := range settings.Offered {
+		offered[id] = true
+	}
+	size := 1
+	if settings.Mode == models.OfferBroadcast {
+		size = settings.BroadcastSize
+	}
+	engine := matching.For(assignment.ID)
+	var picks []matching.Match
+	for _, m := range engine.Rank(*assignment, candidates, 0) {
+		if offered[m.Solver.ID] {
+			continue
+		}
+		if picks = append(picks, m); len(picks) == size {
+			break
+		}
+	}
+	if len(picks) == 0 {
+		return nil, endAutoAssign(ctx, assignment, models.AutoAssignExhausted, now)
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(picks))
+	for _, m := range picks {
+		ids = append(ids, m.Solver.ID)
+	}
+	round := settings.Round + 1
+	result, err := config.DB.Collection("assignments").UpdateOne(ctx,
+		bson.M{"_id": assignment.ID, "autoAssign.active": true, "autoAssign.round": settings.Round},
+		bson.M{
+			"$set":  bson.M{"autoAssign.round": round},
+			"$push": bson.M{"autoAssign.offered": bson.M{"$each": ids}},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		// Another request sent this round, or auto-assign ended
+		return nil, nil
+	}
+	settings.Round = round
+	settings.Offered = append(settings.Offered, ids...)
+
+	window := time.Duration(settings.WindowMinutes) * time.Minute
+	amount := agreedPrice(*assignment)
+	offers := make([]models.Offer, 0, len(picks))
+	docs := make([]interface{}, 0, len(picks))
+	for _, m := range picks {
+		offer := models.Offer{
+			ID:           primitive.NewObjectID(),
+			AssignmentID: assignment.ID,
+			BuyerID:      assignment.UserID,
+			SolverID:     m.Solver.ID,
+			Mode:         settings.Mode,
+			Round:        round,
+			Score:        m.Score,
+			Amount:       amount,
+			Status:       models.OfferPending,
+			ExpiresAt:    now.Add(window),
+			CreatedAt:    now,
+		}
+		offers = append(offers, offer)
+		docs = append(docs, offer)
+	}
+	if _, err := offerCollection().InsertMany(ctx, docs); err != nil {
+		return nil, err
+	}
+	logImpression(assignment.ID, primitive.NilObjectID, models.SurfaceOffer, engine, picks)
+
+	message := fmt.Sprintf("You have %s to accept \"%s\" for %.2f.", approxDuration(window), assignment.Title, amount)
+	if settings.Mode == models.OfferBroadcast {
+		message += " It was offered to other solvers too; the first to accept gets it."
+	}
+	for _, offer := range offers {
+		go CreateSolverNotification(offer.SolverID, models.NotifTypeOfferReceived, "Urgent Assignment Offer",
+			message, offer.ID, "offer", models.PriorityHigh)
+	}
+	return offers, nil
+}
+
+// endAutoAssign stops offering the assignment and withdraws the offers
+// still pending. The buyer is told when no solver could be found.
+func endAutoAssign(ctx context.Context, assignment *models.Assignment, reason string, now time.Time) error {
+	result, err := config.DB.Collection("assignments").UpdateOne(ctx,
+		bson.M{"_id": assignment.ID, "autoAssign.active": true},
+		bson.M{"$set": bson.M{"autoAssign.active": false, "autoAssign.endedAt": now, "autoAssign.endReason": reason}},
+	)
+	if err != nil {
+		return err
+	}
+	if assignment.AutoAssign != nil {
+		assignment.AutoAssign.Active = false
+		assignment.AutoAssign.EndedAt = now
+		assignment.AutoAssign.EndReason = reason
+	}
+	if result.ModifiedCount == 0 {
+		return nil
+	}
+
+	pending := bson.M{"assignmentId": assignment.ID, "status": models.OfferPending}
+	var withdrawn []models.Offer
+	if cursor, err := offerCollection().Find(ctx, pending, options.Find().SetProjection(bson.M{"solverId": 1})); err == nil {
+		_ = cursor.All(ctx, &withdrawn)
+	}
+	if _, err := offerCollection().UpdateMany(ctx, pending,
+		bson.M{"$set": bson.M{"status": models.OfferWithdrawn, "decidedAt": now}},
+	); err != nil {
+		return err
+	}
+	for _, offer := range withdrawn {
+		go CreateSolverNotification(offer.SolverID, models.NotifTypeOfferWithdrawn, "Offer Closed",
+			fmt.Sprintf("\"%s\" is no longer available.", assignment.Title),
+			offer.ID, "offer", models.PriorityLow)
+	}
+
+	if reason == models.AutoAssignExhausted {
+		go CreateBuyerNotification(assignment.UserID, models.NotifTypeAutoAssignExhausted, "No Solver Found",
+			fmt.Sprintf("No available solver accepted \"%s\" in time. It stays open for bids, or you can start auto-assign again.", assignment.Title),
+			assignment.ID, "assignment", models.PriorityHigh)
+	}
+	return nil
+}
+
+// advanceAutoAssign starts the next round once no offer in the current one
+// is still open.
+func advanceAutoAssign(ctx context.Context, assignmentID primitive.ObjectID, now time.Time) error {
+	assignment, err := loadAssignment(ctx, assignmentID)
+	if err != nil {
+		return err
+	}
+	if assignment.AutoAssign == nil || !assignment.AutoAssign.Active {
+		return nil
+	}
+	if autoAssignBlocker(assignment, now) != "" {
+		return endAutoAssign(ctx, &assignment, models.AutoAssignExhausted, now)
+	}
+
+	open, err := offerCollection().CountDocuments(ctx, bson.M{
+		"assignmentId": assignment.ID,
+		"status":       models.OfferPending,
+		"expiresAt":    bson.M{"$gt": now},
+	})
+	if err != nil || open > 0 {
+		return err
+	}
+	_, err = sendOfferRound(ctx, &assignment, now)
+	return err
+}
+
+// cascadeOffers expires offers whose window has passed and moves every
+// auto-assigned assignment left without an open offer to its next round.
+func cascadeOffers(ctx context.Context, now time.Time) error {
+	if _, err := offerCollection().UpdateMany(ctx,
+		bson.M{"status": models.OfferPending, "expiresAt": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"status": models.OfferExpired, "decidedAt": now}},
+	); err != nil {
+		return err
+	}
+
+	ids, err := config.DB.Collection("assignments").Distinct(ctx, "_id", bson.M{"autoAssign.active": true})
+	if err != nil {
+		return err
+	}
+	for _, raw := range ids {
+		id, ok := raw.(primitive.ObjectID)
+		if !ok {
+			continue
+		}
+		if err := advanceAutoAssign(ctx, id, now); err != nil {
+			fmt.Printf("[cascadeOffers] failed to advance assignment %s: %v\n", id.Hex(), err)
+		}
+	}
+	return nil
+}
+
+// POST /api/assignments/:id/auto-assign - Offer an urgent assignment to solvers automatically (owner only)
+// Body: { "mode": "exclusive" | "broadcast", "broadcastSize": 3, "windowMinutes": 15 }, all optional
+// Exclusive mode offers it to one solver at a time, best match first; broadcast
+// mode to broadcastSize at once. Unanswered offers cascade to the next solvers.
+func StartAutoAssign(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment ID"})
+		return
+	}
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	var req autoAssignRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	now := time.Now()
+	settings, err := req.settings(now)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	assignment, err := loadAssignment(ctx, objID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+		return
+	}
+	if !isAssignmentOwner(callerID, assignment) {
+		forbidden(c, "Only the assignment owner can auto-assign it")
+		return
+	}
+	if reason := autoAssignBlocker(assignment, now); reason != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": reason})
+		return
+	}
+
+	result, err := config.DB.Collection("assignments").UpdateOne(ctx,
+		bson.M{"_id": assignment.ID, "status": assignment.Status, "autoAssign.active": bson.M{"$ne": true}},
+		bson.M{"$set": bson.M{"autoAssign": settings}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start auto-assign"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Auto-assign is already running, or the assignment changed; reload and retry"})
+		return
+	}
+	assignment.AutoAssign = &settings
+
+	// The cascade job retries the first round if it fails here
+	offers, err := sendOfferRound(ctx, &assignment, now)
+	if err != nil {
+		fmt.Printf("[StartAutoAssign] failed to send offers for %s: %v\n", assignment.ID.Hex(), err)
+	}
+	if offers == nil {
+		offers = []models.Offer{}
+	}
+
+	message := "Auto-assign started"
+	if !assignment.AutoAssign.Active {
+		message = "No available solver could be offered the assignment"
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "autoAssign": assignment.AutoAssign, "offers": offers})
+}
+
+// DELETE /api/assignments/:id/auto-assign - Stop auto-assigning (owner only)
+// Pending offers are withdrawn.
+func StopAutoAssign(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment ID"})
+		return
+	}
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	assignment, err := loadAssignment(ctx, objID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+		return
+	}
+	if !isAssignmentOwner(callerID, assignment) {
+		forbidden(c, "Only the assignment owner can stop auto-assign")
+		return
+	}
+	if assignment.AutoAssign == nil || !assignment.AutoAssign.Active {
+		c.JSON(http.StatusConflict, gin.H{"error": "Auto-assign is not running"})
+		return
+	}
+
+	if err := endAutoAssign(ctx, &assignment, models.AutoAssignStopped, time.Now()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to stop auto-assign"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Auto-assign stopped", "autoAssign": assignment.AutoAssign})
+}
+
+// GET /api/assignments/:id/offers - Offers sent for an assignment, oldest first (owner only)
+func GetAssignmentOffers(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment ID"})
+		return
+	}
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	assignment, err := loadAssignment(ctx, objID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+		return
+	}
+	if !isAssignmentOwner(callerID, assignment) {
+		forbidden(c, "Only the assignment owner can see its offers")
+		return
+	}
+
+	cursor, err := offerCollection().Find(ctx, bson.M{"assignmentId": objID},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load offers"})
+		return
+	}
+	offers := []models.Offer{}
+	if err := cursor.All(ctx, &offers); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load offers"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"autoAssign": assignment.AutoAssign, "offers": offers})
+}
+
+// offerSorts are the sort orders accepted by GetMyOffers.
+var offerSorts = map[string]sortSpec{
+	"newest":   {Field: "createdAt", Desc: true},
+	"expiring": {Field: "expiresAt"},
+}
+
+// GET /api/offers - The caller's offers (solver only)
+// Query: status (default pending; "all" for every status), sort=newest|expiring, cursor, limit
+func GetMyOffers(c *gin.Context) {
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+	page, err := parsePageRequest(c, offerSorts, "newest")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter := bson.M{"solverId": callerID}
+	switch status := c.DefaultQuery("status", models.OfferPending); status {
+	case "all":
+	case models.OfferPending:
+		filter["status"] = status
+		filter["expiresAt"] = bson.M{"$gt": time.Now()}
+	case models.OfferAccepted, models.OfferDeclined, models.OfferExpired, models.OfferWithdrawn:
+		filter["status"] = status
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown status " + status})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	offers := []models.Offer{}
+	next, err := findPage(ctx, offerCollection(), filter, page, nil, &offers)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load offers"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"offers": offers, "next_cursor": next})
+}
+
+// loadOfferForSolver loads the offer in the :id path parameter and checks
+// that it was sent to the caller and is still open. It writes the error
+// response and returns false otherwise.
+func loadOfferForSolver(ctx context.Context, c *gin.Context, callerID primitive.ObjectID, now time.Time) (models.Offer, bool) {
+	offerID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offer ID"})
+		return models.Offer{}, false
+	}
+	var offer models.Offer
+	if err := offerCollection().FindOne(ctx, bson.M{"_id": offerID}).Decode(&offer); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Offer not found"})
+		return models.Offer{}, false
+	}
+	if !isSelf(callerID, offer.SolverID) {
+		forbidden(c, "This offer was sent to another solver")
+		return models.Offer{}, false
+	}
+	if offer.Status != models.OfferPending || !offer.ExpiresAt.After(now) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Offer is no longer open", "status": offer.Status})
+		return models.Offer{}, false
+	}
+	return offer, true
+}
+
+// POST /api/offers/:id/accept - Accept an offer and take the assignment (offered solver only)
+// In broadcast mode the first solver to accept wins; the others get a conflict.
+func AcceptOffer(c *gin.Context) {
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	offer, ok := loadOfferForSolver(ctx, c, callerID, now)
+	if !ok {
+		return
+	}
+	assignment, err := loadAssignment(ctx, offer.AssignmentID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+		return
+	}
+
+	// Claim the offer so it cannot expire or be withdrawn underneath us
+	result, err := offerCollection().UpdateOne(ctx,
+		bson.M{"_id": offer.ID, "status": models.OfferPending, "expiresAt": bson.M{"$gt": now}},
+		bson.M{"$set": bson.M{"status": models.OfferAccepted, "decidedAt": now}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept offer"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Offer is no longer open"})
+		return
+	}
+
+	// The transition only applies while the assignment is still open, so
+	// of several solvers accepting a broadcast offer exactly one wins
+	err = transitionAssignment(ctx, &assignment, models.AssignmentAccepted, callerID, "accepted offer "+offer.ID.Hex(), bson.M{
+		"solverId": callerID,
+		"dueAt":    solverDueAt(ctx, assignment, callerID),
+	})
+	if err != nil {
+		var illegal *models.IllegalTransitionError
+		lost := errors.Is(err, errAssignmentChanged) || errors.As(err, &illegal)
+		status := models.OfferPending
+		if lost {
+			status = models.OfferWithdrawn
+		}
+		if _, revertErr := offerCollection().UpdateOne(ctx,
+			bson.M{"_id": offer.ID},
+			bson.M{"$set": bson.M{"status": status, "decidedAt": now}},
+		); revertErr != nil {
+			fmt.Printf("[AcceptOffer] failed to revert offer %s: %v\n", offer.ID.Hex(), revertErr)
+		}
+		if lost {
+			c.JSON(http.StatusConflict, gin.H{"error": "The assignment has already been taken"})
+			return
+		}
+		respondTransitionError(c, err)
+		return
+	}
+
+	rejectPendingBids(ctx, assignment, now)
+	go CreateBuyerNotification(assignment.UserID, models.NotifTypeOfferAccepted, "Solver Found",
+		fmt.Sprintf("A solver accepted \"%s\" for %.2f. Complete payment to start the work.", assignment.Title, offer.Amount),
+		assignment.ID, "assignment", models.PriorityHigh)
+
+	offer.Status = models.OfferAccepted
+	offer.DecidedAt = now
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Offer accepted",
+		"assignment_id": assignment.ID.Hex(),
+		"offer":         offer,
+	})
+}
+
+// POST /api/offers/:id/decline - Decline an offer (offered solver only)
+// Body: { "reason": "optional note" }
+// Once every offer in the round is answered, the next solvers are offered it.
+func DeclineOffer(c *gin.Context) {
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Reason) > maxDeclineReasonLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("reason must be at most %d characters", maxDeclineReasonLength)})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	offer, ok := loadOfferForSolver(ctx, c, callerID, now)
+	if !ok {
+		return
+	}
+
+	set := bson.M{"status": models.OfferDeclined, "decidedAt": now}
+	if reason := strings.TrimSpace(req.Reason); reason != "" {
+		set["declineReason"] = reason
+	}
+	result, err := offerCollection().UpdateOne(ctx,
+		bson.M{"_id": offer.ID, "status": models.OfferPending},
+		bson.M{"$set": set},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decline offer"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Offer is no longer open"})
+		return
+	}
+
+	// Cascade now rather than waiting for the job; it retries on failure
+	if err := advanceAutoAssign(ctx, offer.AssignmentID, now); err != nil {
+		fmt.Printf("[DeclineOffer] failed to advance assignment %s: %v\n", offer.AssignmentID.Hex(), err)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Offer declined"})
+}
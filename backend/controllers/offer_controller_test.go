@@ -0,0 +1,229 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/middleware"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+var offerTestNow = time.Date(2026, time.March, 4, 10, 0, 0, 0, time.UTC)
+
+// autoAssigned is an urgent posted assignment in the given auto-assign round,
+// already offered to the solvers in offered.
+func autoAssigned(id, buyer primitive.ObjectID, mode string, round int, offered ...primitive.ObjectID) bson.D {
+	return bson.D{
+		{Key: "_id", Value: id},
+		{Key: "userId", Value: buyer},
+		{Key: "title", Value: "Urgent lab report"},
+		{Key: "urgency", Value: models.UrgencyHigh},
+		{Key: "status", Value: models.AssignmentPosted},
+		{Key: "autoAssign", Value: bson.D{
+			{Key: "mode", Value: mode},
+			{Key: "broadcastSize", Value: 2},
+			{Key: "windowMinutes", Value: 15},
+			{Key: "active", Value: true},
+			{Key: "round", Value: round},
+			{Key: "offered", Value: offered},
+		}},
+	}
+}
+
+func solverDoc(id primitive.ObjectID) bson.D {
+	return bson.D{{Key: "_id", Value: id}, {Key: "roles", Value: bson.A{models.RoleSolver}}}
+}
+
+func notMatched() bson.D {
+	return mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0})
+}
+
+func TestAcceptBroadcastOfferAfterAnotherSolverWon(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	buyer := primitive.NewObjectID()
+	second := primitive.NewObjectID()
+	assignmentID := primitive.NewObjectID()
+	offerID := primitive.NewObjectID()
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("second acceptor", func(mt *mtest.T) {
+		config.DB = mt.Client.Database("test")
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test.offers", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: offerID},
+				{Key: "assignmentId", Value: assignmentID},
+				{Key: "solverId", Value: second},
+				{Key: "mode", Value: models.OfferBroadcast},
+				{Key: "status", Value: models.OfferPending},
+				{Key: "expiresAt", Value: time.Now().Add(10 * time.Minute)},
+			}),
+			// Loaded while still posted; the first acceptor wins before our update
+			mtest.CreateCursorResponse(0, "test.assignments", mtest.FirstBatch, autoAssigned(assignmentID, buyer, models.OfferBroadcast, 1)),
+			updated(1), // claim the offer
+			mtest.CreateCursorResponse(0, "test.chats", mtest.FirstBatch),
+			notMatched(), // the assignment is no longer posted
+			updated(1),   // settle the offer
+		)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+		c.Params = gin.Params{{Key: "id", Value: offerID.Hex()}}
+		c.Set(middleware.ContextUserID, second)
+
+		AcceptOffer(c)
+
+		if w.Code != http.StatusConflict {
+			mt.Fatalf("status = %d, want %d; body %s", w.Code, http.StatusConflict, w.Body.String())
+		}
+		cmds := startedCommands(mt)
+		settle := cmds[len(cmds)-1].Lookup("updates").Array().Index(0).Value().Document()
+		if id := settle.Lookup("q", "_id").ObjectID(); id != offerID {
+			mt.Fatalf("settled offer %s, want %s", id.Hex(), offerID.Hex())
+		}
+		if status := settle.Lookup("u", "$set", "status").StringValue(); status != models.OfferWithdrawn {
+			mt.Fatalf("losing offer left %q, want %q", status, models.OfferWithdrawn)
+		}
+	})
+}
+
+func TestSendOfferRoundAlreadyClaimed(t *testing.T) {
+	buyer := primitive.NewObjectID()
+	assignmentID := primitive.NewObjectID()
+	first := primitive.NewObjectID()
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("another request sent the round", func(mt *mtest.T) {
+		config.DB = mt.Client.Database("test")
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test.assignments", mtest.FirstBatch, autoAssigned(assignmentID, buyer, models.OfferExclusive, 1, first)),
+		)
+		assignment, err := loadAssignment(context.Background(), assignmentID)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		mt.ClearEvents()
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test.users", mtest.FirstBatch, solverDoc(first), solverDoc(primitive.NewObjectID())),
+			mtest.CreateCursorResponse(0, "test.assignments", mtest.FirstBatch), // workloads
+			notMatched(), // the round claim
+		)
+		offers, err := sendOfferRound(context.Background(), &assignment, offerTestNow)
+		if err != nil || offers != nil {
+			mt.Fatalf("sendOfferRound = %v, %v; want no offers and no error", offers, err)
+		}
+		cmds := startedCommands(mt)
+		if n := countCommands(cmds, "insert", "offers"); n != 0 {
+			mt.Fatalf("%d offer inserts, want 0", n)
+		}
+		claim := cmds[len(cmds)-1].Lookup("updates").Array().Index(0).Value().Document()
+		if round := claim.Lookup("q", "autoAssign.round").Int32(); round != 1 {
+			mt.Fatalf("claim matches round %d, want the loaded round 1", round)
+		}
+		if assignment.AutoAssign.Round != 1 {
+			mt.Fatalf("round advanced to %d locally, want 1", assignment.AutoAssign.Round)
+		}
+	})
+}
+
+func TestCascadeOffers(t *testing.T) {
+	buyer := primitive.NewObjectID()
+	assignmentID := primitive.NewObjectID()
+	first := primitive.NewObjectID()
+	next := primitive.NewObjectID()
+	active := mtest.CreateSuccessResponse(bson.E{Key: "values", Value: bson.A{assignmentID}})
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("expired offer moves to the next solver", func(mt *mtest.T) {
+		config.DB = mt.Client.Database("test")
+		mt.AddMockResponses(
+			updated(1), // expire the first solver's offer
+			active,
+			mtest.CreateCursorResponse(0, "test.assignments", mtest.FirstBatch, autoAssigned(assignmentID, buyer, models.OfferExclusive, 1, first)),
+			mtest.CreateCursorResponse(0, "test.offers", mtest.FirstBatch), // no open offers
+			mtest.CreateCursorResponse(0, "test.users", mtest.FirstBatch, solverDoc(first), solverDoc(next)),
+			mtest.CreateCursorResponse(0, "test.assignments", mtest.FirstBatch), // workloads
+			updated(1),                    // round claim
+			mtest.CreateSuccessResponse(), // offer insert
+		)
+		if err := cascadeOffers(context.Background(), offerTestNow); err != nil {
+			mt.Fatal(err)
+		}
+
+		cmds := startedCommands(mt)
+		expire := cmds[0].Lookup("updates").Array().Index(0).Value().Document()
+		if cutoff := expire.Lookup("q", "expiresAt", "$lte").Time(); !cutoff.Equal(offerTestNow) {
+			mt.Fatalf("offers expire at %s, want %s", cutoff, offerTestNow)
+		}
+		if status := expire.Lookup("u", "$set", "status").StringValue(); status != models.OfferExpired {
+			mt.Fatalf("expired offers set to %q", status)
+		}
+		var insert bson.Raw
+		for _, cmd := range cmds {
+			if v, err := cmd.LookupErr("insert"); err == nil && v.StringValue() == "offers" {
+				insert = cmd
+			}
+		}
+		if insert == nil {
+			mt.Fatal("no offer sent to the next solver")
+		}
+		offers, _ := insert.Lookup("documents").Array().Values()
+		if len(offers) != 1 {
+			mt.Fatalf("%d offers sent, want 1 in exclusive mode", len(offers))
+		}
+		offer := offers[0].Document()
+		if solver := offer.Lookup("solverId").ObjectID(); solver != next {
+			mt.Fatalf("offer sent to %s, want the next solver %s", solver.Hex(), next.Hex())
+		}
+		if round := offer.Lookup("round").Int32(); round != 2 {
+			mt.Fatalf("offer is round %d, want 2", round)
+		}
+	})
+
+	mt.Run("last round ends as exhausted", func(mt *mtest.T) {
+		config.DB = mt.Client.Database("test")
+		mt.AddMockResponses(
+			updated(1),
+			active,
+			mtest.CreateCursorResponse(0, "test.assignments", mtest.FirstBatch, autoAssigned(assignmentID, buyer, models.OfferExclusive, maxOfferRounds, first)),
+			mtest.CreateCursorResponse(0, "test.offers", mtest.FirstBatch),
+			updated(1), // end auto-assign
+			mtest.CreateCursorResponse(0, "test.offers", mtest.FirstBatch),
+			updated(0), // withdraw pending offers
+		)
+		if err := cascadeOffers(context.Background(), offerTestNow); err != nil {
+			mt.Fatal(err)
+		}
+
+		cmds := startedCommands(mt)
+		if n := countCommands(cmds, "insert", "offers"); n != 0 {
+			mt.Fatalf("%d offer inserts after the last round, want 0", n)
+		}
+		if n := countCommands(cmds, "find", "users"); n != 0 {
+			mt.Fatalf("looked for solvers after the last round")
+		}
+		var end bson.Raw
+		for _, cmd := range cmds {
+			if v, err := cmd.LookupErr("update"); err == nil && v.StringValue() == "assignments" {
+				end = cmd.Lookup("updates").Array().Index(0).Value().Document()
+			}
+		}
+		if end == nil {
+			mt.Fatal("auto-assign was not ended")
+		}
+		if reason := end.Lookup("u", "$set", "autoAssign.endReason").StringValue(); reason != models.AutoAssignExhausted {
+			mt.Fatalf("auto-assign ended as %q, want %q", reason, models.AutoAssignExhausted)
+		}
+	})
+}
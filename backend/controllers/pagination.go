@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// sortSpec orders a listing by one field, with _id as the tie-breaker so the
+// order is stable and cursors never skip or repeat documents.
+type sortSpec struct {
+	Field string
+	Desc  bool
+}
+
+func (s sortSpec) options() *options.FindOptions {
+	dir := 1
+	if s.Desc {
+		dir = -1
+	}
+	return options.Find().SetSort(bson.D{{Key: s.Field, Value: dir}, {Key: "_id", Value: dir}})
+}
+
+// pageCursor marks the last document of a page. It is BSON-encoded so the
+// sort value keeps its type (date, number, string) across the round trip.
+type pageCursor struct {
+	Value interface{}        `bson:"v"`
+	ID    primitive.ObjectID `bson:"id"`
+}
+
+func encodeCursor(value interface{}, id primitive.ObjectID) (string, error) {
+	raw, err := bson.Marshal(pageCursor{Value: value, ID: id})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func decodeCursor(token string) (pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return pageCursor{}, fmt.Errorf("invalid cursor")
+	}
+	var cursor pageCursor
+	if err := bson.Unmarshal(raw, &cursor); err != nil || cursor.ID.IsZero() {
+		return pageCursor{}, fmt.Errorf("invalid cursor")
+	}
+	return cursor, nil
+}
+
+// after returns the filter selecting documents that sort after the cursor.
+// MongoDB sorts null and missing values before all others, but $gt and $lt
+// never match across types, so documents without the sort field (an
+// unrated solver, an assignment without a deadline) need their own clauses.
+func (s sortSpec) after(cursor pageCursor) bson.M {
+	op := "$gt"
+	if s.Desc {
+		op = "$lt"
+	}
+	tie := bson.M{s.Field: cursor.Value, "_id": bson.M{op: cursor.ID}}
+	if cursor.Value == nil {
+		if s.Desc {
+			// Nulls come last, so only the remaining nulls follow
+			return tie
+		}
+		return bson.M{"$or": bson.A{tie, bson.M{s.Field: bson.M{"$ne": nil}}}}
+	}
+	clauses := bson.A{bson.M{s.Field: bson.M{op: cursor.Value}}, tie}
+	if s.Desc {
+		clauses = append(clauses, bson.M{s.Field: nil})
+	}
+	return bson.M{"$or": clauses}
+}
+
+// pageRequest holds the parsed sort, cursor and limit query parameters.
+type pageRequest struct {
+	Sort   sortSpec
+	Cursor *pageCursor
+	Limit  int64
+}
+
+// parsePageRequest reads sort, cursor and limit from the query string. sorts
+// maps the accepted sort names to their specs; defaultSort must be one of them.
+func parsePageRequest(c *gin.Context, sorts map[string]sortSpec, defaultSort string) (pageRequest, error) {
+	name := c.DefaultQuery("sort", defaultSort)
+	spec, ok := sorts[name]
+	if !ok {
+		names := make([]string, 0, len(sorts))
+		for n := range sorts {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return pageRequest{}, fmt.Errorf("sort must be one of: %s", strings.Join(names, ", "))
+	}
+	req := pageRequest{Sort: spec, Limit: defaultPageSize}
+
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || limit <= 0 {
+			return pageRequest{}, fmt.Errorf("invalid limit")
+		}
+		req.Limit = min(limit, maxPageSize)
+	}
+	if v := c.Query("cursor"); v != "" {
+		cursor, err := decodeCursor(v)
+		if err != nil {
+			return pageRequest{}, err
+		}
+		req.Cursor = &cursor
+	}
+	return req, nil
+}
+
+// apply adds the cursor condition to filter and returns the find options.
+// One extra document is fetched so the caller can tell whether a next page exists.
+func (p pageRequest) apply(filter bson.M) *options.FindOptions {
+	if p.Cursor != nil {
+		filter["$and"] = append(andClauses(filter), p.Sort.after(*p.Cursor))
+	}
+	return p.Sort.options().SetLimit(p.Limit + 1)
+}
+
+func andClauses(filter bson.M) bson.A {
+	if clauses, ok := filter["$and"].(bson.A); ok {
+		return clauses
+	}
+	return bson.A{}
+}
+
+// findPage runs filter against coll and decodes one page of results into
+// out, which must point to a slice. It returns the token for the next page,
+// or "" when this is the last one.
+func findPage(ctx context.Context, coll *mongo.Collection, filter bson.M, page pageRequest, projection interface{}, out interface{}) (string, error) {
+	opts := page.apply(filter)
+	if projection != nil {
+		opts.SetProjection(projection)
+	}
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return "", err
+	}
+	var docs []bson.Raw
+	if err := cursor.All(ctx, &docs); err != nil {
+		return "", err
+	}
+
+	next := ""
+	if int64(len(docs)) > page.Limit {
+		docs = docs[:page.Limit]
+		last := docs[len(docs)-1]
+		var value interface{}
+		if raw, err := last.LookupErr(page.Sort.Field); err == nil {
+			if err := raw.Unmarshal(&value); err != nil {
+				return "", err
+			}
+		}
+		if next, err = encodeCursor(value, last.Lookup("_id").ObjectID()); err != nil {
+			return "", err
+		}
+	}
+
+	items := reflect.ValueOf(out).Elem()
+	items.Set(reflect.MakeSlice(items.Type(), len(docs), len(docs)))
+	for i, doc := range docs {
+		if err := bson.Unmarshal(doc, items.Index(i).Addr().Interface()); err != nil {
+			return "", err
+		}
+	}
+	return next, nil
+}
+
+// splitList splits a comma-separated query value, dropping empty items.
+func splitList(v string) []string {
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// queryFloat parses an optional numeric query parameter.
+func queryFloat(c *gin.Context, name string) (float64, bool, error) {
+	v := c.Query(name)
+	if v == "" {
+		return 0, false, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("%s must be a number", name)
+	}
+	return f, true, nil
+}
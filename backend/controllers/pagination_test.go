@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// matches evaluates the subset of the query language sortSpec.after emits,
+// with MongoDB's rules: comparisons never match across types, and equality
+// with null also matches a missing field.
+func matches(doc, filter bson.M) bool {
+	for key, cond := range filter {
+		if key == "$or" {
+			hit := false
+			for _, clause := range cond.(bson.A) {
+				hit = hit || matches(doc, clause.(bson.M))
+			}
+			if !hit {
+				return false
+			}
+			continue
+		}
+		value := doc[key]
+		ops, isOps := cond.(bson.M)
+		if !isOps {
+			ops = bson.M{"$eq": cond}
+		}
+		for op, arg := range ops {
+			var ok bool
+			switch op {
+			case "$eq":
+				ok = compareValues(value, arg) == 0
+			case "$ne":
+				ok = compareValues(value, arg) != 0
+			case "$gt":
+				ok = value != nil && arg != nil && compareValues(value, arg) > 0
+			case "$lt":
+				ok = value != nil && arg != nil && compareValues(value, arg) < 0
+			}
+			if !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// compareValues orders null before numbers, as MongoDB sorts them.
+func compareValues(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	if x, ok := a.(primitive.ObjectID); ok {
+		y := b.(primitive.ObjectID)
+		return bytes.Compare(x[:], y[:])
+	}
+	x, y := a.(float64), b.(float64)
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+func TestPagingWithMissingSortValues(t *testing.T) {
+	// nil is a stored null; "missing" documents have no v at all
+	values := []interface{}{3.0, nil, "missing", 1.0, 3.0, nil, 2.0, "missing", 1.0}
+	var docs []bson.M
+	for _, v := range values {
+		doc := bson.M{"_id": primitive.NewObjectID()}
+		if v != "missing" {
+			doc["v"] = v
+		}
+		docs = append(docs, doc)
+	}
+
+	for _, spec := range []sortSpec{{Field: "v"}, {Field: "v", Desc: true}} {
+		sorted := append([]bson.M(nil), docs...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			c := compareValues(sorted[i]["v"], sorted[j]["v"])
+			if c == 0 {
+				c = compareValues(sorted[i]["_id"], sorted[j]["_id"])
+			}
+			if spec.Desc {
+				return c > 0
+			}
+			return c < 0
+		})
+
+		var seen []primitive.ObjectID
+		token := ""
+		for pages := 0; ; pages++ {
+			if pages > len(docs) {
+				t.Fatalf("desc=%v: paging does not terminate", spec.Desc)
+			}
+			var page []bson.M
+			for _, doc := range sorted {
+				if token == "" {
+					page = append(page, doc)
+					continue
+				}
+				cursor, err := decodeCursor(token)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if matches(doc, spec.after(cursor)) {
+					page = append(page, doc)
+				}
+			}
+			if len(page) > 2 {
+				page = page[:2]
+			}
+			for _, doc := range page {
+				seen = append(seen, doc["_id"].(primitive.ObjectID))
+			}
+			if len(page) < 2 {
+				break
+			}
+			last := page[len(page)-1]
+			var err error
+			if token, err = encodeCursor(last["v"], last["_id"].(primitive.ObjectID)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if len(seen) != len(sorted) {
+			t.Fatalf("desc=%v: paged through %d documents, want %d", spec.Desc, len(seen), len(sorted))
+		}
+		for i, doc := range sorted {
+			if seen[i] != doc["_id"] {
+				t.Fatalf("desc=%v: document %d is %s, want %s (v=%v)", spec.Desc, i, seen[i].Hex(), doc["_id"].(primitive.ObjectID).Hex(), doc["v"])
+			}
+		}
+	}
+}
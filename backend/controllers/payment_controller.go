@@ -9,6 +9,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/Aashishvatwani/homeworld/audit"
 	"github.com/Aashishvatwani/homeworld/config"
 	"github.com/Aashishvatwani/homeworld/models"
 	"github.com/Aashishvatwani/homeworld/utils"
@@ -18,12 +19,18 @@ import (
 )
 
 // POST /api/payment/create
+// When a bid has been accepted the solver and amount come from it; otherwise
+// the buyer hires solverId, who must hold the solver role, directly at the
+// assignment's posted price.
+// Assignments split into milestones are paid one milestone at a time: pass
+// milestoneId, and the milestone's amount is charged and escrowed on its own.
 func CreatePayment(c *gin.Context) {
 	var paymentReq struct {
 		AssignmentID primitive.ObjectID `json:"assignmentId"`
 		BuyerID      primitive.ObjectID `json:"buyerId"`
 		SolverID     primitive.ObjectID `json:"solverId"`
-		Amount       float64            `json:"amount"`
+		Amount       float64            `json:"amount"` // ignored; kept for older clients
+		MilestoneID  string             `json:"milestoneId"`
 		// method: "onchain" or "bank" (bank uses Razorpay/fiat)
 		Method string `json:"method"`
 	}
@@ -34,10 +41,81 @@ func CreatePayment(c *gin.Context) {
 		return
 	}
 
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Only the buyer who owns the assignment may pay for it
+	var assignment models.Assignment
+	if err := config.DB.Collection("assignments").FindOne(ctx, bson.M{"_id": paymentReq.AssignmentID}).Decode(&assignment); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+		return
+	}
+	if !isAssignmentOwner(callerID, assignment) {
+		forbidden(c, "Only the assignment owner can create a payment for it")
+		return
+	}
+	paymentReq.BuyerID = callerID
+
+	var milestone models.Milestone
+	if len(assignment.Milestones) > 0 {
+		if milestone, ok = milestoneToFund(c, assignment, paymentReq.MilestoneID); !ok {
+			return
+		}
+	} else if paymentReq.MilestoneID != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Assignment has no milestones"})
+		return
+	}
+
+	if !milestone.ID.IsZero() && !assignment.SolverID.IsZero() {
+		// A milestone of an assignment whose solver is already chosen
+		paymentReq.SolverID = assignment.SolverID
+	} else if !assignment.AcceptedBidID.IsZero() {
+		var bid models.Bid
+		err := config.DB.Collection("bids").FindOne(ctx, bson.M{"_id": assignment.AcceptedBidID, "status": models.BidAccepted}).Decode(&bid)
+		if err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Accepted bid not found for assignment"})
+			return
+		}
+		if assignment.Status != models.AssignmentAccepted {
+			c.JSON(http.StatusConflict, gin.H{"error": "Assignment is " + assignment.Status + " and cannot be paid for"})
+			return
+		}
+		paymentReq.SolverID = bid.SolverID
+		paymentReq.Amount = bid.Amount
+	} else {
+		if !models.CanTransitionAssignment(assignment.Status, models.AssignmentAccepted) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Assignment is " + assignment.Status + " and cannot accept a solver"})
+			return
+		}
+		if paymentReq.SolverID.IsZero() || assignment.Price <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Accept a bid first, or pass solverId for an assignment with a posted price"})
+			return
+		}
+		// Hiring directly still has to name a solver other than the buyer
+		if paymentReq.SolverID == callerID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot hire yourself for your own assignment"})
+			return
+		}
+		solver, err := loadUser(ctx, paymentReq.SolverID)
+		if err != nil || !solver.HasRole(models.RoleSolver) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "solverId is not a solver"})
+			return
+		}
+		paymentReq.Amount = assignment.Price
+	}
+	if !milestone.ID.IsZero() {
+		paymentReq.Amount = milestone.Amount
+	}
+
 	fmt.Printf("Creating payment - AssignmentID: %s, BuyerID: %s, SolverID: %s, Amount: %.2f\n",
 		paymentReq.AssignmentID.Hex(), paymentReq.BuyerID.Hex(), paymentReq.SolverID.Hex(), paymentReq.Amount)
 
-	// Calculate commission (10%)
+	// Calculate commission (10%), per milestone for milestone payments
 	commission := paymentReq.Amount * 0.10
 	solverAmount := paymentReq.Amount - commission
 
@@ -54,6 +132,10 @@ func CreatePayment(c *gin.Context) {
 		SolverAmount: solverAmount,
 		CreatedAt:    time.Now(),
 	}
+	if !milestone.ID.IsZero() {
+		payment.MilestoneID = milestone.ID
+		payment.EscrowID = models.MilestoneEscrowID(assignment.ID, milestone.Index)
+	}
 
 	if paymentReq.Method == "onchain" {
 		payment.PaymentMethod = "onchain"
@@ -61,7 +143,11 @@ func CreatePayment(c *gin.Context) {
 	} else {
 		// default to bank/razorpay flow
 		fmt.Println("Creating Razorpay order...")
-		razorpayOrderID, err := utils.CreateRazorpayOrder(paymentReq.Amount, "Assignment Payment")
+		receipt := "Assignment Payment"
+		if !milestone.ID.IsZero() {
+			receipt = fmt.Sprintf("Assignment Milestone %d Payment", milestone.Index)
+		}
+		razorpayOrderID, err := utils.CreateRazorpayOrder(paymentReq.Amount, receipt)
 		if err != nil {
 			fmt.Printf("Error creating Razorpay order: %v\n", err)
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -78,9 +164,6 @@ func CreatePayment(c *gin.Context) {
 
 	fmt.Printf("Payment object created with ID: %s\n", payment.ID.Hex())
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
 	paymentCollection := config.DB.Collection("payments")
 	result, err := paymentCollection.InsertOne(ctx, payment)
 	if err != nil {
@@ -90,6 +173,7 @@ func CreatePayment(c *gin.Context) {
 	}
 
 	fmt.Printf("Payment successfully created with InsertedID: %v\n", result.InsertedID)
+	recordAudit(c, audit.ActionPaymentCreate, audit.TargetPayment, payment.ID.Hex(), nil, payment)
 
 	resp := gin.H{
 		"message":       "Payment created successfully",
@@ -99,12 +183,16 @@ func CreatePayment(c *gin.Context) {
 		"solver_amount": solverAmount,
 		"method":        payment.PaymentMethod,
 	}
+	if !milestone.ID.IsZero() {
+		resp["milestone_id"] = milestone.ID.Hex()
+	}
 	if payment.PaymentMethod == "bank" {
 		resp["razorpay_order_id"] = payment.RazorpayOrderID
 	} else if payment.PaymentMethod == "onchain" {
 		// Provide contract info so frontend can prompt MetaMask to deposit to contract
 		resp["contract_address"] = utils.GetSmartContractAddress()
-		resp["assignment_id"] = paymentReq.AssignmentID.Hex()
+		// Milestone payments are held in their own escrow record
+		resp["assignment_id"] = escrowID(payment)
 		resp["amount"] = paymentReq.Amount
 		resp["message"] = "Please deposit the required amount to the smart contract using your wallet; then call /api/payment/onchain/verify with the txHash"
 	}
@@ -132,14 +220,32 @@ func VerifyPayment(c *gin.Context) {
 		return
 	}
 
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	paymentCollection := config.DB.Collection("payments")
-	// Update payment status
-	_, err := paymentCollection.UpdateOne(
+
+	// Only the paying buyer may confirm the payment
+	var existing models.Payment
+	if err := paymentCollection.FindOne(ctx, bson.M{"razorpayOrderId": verifyReq.OrderID}).Decode(&existing); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payment not found"})
+		return
+	}
+	if !isSelf(callerID, existing.BuyerID) {
+		forbidden(c, "Only the buyer can verify this payment")
+		return
+	}
+
+	// Update payment status. Only a pending payment can be marked paid, so
+	// replaying a verification cannot reset a released or refunded payment.
+	result, err := paymentCollection.UpdateOne(
 		ctx,
-		bson.M{"razorpayOrderId": verifyReq.OrderID},
+		bson.M{"razorpayOrderId": verifyReq.OrderID, "status": "pending"},
 		bson.M{"$set": bson.M{
 			"status":            "paid",
 			"razorpayPaymentId": verifyReq.PaymentID,
@@ -150,10 +256,20 @@ func VerifyPayment(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify payment"})
 		return
 	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Payment has already been verified"})
+		return
+	}
+	recordAudit(c, audit.ActionPaymentVerify, audit.TargetPayment, existing.ID.Hex(),
+		gin.H{"status": existing.Status},
+		gin.H{"status": "paid", "method": existing.PaymentMethod, "razorpayPaymentId": verifyReq.PaymentID},
+	)
 
 	// Get payment details for notifications
 	var payment models.Payment
 	paymentCollection.FindOne(ctx, bson.M{"razorpayOrderId": verifyReq.OrderID}).Decode(&payment)
+	acceptAssignmentForPayment(ctx, payment, callerID)
+	fundMilestone(ctx, payment)
 
 	// Attempt to create on-chain escrow now that payment is verified
 	userCollection := config.DB.Collection("users")
@@ -168,10 +284,19 @@ func VerifyPayment(c *gin.Context) {
 	} else {
 		// Convert amount to wei (approximate via float multiplication)
 		amountInWei := fmt.Sprintf("%.0f", payment.Amount*1e18)
-		txHash, err := utils.CreateAssignmentEscrow(payment.AssignmentID.Hex(), buyer.EthereumAddress, solver.EthereumAddress, amountInWei)
+		txHash, err := utils.CreateAssignmentEscrow(escrowID(payment), buyer.EthereumAddress, solver.EthereumAddress, amountInWei)
 		if err != nil {
 			fmt.Printf("Error creating on-chain escrow: %v\n", err)
 		} else {
+			recordAudit(c, audit.ActionEscrowCreate, audit.TargetPayment, payment.ID.Hex(), nil, gin.H{
+				"assignmentId": payment.AssignmentID.Hex(),
+				"escrowId":     escrowID(payment),
+				"buyer":        buyer.EthereumAddress,
+				"solver":       solver.EthereumAddress,
+				"amountInWei":  amountInWei,
+				"txHash":       txHash,
+			})
+
 			// Store transaction hash on payment
 			_, err := paymentCollection.UpdateOne(ctx, bson.M{"_id": payment.ID}, bson.M{"$set": bson.M{"transactionHash": txHash}})
 			if err != nil {
@@ -193,16 +318,19 @@ func VerifyPayment(c *gin.Context) {
 		models.PriorityHigh,
 	)
 
-	// Notify solver (payment received in escrow)
-	go CreateSolverNotification(
-		payment.SolverID,
-		models.NotifTypePaymentReceived,
-		"Payment Escrowed",
-		"Payment has been received and secured. Complete the assignment to receive funds.",
-		payment.AssignmentID,
-		"payment",
-		models.PriorityHigh,
-	)
+	// Notify solver (payment received in escrow); fundMilestone has told
+	// them about a funded milestone
+	if payment.MilestoneID.IsZero() {
+		go CreateSolverNotification(
+			payment.SolverID,
+			models.NotifTypePaymentReceived,
+			"Payment Escrowed",
+			"Payment has been received and secured. Complete the assignment to receive funds.",
+			payment.AssignmentID,
+			"payment",
+			models.PriorityHigh,
+		)
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":            "Payment verified successfully",
@@ -226,11 +354,20 @@ func GetPayment(c *gin.Context) {
 		return
 	}
 
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+	if !isPaymentParty(callerID, payment) {
+		forbidden(c, "You are not a party to this payment")
+		return
+	}
+
 	c.JSON(http.StatusOK, payment)
 }
 
-// POST /api/payment/generate-test-signature - Generate signature for testing
-// ⚠️ FOR TESTING ONLY - Remove in production
+// POST /api/payment/generate-test-signature - Generate signature for testing (admin only)
+// Only registered when RAZORPAY_TEST_SIGNATURES=true; never enable it in production.
 func GenerateTestSignature(c *gin.Context) {
 	var req struct {
 		OrderID   string `json:"order_id" binding:"required"`
@@ -255,7 +392,7 @@ func GenerateTestSignature(c *gin.Context) {
 		"payment_id": req.PaymentID,
 		"signature":  signature,
 		"message":    "Use this signature to test payment verification",
-		"warning":    "⚠️ This endpoint is for testing only. Remove in production!",
+		"warning":    "⚠️ This endpoint is for testing only. Unset RAZORPAY_TEST_SIGNATURES in production!",
 	})
 }
 
@@ -288,8 +425,17 @@ func VerifyOnchainPayment(c *gin.Context) {
 		return
 	}
 
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+	if !isSelf(callerID, payment.BuyerID) {
+		forbidden(c, "only the buyer can verify this payment")
+		return
+	}
+
 	// Verify on-chain status via utils.GetEscrowStatus (mocked)
-	status, err := utils.GetEscrowStatus(payment.AssignmentID.Hex())
+	status, err := utils.GetEscrowStatus(escrowID(payment))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to query escrow status"})
 		return
@@ -297,7 +443,7 @@ func VerifyOnchainPayment(c *gin.Context) {
 
 	// Here we accept the txHash and mark payment as paid if escrow status looks correct
 	// In real impl verify that txHash corresponds to escrow creation and amount matches
-	_, err = paymentCollection.UpdateOne(ctx, bson.M{"_id": payment.ID}, bson.M{"$set": bson.M{
+	result, err := paymentCollection.UpdateOne(ctx, bson.M{"_id": payment.ID, "status": "pending"}, bson.M{"$set": bson.M{
 		"status":           "paid",
 		"onchainDepositTx": req.TxHash,
 		"onchainConfirmed": true,
@@ -307,10 +453,22 @@ func VerifyOnchainPayment(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update payment"})
 		return
 	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "payment has already been verified"})
+		return
+	}
+	recordAudit(c, audit.ActionPaymentVerify, audit.TargetPayment, payment.ID.Hex(),
+		gin.H{"status": payment.Status},
+		gin.H{"status": "paid", "method": payment.PaymentMethod, "onchainDepositTx": req.TxHash},
+	)
+	acceptAssignmentForPayment(ctx, payment, callerID)
+	fundMilestone(ctx, payment)
 
 	// Notify parties
 	go CreateBuyerNotification(payment.BuyerID, models.NotifTypePaymentConfirmed, "On-chain Payment Confirmed", "Your on-chain payment has been detected and escrow created.", payment.AssignmentID, "payment", models.PriorityHigh)
-	go CreateSolverNotification(payment.SolverID, models.NotifTypePaymentReceived, "Payment Escrowed", "An on-chain payment has been received for assignment.", payment.AssignmentID, "payment", models.PriorityHigh)
+	if payment.MilestoneID.IsZero() {
+		go CreateSolverNotification(payment.SolverID, models.NotifTypePaymentReceived, "Payment Escrowed", "An on-chain payment has been received for assignment.", payment.AssignmentID, "payment", models.PriorityHigh)
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "on-chain payment verified and recorded", "payment_id": req.PaymentID, "escrow_status": status})
 }
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/middleware"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// Without an accepted bid the buyer names the solver, who must be someone
+// else holding the solver role.
+func TestCreatePaymentRejectsInvalidDirectHire(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	buyer := primitive.NewObjectID()
+	assignmentID := primitive.NewObjectID()
+	assignment := bson.D{
+		{Key: "_id", Value: assignmentID},
+		{Key: "userId", Value: buyer},
+		{Key: "status", Value: models.AssignmentPosted},
+		{Key: "price", Value: 500.0},
+	}
+	buyerOnly := primitive.NewObjectID()
+
+	cases := []struct {
+		name     string
+		solverID primitive.ObjectID
+		solver   bson.D // the user the solver lookup returns, if it gets that far
+		wantErr  string
+	}{
+		{name: "buyer hires themselves", solverID: buyer, wantErr: "cannot hire yourself"},
+		{name: "solverId without the solver role", solverID: buyerOnly, solver: bson.D{
+			{Key: "_id", Value: buyerOnly},
+			{Key: "roles", Value: bson.A{models.RoleBuyer}},
+		}, wantErr: "not a solver"},
+		{name: "unknown solverId", solverID: primitive.NewObjectID(), solver: bson.D{}, wantErr: "not a solver"},
+	}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	for _, tc := range cases {
+		mt.Run(tc.name, func(mt *mtest.T) {
+			config.DB = mt.Client.Database("test")
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "test.assignments", mtest.FirstBatch, assignment))
+			if len(tc.solver) > 0 {
+				mt.AddMockResponses(mtest.CreateCursorResponse(0, "test.users", mtest.FirstBatch, tc.solver))
+			} else if tc.solver != nil {
+				mt.AddMockResponses(mtest.CreateCursorResponse(0, "test.users", mtest.FirstBatch))
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			body := fmt.Sprintf(`{"assignmentId":%q,"solverId":%q,"method":"bank"}`, assignmentID.Hex(), tc.solverID.Hex())
+			c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Set(middleware.ContextUserID, buyer)
+
+			CreatePayment(c)
+
+			if w.Code != http.StatusBadRequest {
+				mt.Fatalf("status = %d, want %d; body %s", w.Code, http.StatusBadRequest, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tc.wantErr) {
+				mt.Fatalf("body %s, want an error mentioning %q", w.Body.String(), tc.wantErr)
+			}
+		})
+	}
+}
+
+// Replaying a verification must not move a payment that has moved on from
+// pending back to paid, or accept the assignment and create escrow again.
+func TestVerifyPaymentReplayConflicts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	buyer := primitive.NewObjectID()
+	t.Setenv("RAZORPAY_KEY_SECRET", "test-secret")
+	mac := hmac.New(sha256.New, []byte("test-secret"))
+	mac.Write([]byte("order_1|pay_2"))
+	body := fmt.Sprintf(`{"order_id":"order_1","payment_id":"pay_2","signature":"%x"}`, mac.Sum(nil))
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("released payment", func(mt *mtest.T) {
+		config.DB = mt.Client.Database("test")
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test.payments", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: primitive.NewObjectID()},
+				{Key: "buyerId", Value: buyer},
+				{Key: "solverId", Value: primitive.NewObjectID()},
+				{Key: "razorpayOrderId", Value: "order_1"},
+				{Key: "status", Value: "released"},
+			}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}),
+		)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set(middleware.ContextUserID, buyer)
+
+		VerifyPayment(c)
+
+		if w.Code != http.StatusConflict {
+			mt.Fatalf("status = %d, want %d; body %s", w.Code, http.StatusConflict, w.Body.String())
+		}
+		cmds := startedCommands(mt)
+		if len(cmds) != 2 {
+			mt.Fatalf("%d commands sent, want only the lookup and the claim", len(cmds))
+		}
+		filter := cmds[1].Lookup("updates").Array().Index(0).Value().Document().Lookup("q").Document()
+		if status := filter.Lookup("status").StringValue(); status != "pending" {
+			mt.Fatalf("claim matches status %q, want pending", status)
+		}
+	})
+}
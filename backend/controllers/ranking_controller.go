@@ -0,0 +1,240 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/matching"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// defaultMetricsWindow is how far back GetMatchMetrics looks by default.
+const defaultMetricsWindow = 30 * 24 * time.Hour
+
+// logImpression records in the background that matches, ranked by engine,
+// were shown for a saved assignment. Losing an entry only loses training
+// data, so failures are logged and not returned.
+func logImpression(assignmentID, viewerID primitive.ObjectID, surface string, engine *matching.Engine, matches []matching.Match) {
+	if assignmentID.IsZero() || len(matches) == 0 {
+		return
+	}
+	impression := models.MatchImpression{
+		AssignmentID: assignmentID,
+		ViewerID:     viewerID,
+		Surface:      surface,
+		Variant:      engine.Variant(),
+		ModelVersion: engine.ModelVersion(),
+		Results:      make([]models.ImpressionResult, 0, len(matches)),
+		CreatedAt:    time.Now(),
+	}
+	for i, m := range matches {
+		features := make(map[string]float64, len(m.Breakdown))
+		for _, f := range m.Breakdown {
+			features[f.Feature] = f.Score
+		}
+		impression.Results = append(impression.Results, models.ImpressionResult{
+			SolverID: m.Solver.ID,
+			Position: i + 1,
+			Score:    m.Score,
+			Features: features,
+		})
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := matching.ImpressionCollection().InsertOne(ctx, impression); err != nil {
+			fmt.Printf("[logImpression] failed to log impression for %s: %v\n", assignmentID.Hex(), err)
+		}
+	}()
+}
+
+// recordOutcome notes that a solver went on to the outcome's event for an
+// assignment. The first occurrence of each event is kept, except that a
+// later rating replaces an earlier one.
+func recordOutcome(ctx context.Context, outcome models.MatchOutcome) {
+	if outcome.AssignmentID.IsZero() || outcome.SolverID.IsZero() {
+		return
+	}
+	if outcome.At.IsZero() {
+		outcome.At = time.Now()
+	}
+	insert := bson.M{"at": outcome.At}
+	if outcome.OnTime != nil {
+		insert["onTime"] = *outcome.OnTime
+	}
+	update := bson.M{"$setOnInsert": insert}
+	if outcome.Rating > 0 {
+		update["$set"] = bson.M{"rating": outcome.Rating}
+	}
+	_, err := matching.OutcomeCollection().UpdateOne(ctx,
+		bson.M{"assignmentId": outcome.AssignmentID, "solverId": outcome.SolverID, "event": outcome.Event},
+		update,
+		options.Update().SetUpsert(true),
+	)
+	// A concurrent upsert of the same event loses the race harmlessly
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		fmt.Printf("[recordOutcome] failed to record %s for assignment %s: %v\n", outcome.Event, outcome.AssignmentID.Hex(), err)
+	}
+}
+
+// recordTransitionOutcome records the acceptance or completion of an
+// assignment by its solver. extra is the update transitionAssignment
+// applied, which carries the solver on acceptance.
+func recordTransitionOutcome(ctx context.Context, assignment models.Assignment, to string, extra bson.M, at time.Time) {
+	solverID := assignment.SolverID
+	if id, ok := extra["solverId"].(primitive.ObjectID); ok {
+		solverID = id
+	}
+	switch to {
+	case models.AssignmentAccepted:
+		recordOutcome(ctx, models.MatchOutcome{AssignmentID: assignment.ID, SolverID: solverID, Event: models.OutcomeAccepted, At: at})
+	case models.AssignmentCompleted:
+		due := assignment.DueAt
+		if due.IsZero() {
+			due = assignment.Deadline
+		}
+		// Judge by the first delivery; later revisions do not make it late
+		delivered := at
+		for _, change := range assignment.StatusHistory {
+			if change.To == models.AssignmentDelivered {
+				delivered = change.At
+				break
+			}
+		}
+		onTime := due.IsZero() || !delivered.After(due)
+		recordOutcome(ctx, models.MatchOutcome{AssignmentID: assignment.ID, SolverID: solverID, Event: models.OutcomeCompleted, OnTime: &onTime, At: at})
+	}
+}
+
+// variantMetrics compares how solvers ranked by one variant converted.
+type variantMetrics struct {
+	Variant      string `json:"variant"`
+	Impressions  int64  `json:"impressions"`
+	Assignments  int    `json:"assignments"`   // assignments ranked by the variant
+	SolversShown int    `json:"solvers_shown"` // distinct assignment and solver pairs
+	// BidRate is the share of shown solvers who bid
+	BidRate float64 `json:"bid_rate"`
+	// AcceptRate is the share of assignments that went to a shown solver
+	AcceptRate float64 `json:"accept_rate"`
+	// OnTimeRate is the share of those that were completed on time
+	OnTimeRate float64 `json:"on_time_rate"`
+	// AvgRating is the mean buyer rating of shown solvers who were rated
+	AvgRating float64 `json:"avg_rating"`
+	// MeanAcceptedPosition is the average rank of the solver who got the work
+	MeanAcceptedPosition float64 `json:"mean_accepted_position"`
+
+	bids, accepted, onTime, rated, ratingSum, positionSum int
+	assignments                                           map[primitive.ObjectID]bool
+}
+
+// GET /api/admin/matching/metrics - Conversion of the heuristic and learned rankers (admin only)
+// Query: since (RFC 3339; default 30 days ago)
+// Only outcomes after a solver was first shown count towards a variant.
+func GetMatchMetrics(c *gin.Context) {
+	since := time.Now().Add(-defaultMetricsWindow)
+	if v := c.Query("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "since must be an RFC 3339 timestamp"})
+			return
+		}
+		since = t
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	byVariant := map[string]*variantMetrics{}
+	metricsFor := func(variant string) *variantMetrics {
+		m, ok := byVariant[variant]
+		if !ok {
+			m = &variantMetrics{Variant: variant, assignments: map[primitive.ObjectID]bool{}}
+			byVariant[variant] = m
+		}
+		return m
+	}
+
+	cursor, err := matching.ImpressionCollection().Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"createdAt": bson.M{"$gte": since}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$variant", "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load impressions"})
+		return
+	}
+	var counts []struct {
+		Variant string `bson:"_id"`
+		Count   int64  `bson:"count"`
+	}
+	if err := cursor.All(ctx, &counts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load impressions"})
+		return
+	}
+	for _, row := range counts {
+		metricsFor(row.Variant).Impressions = row.Count
+	}
+
+	err = matching.EachShownPair(ctx, since, func(p matching.ShownPair) error {
+		m := metricsFor(p.Variant)
+		m.SolversShown++
+		m.assignments[p.AssignmentID] = true
+		if p.Bid {
+			m.bids++
+		}
+		if p.Accepted {
+			m.accepted++
+			m.positionSum += p.Position
+			if p.Completed && p.OnTime {
+				m.onTime++
+			}
+		}
+		if p.Rating > 0 {
+			m.rated++
+			m.ratingSum += p.Rating
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load outcomes"})
+		return
+	}
+
+	variants := make([]*variantMetrics, 0, len(byVariant))
+	for _, m := range byVariant {
+		m.Assignments = len(m.assignments)
+		m.BidRate = ratio(m.bids, m.SolversShown)
+		m.AcceptRate = ratio(m.accepted, m.Assignments)
+		m.OnTimeRate = ratio(m.onTime, m.accepted)
+		m.AvgRating = ratio(m.ratingSum, m.rated)
+		m.MeanAcceptedPosition = ratio(m.positionSum, m.accepted)
+		variants = append(variants, m)
+	}
+	sort.Slice(variants, func(i, j int) bool { return variants[i].Variant < variants[j].Variant })
+
+	version, percent := matching.LearnedShare()
+
+	c.JSON(http.StatusOK, gin.H{
+		"since":           since,
+		"model_version":   version,
+		"learned_percent": percent,
+		"variants":        variants,
+	})
+}
+
+// ratio is n/d to four decimals, 0 when d is 0.
+func ratio(n, d int) float64 {
+	if d == 0 {
+		return 0
+	}
+	return math.Round(float64(n)/float64(d)*10000) / 10000
+}
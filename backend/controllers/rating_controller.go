@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const maxRatingCommentLength = 1000
+
+// POST /api/assignments/:id/rating - Rate the solver of a completed assignment (owner only)
+// Body: { "rating": 5, "comment": "optional" }
+// Each assignment can be rated once. The rating feeds the solver's average
+// rating and the match outcomes the ranker is trained on.
+func RateAssignment(c *gin.Context) {
+	var req struct {
+		Rating  int    `json:"rating" binding:"required"`
+		Comment string `json:"comment"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rating is required"})
+		return
+	}
+	if req.Rating < 1 || req.Rating > 5 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rating must be between 1 and 5"})
+		return
+	}
+	req.Comment = strings.TrimSpace(req.Comment)
+	if len(req.Comment) > maxRatingCommentLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("comment must be at most %d characters", maxRatingCommentLength)})
+		return
+	}
+
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment ID"})
+		return
+	}
+
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	assignment, err := loadAssignment(ctx, objID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+		return
+	}
+	if !isAssignmentOwner(callerID, assignment) {
+		forbidden(c, "Only the assignment owner can rate it")
+		return
+	}
+	if assignment.Status != models.AssignmentCompleted || assignment.SolverID.IsZero() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only completed assignments can be rated"})
+		return
+	}
+
+	rating := models.AssignmentRating{Stars: req.Rating, Comment: req.Comment, RatedAt: time.Now()}
+	result, err := config.DB.Collection("assignments").UpdateOne(ctx,
+		bson.M{"_id": assignment.ID, "status": models.AssignmentCompleted, "rating": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"rating": rating}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save rating"})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Assignment has already been rated"})
+		return
+	}
+
+	if err := addSolverRating(ctx, assignment.SolverID, req.Rating); err != nil {
+		fmt.Printf("[RateAssignment] failed to update rating of solver %s: %v\n", assignment.SolverID.Hex(), err)
+	}
+	recordOutcome(ctx, models.MatchOutcome{AssignmentID: assignment.ID, SolverID: assignment.SolverID, Event: models.OutcomeRated, Rating: req.Rating, At: rating.RatedAt})
+
+	go CreateSolverNotification(assignment.SolverID, models.NotifTypeRatingReceived, "New Rating",
+		fmt.Sprintf("The buyer rated your work on \"%s\" %d out of 5.", assignment.Title, req.Rating),
+		assignment.ID, "assignment", models.PriorityMedium)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Rating saved",
+		"assignment_id": assignment.ID.Hex(),
+		"rating":        rating,
+	})
+}
+
+// addSolverRating folds stars into the solver's running average rating.
+func addSolverRating(ctx context.Context, solverID primitive.ObjectID, stars int) error {
+	count := bson.M{"$ifNull": bson.A{"$ratingCount", 0}}
+	_, err := config.DB.Collection("users").UpdateOne(ctx, bson.M{"_id": solverID}, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"avgRating": bson.M{"$divide": bson.A{
+				bson.M{"$add": bson.A{
+					bson.M{"$multiply": bson.A{bson.M{"$ifNull": bson.A{"$avgRating", 0}}, count}},
+					stars,
+				}},
+				bson.M{"$add": bson.A{count, 1}},
+			}},
+			"ratingCount": bson.M{"$add": bson.A{count, 1}},
+		}}},
+	})
+	return err
+}
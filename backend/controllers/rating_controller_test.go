@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/middleware"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestRateAssignment(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	buyer := primitive.NewObjectID()
+	solver := primitive.NewObjectID()
+	assignmentID := primitive.NewObjectID()
+	completed := bson.D{
+		{Key: "_id", Value: assignmentID},
+		{Key: "userId", Value: buyer},
+		{Key: "solverId", Value: solver},
+		{Key: "title", Value: "Lab report"},
+		{Key: "status", Value: models.AssignmentCompleted},
+	}
+
+	rate := func(mt *mtest.T, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Params = gin.Params{{Key: "id", Value: assignmentID.Hex()}}
+		c.Set(middleware.ContextUserID, buyer)
+		RateAssignment(c)
+		return w
+	}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("records the rating outcome", func(mt *mtest.T) {
+		config.DB = mt.Client.Database("test")
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test.assignments", mtest.FirstBatch, completed),
+			updated(1),                    // assignment rating
+			updated(1),                    // solver average
+			updated(1),                    // match outcome
+			mtest.CreateSuccessResponse(), // notification
+		)
+
+		if w := rate(mt, `{"rating":4,"comment":"Thorough"}`); w.Code != http.StatusOK {
+			mt.Fatalf("status = %d, want %d; body %s", w.Code, http.StatusOK, w.Body.String())
+		}
+
+		var outcome bson.Raw
+		for _, cmd := range startedCommands(mt) {
+			if v, err := cmd.LookupErr("update"); err == nil && v.StringValue() == "match_outcomes" {
+				outcome = cmd.Lookup("updates").Array().Index(0).Value().Document()
+			}
+		}
+		if outcome == nil {
+			mt.Fatalf("no match outcome recorded")
+		}
+		if event := outcome.Lookup("q", "event").StringValue(); event != models.OutcomeRated {
+			mt.Fatalf("outcome event = %q, want %q", event, models.OutcomeRated)
+		}
+		if stars := outcome.Lookup("u", "$set", "rating").Int32(); stars != 4 {
+			mt.Fatalf("outcome rating = %d, want 4", stars)
+		}
+	})
+
+	mt.Run("only once", func(mt *mtest.T) {
+		config.DB = mt.Client.Database("test")
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test.assignments", mtest.FirstBatch, completed),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}),
+		)
+		if w := rate(mt, `{"rating":2}`); w.Code != http.StatusConflict {
+			mt.Fatalf("status = %d, want %d; body %s", w.Code, http.StatusConflict, w.Body.String())
+		}
+	})
+
+	mt.Run("not before completion", func(mt *mtest.T) {
+		config.DB = mt.Client.Database("test")
+		inProgress := append(bson.D{}, completed[:4]...)
+		inProgress = append(inProgress, bson.E{Key: "status", Value: models.AssignmentInProgress})
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "test.assignments", mtest.FirstBatch, inProgress))
+		if w := rate(mt, `{"rating":5}`); w.Code != http.StatusConflict {
+			mt.Fatalf("status = %d, want %d; body %s", w.Code, http.StatusConflict, w.Body.String())
+		}
+	})
+}
@@ -0,0 +1,270 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/audit"
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/Aashishvatwani/homeworld/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// errRefundInProgress is returned when the payment is no longer "paid",
+// usually because another request is already refunding or releasing it.
+var errRefundInProgress = errors.New("payment is not in a refundable state")
+
+// errPayoutInProgress is the payout counterpart of errRefundInProgress.
+var errPayoutInProgress = errors.New("payment is not in a releasable state")
+
+// isOnchainPayment reports whether the payment is held by the escrow
+// contract rather than Razorpay.
+func isOnchainPayment(payment models.Payment) bool {
+	return payment.PaymentMethod != "bank" && payment.PaymentMethod != "razorpay"
+}
+
+// escrowID is the escrow contract record holding an on-chain payment.
+func escrowID(payment models.Payment) string {
+	if payment.EscrowID != "" {
+		return payment.EscrowID
+	}
+	return payment.AssignmentID.Hex()
+}
+
+// paidPayment returns the captured payment for an assignment.
+func paidPayment(ctx context.Context, assignment models.Assignment) (models.Payment, error) {
+	var payment models.Payment
+	err := config.DB.Collection("payments").FindOne(ctx,
+		bson.M{"assignmentId": assignment.ID, "status": "paid"},
+	).Decode(&payment)
+	return payment, err
+}
+
+// refundPayment returns amount of a paid payment to the buyer, through a
+// Razorpay refund or the escrow contract, and returns the refund reference.
+// The payment is claimed with a conditional update first so it cannot be
+// refunded twice or released while the refund is in flight. Escrow refunds
+// always return the full deposit.
+func refundPayment(ctx context.Context, c *gin.Context, payment models.Payment, amount float64, reason string) (string, error) {
+	amount = math.Round(amount*100) / 100
+	if amount <= 0 || amount > payment.Amount {
+		return "", fmt.Errorf("refund amount must be between 0 and %.2f", payment.Amount)
+	}
+	onchain := isOnchainPayment(payment)
+	if onchain && amount != payment.Amount {
+		return "", fmt.Errorf("escrow payments can only be refunded in full")
+	}
+
+	payments := config.DB.Collection("payments")
+	result, err := payments.UpdateOne(ctx,
+		bson.M{"_id": payment.ID, "status": "paid"},
+		bson.M{"$set": bson.M{"status": "refunding"}},
+	)
+	if err != nil {
+		return "", err
+	}
+	if result.MatchedCount == 0 {
+		return "", errRefundInProgress
+	}
+	reopen := func() {
+		if _, err := payments.UpdateOne(ctx, bson.M{"_id": payment.ID}, bson.M{"$set": bson.M{"status": "paid"}}); err != nil {
+			fmt.Printf("[refundPayment] failed to reopen payment %s: %v\n", payment.ID.Hex(), err)
+		}
+	}
+
+	var refundID string
+	if onchain {
+		buyer, _ := loadUser(ctx, payment.BuyerID)
+		refundID, err = utils.RefundEscrowPayment(escrowID(payment), buyer.EthereumAddress)
+		if err != nil {
+			reopen()
+			return "", fmt.Errorf("escrow refund failed: %w", err)
+		}
+		recordAudit(c, audit.ActionEscrowRefund, audit.TargetPayment, payment.ID.Hex(),
+			gin.H{"status": payment.Status},
+			gin.H{"status": "refunded", "assignmentId": payment.AssignmentID.Hex(), "escrowId": escrowID(payment), "txHash": refundID, "reason": reason},
+		)
+	} else {
+		refundID, err = utils.RefundRazorpayPayment(payment.RazorpayPaymentID, amount)
+		if err != nil {
+			reopen()
+			return "", fmt.Errorf("razorpay refund failed: %w", err)
+		}
+		recordAudit(c, audit.ActionPaymentRefund, audit.TargetPayment, payment.ID.Hex(),
+			gin.H{"status": payment.Status},
+			gin.H{"status": "refunded", "amount": amount, "refundId": refundID, "reason": reason},
+		)
+	}
+
+	status := "refunded"
+	if amount < payment.Amount {
+		status = "partially_refunded"
+	}
+	_, err = payments.UpdateOne(ctx, bson.M{"_id": payment.ID}, bson.M{"$set": bson.M{
+		"status":       status,
+		"refundId":     refundID,
+		"refundAmount": amount,
+		"refundedAt":   time.Now(),
+	}})
+	if err != nil {
+		// The money has moved; the audit entry above is the record of it
+		fmt.Printf("[refundPayment] refund %s issued but payment %s not updated: %v\n", refundID, payment.ID.Hex(), err)
+	}
+	return refundID, nil
+}
+
+// settleSplit refunds the buyer's share of a payment and pays the solver
+// theirs. refundID and payoutID hold the legs already completed; each leg is
+// saved on the record driving the settlement (a cancellation or dispute) as
+// soon as it completes, so a retry never pays anyone twice.
+func settleSplit(ctx context.Context, c *gin.Context, records *mongo.Collection, recordID, paymentID primitive.ObjectID, split models.RefundSplit, reason string, refundID, payoutID *string) error {
+	var payment models.Payment
+	if err := config.DB.Collection("payments").FindOne(ctx, bson.M{"_id": paymentID}).Decode(&payment); err != nil {
+		return fmt.Errorf("failed to load payment: %w", err)
+	}
+
+	if split.CustomerRefund > 0 && *refundID == "" {
+		id, err := refundPayment(ctx, c, payment, split.CustomerRefund, reason)
+		if err != nil {
+			return err
+		}
+		*refundID = id
+		saveSettlementStep(ctx, records, recordID, bson.M{"refundId": id})
+	}
+	if split.SolverAmount > 0 && *payoutID == "" {
+		id, err := paySolverShare(ctx, c, payment, split.SolverAmount, reason)
+		if err != nil {
+			return err
+		}
+		*payoutID = id
+		saveSettlementStep(ctx, records, recordID, bson.M{"payoutId": id})
+	}
+	return nil
+}
+
+func saveSettlementStep(ctx context.Context, records *mongo.Collection, recordID primitive.ObjectID, set bson.M) {
+	if _, err := records.UpdateOne(ctx, bson.M{"_id": recordID}, bson.M{"$set": set}); err != nil {
+		fmt.Printf("[saveSettlementStep] failed to record %v on %s: %v\n", set, recordID.Hex(), err)
+	}
+}
+
+// paySolverShare pays amount of a settled payment to the solver: a Razorpay
+// payout for bank payments, or the whole escrow deposit for on-chain
+// payments. It returns the payout ID or transaction hash. Like
+// refundPayment, it claims the payment with a conditional update first so
+// the solver is never paid twice, and hands it back if the payout fails.
+func paySolverShare(ctx context.Context, c *gin.Context, payment models.Payment, amount float64, reason string) (string, error) {
+	payments := config.DB.Collection("payments")
+	solver, err := loadUser(ctx, payment.SolverID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load solver: %w", err)
+	}
+
+	// A split settlement refunds the buyer's share first, leaving the
+	// payment partially refunded rather than paid
+	var claimed models.Payment
+	err = payments.FindOneAndUpdate(ctx,
+		bson.M{"_id": payment.ID, "status": bson.M{"$in": bson.A{"paid", "partially_refunded"}}},
+		bson.M{"$set": bson.M{"status": "releasing"}},
+		options.FindOneAndUpdate().SetReturnDocument(options.Before),
+	).Decode(&claimed)
+	if err == mongo.ErrNoDocuments {
+		return "", errPayoutInProgress
+	}
+	if err != nil {
+		return "", err
+	}
+	reopen := func() {
+		if _, err := payments.UpdateOne(ctx, bson.M{"_id": payment.ID}, bson.M{"$set": bson.M{"status": claimed.Status}}); err != nil {
+			fmt.Printf("[paySolverShare] failed to reopen payment %s: %v\n", payment.ID.Hex(), err)
+		}
+	}
+
+	if isOnchainPayment(payment) {
+		buyer, _ := loadUser(ctx, payment.BuyerID)
+		assignmentID := payment.AssignmentID.Hex()
+		escrow := escrowID(payment)
+		if _, err := utils.MarkAssignmentComplete(escrow, solver.EthereumAddress); err != nil {
+			reopen()
+			return "", fmt.Errorf("failed to mark assignment completed on-chain: %w", err)
+		}
+		recordAudit(c, audit.ActionEscrowComplete, audit.TargetAssignment, assignmentID, nil, gin.H{"solver": solver.EthereumAddress, "escrowId": escrow})
+
+		// Wait for the completion to be mined before releasing
+		time.Sleep(3 * time.Second)
+
+		txHash, err := utils.ReleaseEscrowPayment(escrow, buyer.EthereumAddress, solver.EthereumAddress)
+		if err != nil {
+			reopen()
+			return "", fmt.Errorf("failed to release escrow: %w", err)
+		}
+		recordAudit(c, audit.ActionEscrowRelease, audit.TargetPayment, payment.ID.Hex(),
+			gin.H{"status": claimed.Status},
+			gin.H{"status": "released", "assignmentId": assignmentID, "escrowId": escrow, "solver": solver.EthereumAddress, "txHash": txHash, "reason": reason},
+		)
+		_, err = payments.UpdateOne(ctx, bson.M{"_id": payment.ID}, bson.M{"$set": bson.M{
+			"status":          "released",
+			"transactionHash": txHash,
+			"releasedAt":      time.Now(),
+		}})
+		if err != nil {
+			fmt.Printf("[paySolverShare] escrow %s released but payment %s not updated: %v\n", txHash, payment.ID.Hex(), err)
+		}
+		return txHash, nil
+	}
+
+	solverPayout, err := utils.DecryptPayout(solver.ID, solver.Payout)
+	if err != nil {
+		reopen()
+		return "", fmt.Errorf("failed to read solver payout details: %w", err)
+	}
+	if !solverPayout.IsComplete() {
+		reopen()
+		return "", fmt.Errorf("solver has not set up payout details")
+	}
+	payoutID, err := utils.CreateRazorpayPayout(amount, "INR", map[string]string{
+		"accountHolderName": solverPayout.AccountHolderName,
+		"accountNumber":     solverPayout.AccountNumber,
+		"ifsc":              solverPayout.IFSC,
+		"bankName":          solverPayout.BankName,
+		"upi":               solverPayout.UPI,
+	})
+	if err != nil {
+		reopen()
+		return "", fmt.Errorf("failed to create payout: %w", err)
+	}
+	recordAudit(c, audit.ActionPayout, audit.TargetPayment, payment.ID.Hex(),
+		gin.H{"status": claimed.Status},
+		gin.H{
+			"payoutId":    payoutID,
+			"amount":      amount,
+			"solverId":    payment.SolverID.Hex(),
+			"destination": solverPayout.Masked(),
+			"reason":      reason,
+		},
+	)
+
+	// A partially refunded payment keeps that status; one with no refund is released
+	status := "released"
+	if claimed.Status == "partially_refunded" {
+		status = claimed.Status
+	}
+	_, err = payments.UpdateOne(ctx, bson.M{"_id": payment.ID}, bson.M{"$set": bson.M{
+		"status":           status,
+		"razorpayPayoutId": payoutID,
+		"payoutStatus":     "initiated",
+		"releasedAt":       time.Now(),
+	}})
+	if err != nil {
+		fmt.Printf("[paySolverShare] payout %s created but payment %s not updated: %v\n", payoutID, payment.ID.Hex(), err)
+	}
+	return payoutID, nil
+}
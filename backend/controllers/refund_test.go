@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// paySolverShare claims the payment before paying out, keeps a partial
+// refund's status once paid, and hands the payment back if the payout fails.
+func TestPaySolverShareClaimsPaymentFirst(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("RAZORPAY_PAYOUT_ENABLED", "") // mock payouts
+
+	solverID := primitive.NewObjectID()
+	p := models.Payment{ID: primitive.NewObjectID(), SolverID: solverID, PaymentMethod: "bank", Amount: 1000}
+	payment := bson.D{
+		{Key: "_id", Value: p.ID},
+		{Key: "solverId", Value: solverID},
+		{Key: "paymentMethod", Value: "bank"},
+		{Key: "amount", Value: 1000.0},
+	}
+	withStatus := func(status string) bson.D {
+		return append(append(bson.D{}, payment...), bson.E{Key: "status", Value: status})
+	}
+	solver := func(payout bson.D) bson.D {
+		return bson.D{{Key: "_id", Value: solverID}, {Key: "payout", Value: payout}}
+	}
+	upi := bson.D{{Key: "accountHolderName", Value: "Solver"}, {Key: "upi", Value: "solver@upi"}}
+	claimed := func(doc interface{}) bson.D {
+		return mtest.CreateSuccessResponse(bson.E{Key: "value", Value: doc})
+	}
+
+	paySolver := func() (string, error) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+		return paySolverShare(context.Background(), c, p, 450, "test")
+	}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	for _, tc := range []struct{ prior, final string }{
+		{prior: "paid", final: "released"},
+		{prior: "partially_refunded", final: "partially_refunded"},
+	} {
+		mt.Run("pays out from "+tc.prior, func(mt *mtest.T) {
+			config.DB = mt.Client.Database("test")
+			mt.AddMockResponses(
+				mtest.CreateCursorResponse(0, "test.users", mtest.FirstBatch, solver(upi)),
+				claimed(withStatus(tc.prior)),
+				mtest.CreateCursorResponse(0, "test.audit_log", mtest.FirstBatch),
+				mtest.CreateSuccessResponse(), // audit entry
+				updated(1),
+			)
+			if _, err := paySolver(); err != nil {
+				mt.Fatal(err)
+			}
+
+			cmds := startedCommands(mt)
+			claim := cmds[1]
+			if got := claim.Lookup("update", "$set", "status").StringValue(); got != "releasing" {
+				mt.Fatalf("claim sets status %q, want releasing", got)
+			}
+			statuses, _ := claim.Lookup("query", "status", "$in").Array().Values()
+			if len(statuses) != 2 {
+				mt.Fatalf("claim matches %v, want paid and partially_refunded", statuses)
+			}
+			final := cmds[len(cmds)-1].Lookup("updates").Array().Index(0).Value().Document()
+			if got := final.Lookup("u", "$set", "status").StringValue(); got != tc.final {
+				mt.Fatalf("payment left %q, want %q", got, tc.final)
+			}
+		})
+	}
+
+	mt.Run("already claimed", func(mt *mtest.T) {
+		config.DB = mt.Client.Database("test")
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test.users", mtest.FirstBatch, solver(upi)),
+			claimed(nil),
+		)
+		if _, err := paySolver(); err != errPayoutInProgress {
+			mt.Fatalf("err = %v, want errPayoutInProgress", err)
+		}
+		if n := len(startedCommands(mt)); n != 2 {
+			mt.Fatalf("%d commands sent, want only the solver lookup and the claim", n)
+		}
+	})
+
+	mt.Run("no payout details", func(mt *mtest.T) {
+		config.DB = mt.Client.Database("test")
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test.users", mtest.FirstBatch, solver(bson.D{})),
+			claimed(withStatus("partially_refunded")),
+			updated(1), // reopen
+		)
+		if _, err := paySolver(); err == nil {
+			mt.Fatal("want an error for a solver without payout details")
+		}
+		cmds := startedCommands(mt)
+		reopen := cmds[len(cmds)-1].Lookup("updates").Array().Index(0).Value().Document()
+		if got := reopen.Lookup("u", "$set", "status").StringValue(); got != "partially_refunded" {
+			mt.Fatalf("payment reopened as %q, want its prior status", got)
+		}
+	})
+}
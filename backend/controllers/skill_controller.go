@@ -0,0 +1,315 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/audit"
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/Aashishvatwani/homeworld/skills"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	maxSkillNameLength = 100
+	maxSkillAliases    = 50
+	// skillWriteTimeout leaves time for renames, which rewrite the skill on
+	// every user and assignment listing it.
+	skillWriteTimeout = 30 * time.Second
+)
+
+type skillRequest struct {
+	ID       string    `json:"id"` // create only; derived from the name when empty
+	Name     *string   `json:"name"`
+	Aliases  *[]string `json:"aliases"`
+	ParentID *string   `json:"parentId"`
+}
+
+// GET /api/skills - List the skill taxonomy
+func ListSkills(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"skills": skills.Current().List()})
+}
+
+// POST /api/admin/skills - Add a skill to the taxonomy (admin only)
+// Body: { "id": "optional-slug", "name": "PyTorch", "aliases": ["torch"], "parentId": "deep-learning" }
+func CreateSkill(c *gin.Context) {
+	var req skillRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Name == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+
+	now := time.Now()
+	skill := models.Skill{ID: req.ID, Aliases: []string{}, CreatedAt: now, UpdatedAt: now}
+	if err := applySkillRequest(&skill, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if skill.ID == "" {
+		skill.ID = skills.Slug(skill.Name)
+	}
+	if !skills.ValidID(skill.ID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id must be lower-case letters and digits separated by dashes"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	list, err := skills.LoadAll(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load skills"})
+		return
+	}
+	if _, found := findSkill(list, skill.ID); found {
+		c.JSON(http.StatusConflict, gin.H{"error": "Skill " + skill.ID + " already exists"})
+		return
+	}
+	registry, err := skills.Build(append(list, skill))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := skills.Collection().InsertOne(ctx, skill); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Skill " + skill.ID + " already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create skill"})
+		return
+	}
+	skills.Use(registry)
+
+	recordAudit(c, audit.ActionSkillCreate, audit.TargetSkill, skill.ID, nil, skill)
+	c.JSON(http.StatusCreated, gin.H{"message": "Skill created", "skill": skill})
+}
+
+// PUT /api/admin/skills/:id - Rename, re-alias or move a skill (admin only)
+// Body: { "name": "...", "aliases": [...], "parentId": "" }; omitted fields are kept
+// Renaming rewrites the skill on every user and assignment listing it.
+func UpdateSkill(c *gin.Context) {
+	var req skillRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), skillWriteTimeout)
+	defer cancel()
+
+	list, err := skills.LoadAll(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load skills"})
+		return
+	}
+	i, found := findSkill(list, c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Skill not found"})
+		return
+	}
+
+	before := list[i]
+	skill := before
+	if err := applySkillRequest(&skill, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	skill.UpdatedAt = time.Now()
+	list[i] = skill
+	registry, err := skills.Build(list)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	set := bson.M{"name": skill.Name, "aliases": skill.Aliases, "updatedAt": skill.UpdatedAt}
+	update := bson.M{"$set": set}
+	if skill.ParentID == "" {
+		update["$unset"] = bson.M{"parentId": ""}
+	} else {
+		set["parentId"] = skill.ParentID
+	}
+	if _, err := skills.Collection().UpdateOne(ctx, bson.M{"_id": skill.ID}, update); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update skill"})
+		return
+	}
+	skills.Use(registry)
+
+	var renamed int64
+	if skill.Name != before.Name {
+		if renamed, err = renameStoredSkill(ctx, before.Name, skill.Name); err != nil {
+			fmt.Printf("[UpdateSkill] failed to rename %q to %q on stored records: %v\n", before.Name, skill.Name, err)
+		}
+	}
+
+	recordAudit(c, audit.ActionSkillUpdate, audit.TargetSkill, skill.ID, before, skill)
+	c.JSON(http.StatusOK, gin.H{"message": "Skill updated", "skill": skill, "records_renamed": renamed})
+}
+
+// DELETE /api/admin/skills/:id - Remove a skill from the taxonomy (admin only)
+// Query: replace_with (skill ID) merges it into another skill, which takes
+// over its name and aliases; otherwise it is removed from user profiles and
+// kept on assignments as free text. Skills with sub-skills cannot be removed.
+func DeleteSkill(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), skillWriteTimeout)
+	defer cancel()
+
+	list, err := skills.LoadAll(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load skills"})
+		return
+	}
+	i, found := findSkill(list, c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Skill not found"})
+		return
+	}
+	skill := list[i]
+	for _, s := range list {
+		if s.ParentID == skill.ID {
+			c.JSON(http.StatusConflict, gin.H{"error": "Skill has sub-skills; move or remove them first"})
+			return
+		}
+	}
+	rest := append(append([]models.Skill{}, list[:i]...), list[i+1:]...)
+
+	var target *models.Skill
+	if id := c.Query("replace_with"); id != "" {
+		j, found := findSkill(rest, id)
+		if !found {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "replace_with must name another existing skill"})
+			return
+		}
+		target = &rest[j]
+		target.Aliases = cleanSkillAliases(target.Name, append(append(target.Aliases, skill.Name), skill.Aliases...))
+		target.UpdatedAt = time.Now()
+	}
+	registry, err := skills.Build(rest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Move the aliases over before deleting, so input keeps resolving
+	if target != nil {
+		_, err = skills.Collection().UpdateOne(ctx, bson.M{"_id": target.ID},
+			bson.M{"$set": bson.M{"aliases": target.Aliases, "updatedAt": target.UpdatedAt}})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge skill"})
+			return
+		}
+	}
+	if _, err := skills.Collection().DeleteOne(ctx, bson.M{"_id": skill.ID}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete skill"})
+		return
+	}
+	skills.Use(registry)
+
+	var updated int64
+	if target != nil {
+		updated, err = renameStoredSkill(ctx, skill.Name, target.Name)
+	} else {
+		var result *mongo.UpdateResult
+		result, err = config.DB.Collection("users").UpdateMany(ctx,
+			bson.M{"skills": skill.Name}, bson.M{"$pull": bson.M{"skills": skill.Name}})
+		if err == nil {
+			updated = result.ModifiedCount
+		}
+	}
+	if err != nil {
+		fmt.Printf("[DeleteSkill] failed to update records listing %q: %v\n", skill.Name, err)
+	}
+
+	after := gin.H{"deleted": true}
+	if target != nil {
+		after["mergedInto"] = target.ID
+	}
+	recordAudit(c, audit.ActionSkillDelete, audit.TargetSkill, skill.ID, skill, after)
+	c.JSON(http.StatusOK, gin.H{"message": "Skill deleted", "records_updated": updated})
+}
+
+// applySkillRequest validates the fields present in req and sets them on skill.
+func applySkillRequest(skill *models.Skill, req skillRequest) error {
+	if req.Name != nil {
+		name := strings.Join(strings.Fields(*req.Name), " ")
+		if name == "" || len(name) > maxSkillNameLength {
+			return fmt.Errorf("name must be 1-%d characters", maxSkillNameLength)
+		}
+		skill.Name = name
+	}
+	if req.Aliases != nil {
+		if len(*req.Aliases) > maxSkillAliases {
+			return fmt.Errorf("at most %d aliases are allowed", maxSkillAliases)
+		}
+		for _, a := range *req.Aliases {
+			if len(a) > maxSkillNameLength {
+				return fmt.Errorf("aliases must be at most %d characters", maxSkillNameLength)
+			}
+		}
+		skill.Aliases = *req.Aliases
+	}
+	if req.ParentID != nil {
+		skill.ParentID = *req.ParentID
+	}
+	skill.Aliases = cleanSkillAliases(skill.Name, skill.Aliases)
+	return nil
+}
+
+// cleanSkillAliases lower-cases and sorts aliases, dropping blanks,
+// duplicates and the skill's own name.
+func cleanSkillAliases(name string, aliases []string) []string {
+	seen := map[string]bool{skills.Key(name): true}
+	cleaned := []string{}
+	for _, a := range aliases {
+		if k := skills.Key(a); k != "" && !seen[k] {
+			seen[k] = true
+			cleaned = append(cleaned, k)
+		}
+	}
+	sort.Strings(cleaned)
+	return cleaned
+}
+
+func findSkill(list []models.Skill, id string) (int, bool) {
+	for i, s := range list {
+		if s.ID == id {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// renameStoredSkill replaces the skill name from with to on users and
+// assignments, dropping from where to is already listed.
+func renameStoredSkill(ctx context.Context, from, to string) (int64, error) {
+	var updated int64
+	for _, name := range []string{"users", "assignments"} {
+		coll := config.DB.Collection(name)
+		pulled, err := coll.UpdateMany(ctx,
+			bson.M{"skills": bson.M{"$all": bson.A{from, to}}},
+			bson.M{"$pull": bson.M{"skills": from}},
+		)
+		if err != nil {
+			return updated, err
+		}
+		renamed, err := coll.UpdateMany(ctx,
+			bson.M{"skills": from},
+			bson.M{"$set": bson.M{"skills.$[s]": to}},
+			options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"s": from}}}),
+		)
+		if err != nil {
+			return updated + pulled.ModifiedCount, err
+		}
+		updated += pulled.ModifiedCount + renamed.ModifiedCount
+	}
+	return updated, nil
+}
@@ -0,0 +1,453 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/audit"
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/Aashishvatwani/homeworld/ratelimit"
+	"github.com/Aashishvatwani/homeworld/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	twoFactorChallengeTTL = 5 * time.Minute
+	recoveryCodeCount     = 10
+	// maxChallengeAttempts is how many wrong codes a login challenge takes
+	// before it is burned and the user has to log in again
+	maxChallengeAttempts = 3
+)
+
+var (
+	errTwoFactorRequired = errors.New("a current two-factor code is required for this change")
+	errTwoFactorInvalid  = errors.New("invalid or already used two-factor code")
+)
+
+// POST /api/auth/2fa/enroll - Start TOTP enrollment
+// Returns the otpauth:// URI to show as a QR code. 2FA is not active until
+// the first code is confirmed via /api/auth/2fa/enable.
+func EnrollTwoFactor(c *gin.Context) {
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := loadUser(ctx, callerID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.TwoFactor.Enabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate secret"})
+		return
+	}
+
+	_, err = config.DB.Collection("users").UpdateOne(ctx,
+		bson.M{"_id": callerID},
+		bson.M{"$set": bson.M{"twoFactor.pendingSecret": secret}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start enrollment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Scan the URI with your authenticator app, then confirm with /api/auth/2fa/enable",
+		"otpauth_uri": utils.TOTPAuthURI(totpIssuer(), user.Email, secret),
+		"secret":      secret,
+	})
+}
+
+// POST /api/auth/2fa/enable - Confirm enrollment with a code
+// Body: { "code": "123456" }. Returns one-time recovery codes; they are not shown again.
+func EnableTwoFactor(c *gin.Context) {
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+		return
+	}
+
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := loadUser(ctx, callerID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.TwoFactor.Enabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+	if user.TwoFactor.PendingSecret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Call /api/auth/2fa/enroll first"})
+		return
+	}
+
+	step, valid := utils.ValidateTOTP(user.TwoFactor.PendingSecret, req.Code, time.Now())
+	if !valid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errTwoFactorInvalid.Error()})
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate recovery codes"})
+		return
+	}
+
+	_, err = config.DB.Collection("users").UpdateOne(ctx,
+		bson.M{"_id": callerID, "twoFactor.pendingSecret": user.TwoFactor.PendingSecret},
+		bson.M{"$set": bson.M{"twoFactor": models.TwoFactorSettings{
+			Enabled:       true,
+			EnabledAt:     time.Now(),
+			Secret:        user.TwoFactor.PendingSecret,
+			RecoveryCodes: hashes,
+			LastUsedStep:  step,
+		}}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return
+	}
+	recordAudit(c, audit.ActionTwoFactorEnable, audit.TargetUser, callerID.Hex(), nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Two-factor authentication enabled. Store these recovery codes somewhere safe.",
+		"recovery_codes": codes,
+	})
+}
+
+// POST /api/auth/2fa/disable - Turn 2FA off
+// Body: { "code": "123456" } or { "recovery_code": "abcde-12345" }
+func DisableTwoFactor(c *gin.Context) {
+	var req struct {
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := loadUser(ctx, callerID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if !user.TwoFactor.Enabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled"})
+		return
+	}
+	if err := checkSecondFactor(ctx, user, req.Code, req.RecoveryCode); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, err = config.DB.Collection("users").UpdateOne(ctx,
+		bson.M{"_id": callerID},
+		bson.M{"$unset": bson.M{"twoFactor": ""}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		return
+	}
+	recordAudit(c, audit.ActionTwoFactorDisable, audit.TargetUser, callerID.Hex(), nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// POST /api/auth/2fa/recovery-codes - Replace all recovery codes
+// Body: { "code": "123456" }
+func RegenerateRecoveryCodes(c *gin.Context) {
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+		return
+	}
+
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := loadUser(ctx, callerID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if !user.TwoFactor.Enabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled"})
+		return
+	}
+	if err := verifyTOTPCode(ctx, user, req.Code); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate recovery codes"})
+		return
+	}
+	_, err = config.DB.Collection("users").UpdateOne(ctx,
+		bson.M{"_id": callerID},
+		bson.M{"$set": bson.M{"twoFactor.recoveryCodes": hashes}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save recovery codes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Recovery codes regenerated. Previous codes no longer work.",
+		"recovery_codes": codes,
+	})
+}
+
+// POST /api/auth/2fa/verify - Complete a login that returned "2fa_required"
+// Body: { "challenge_token": "...", "code": "123456" } or with "recovery_code" instead of "code"
+// Wrong codes count toward the login lockout, and the challenge is burned
+// after maxChallengeAttempts of them.
+func VerifyTwoFactorLogin(c *gin.Context) {
+	var req struct {
+		ChallengeToken string `json:"challenge_token" binding:"required"`
+		Code           string `json:"code"`
+		RecoveryCode   string `json:"recovery_code"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "challenge_token is required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	challenge, err := findUserToken(ctx, req.ChallengeToken, models.TokenPurposeTwoFactor)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login challenge is invalid or has expired; please log in again"})
+		return
+	}
+
+	user, err := loadUser(ctx, challenge.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Wrong codes count toward the same lockout as wrong passwords
+	if lockedFor, err := ratelimit.LoginLockedFor(ctx, user.Email); err != nil {
+		fmt.Printf("[VerifyTwoFactorLogin] lockout check failed: %v\n", err)
+	} else if lockedFor > 0 {
+		respondLoginLocked(c, lockedFor)
+		return
+	}
+	if err := checkSecondFactor(ctx, user, req.Code, req.RecoveryCode); err != nil {
+		if !errors.Is(err, errTwoFactorInvalid) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		burned, burnErr := recordChallengeFailure(ctx, challenge)
+		if burnErr != nil {
+			fmt.Printf("[VerifyTwoFactorLogin] failed to record challenge failure: %v\n", burnErr)
+		}
+		lockedFor, lockErr := ratelimit.RecordLoginFailure(ctx, user.Email)
+		if lockErr != nil {
+			fmt.Printf("[VerifyTwoFactorLogin] failed to record login failure: %v\n", lockErr)
+		}
+		if lockedFor > 0 {
+			respondLoginLocked(c, lockedFor)
+			return
+		}
+		if burned {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Too many invalid codes; please log in again"})
+			return
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Burn the challenge only after the second factor succeeds
+	if _, err := consumeUserToken(ctx, req.ChallengeToken, models.TokenPurposeTwoFactor); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login challenge is invalid or has expired; please log in again"})
+		return
+	}
+	if err := ratelimit.ResetLoginFailures(ctx, user.Email); err != nil {
+		fmt.Printf("[VerifyTwoFactorLogin] failed to reset login failures: %v\n", err)
+	}
+
+	resp, err := issueTokens(ctx, c, user.ID, user.Email, challenge.Role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	resp["message"] = "Login successful"
+	resp["id"] = user.ID
+	resp["roles"] = user.Roles
+	resp["active_role"] = challenge.Role
+	resp["email_verified"] = user.EmailVerified
+	if req.Code == "" {
+		resp["recovery_codes_remaining"] = len(user.TwoFactor.RecoveryCodes) - 1
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// startTwoFactorChallenge issues the short-lived token returned by LoginUser
+// when the account has 2FA enabled.
+func startTwoFactorChallenge(ctx context.Context, userID primitive.ObjectID, activeRole string) (string, error) {
+	return storeUserToken(ctx, models.UserToken{
+		UserID:  userID,
+		Purpose: models.TokenPurposeTwoFactor,
+		Role:    activeRole,
+	}, twoFactorChallengeTTL)
+}
+
+// recordChallengeFailure counts a wrong code against the login challenge and
+// burns it once maxChallengeAttempts is reached, reporting whether it did.
+func recordChallengeFailure(ctx context.Context, challenge models.UserToken) (bool, error) {
+	var updated models.UserToken
+	err := config.DB.Collection("user_tokens").FindOneAndUpdate(ctx,
+		bson.M{"_id": challenge.ID, "usedAt": bson.M{"$exists": false}},
+		bson.M{"$inc": bson.M{"attempts": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err == mongo.ErrNoDocuments {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if updated.Attempts < maxChallengeAttempts {
+		return false, nil
+	}
+	_, err = config.DB.Collection("user_tokens").UpdateOne(ctx,
+		bson.M{"_id": challenge.ID, "usedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"usedAt": time.Now()}},
+	)
+	return true, err
+}
+
+// requireFreshTOTP gates sensitive changes (payout details, wallet address)
+// behind a code that has not been used before. Users without 2FA pass.
+func requireFreshTOTP(ctx context.Context, user models.User, code string) error {
+	if !user.TwoFactor.Enabled {
+		return nil
+	}
+	if code == "" {
+		return errTwoFactorRequired
+	}
+	return verifyTOTPCode(ctx, user, code)
+}
+
+// checkSecondFactor accepts either a TOTP code or a recovery code.
+func checkSecondFactor(ctx context.Context, user models.User, code, recoveryCode string) error {
+	if code != "" {
+		return verifyTOTPCode(ctx, user, code)
+	}
+	if recoveryCode != "" {
+		return consumeRecoveryCode(ctx, user, recoveryCode)
+	}
+	return errTwoFactorRequired
+}
+
+// verifyTOTPCode validates code and records its time step so the same code
+// cannot be replayed.
+func verifyTOTPCode(ctx context.Context, user models.User, code string) error {
+	step, valid := utils.ValidateTOTP(user.TwoFactor.Secret, code, time.Now())
+	if !valid {
+		return errTwoFactorInvalid
+	}
+
+	result, err := config.DB.Collection("users").UpdateOne(ctx,
+		bson.M{"_id": user.ID, "twoFactor.lastUsedStep": bson.M{"$not": bson.M{"$gte": step}}},
+		bson.M{"$set": bson.M{"twoFactor.lastUsedStep": step}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errTwoFactorInvalid
+	}
+	return nil
+}
+
+// consumeRecoveryCode removes a matching recovery code so it works only once.
+func consumeRecoveryCode(ctx context.Context, user models.User, code string) error {
+	hash := utils.HashRecoveryCode(code)
+	result, err := config.DB.Collection("users").UpdateOne(ctx,
+		bson.M{"_id": user.ID, "twoFactor.recoveryCodes": hash},
+		bson.M{"$pull": bson.M{"twoFactor.recoveryCodes": hash}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errTwoFactorInvalid
+	}
+	return nil
+}
+
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = utils.HashRecoveryCode(code)
+	}
+	return codes, hashes, nil
+}
+
+func loadUser(ctx context.Context, userID primitive.ObjectID) (models.User, error) {
+	var user models.User
+	err := config.DB.Collection("users").FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
+	return user, err
+}
+
+func totpIssuer() string {
+	if issuer := os.Getenv("TOTP_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return "Homeworld"
+}
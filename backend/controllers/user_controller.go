@@ -1,27 +1,100 @@
 package controllers
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
-	"fmt"
-
+	"github.com/Aashishvatwani/homeworld/audit"
 	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/middleware"
 	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/Aashishvatwani/homeworld/ratelimit"
+	"github.com/Aashishvatwani/homeworld/sessions"
+	"github.com/Aashishvatwani/homeworld/skills"
 	"github.com/Aashishvatwani/homeworld/utils"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// registerUserRequest is what a new account may set about itself. Ratings,
+// job counts, reliability and availability are computed by the platform
+// and start from their zero values.
+type registerUserRequest struct {
+	Name     string               `json:"name"`
+	Email    string               `json:"email"`
+	Password string               `json:"password"`
+	About    string               `json:"about"`
+	Offering string               `json:"offering"`
+	Skills   []string             `json:"skills"`
+	Payout   models.PayoutDetails `json:"payout"`
+	Location *models.GeoPoint     `json:"location"`
+	Role     string               `json:"role"`
+}
+
 // POST /api/auth/register
+// Every account starts with the buyer role. Passing "role": "solver" also
+// grants the solver role when the solver profile fields are supplied;
+// otherwise it can be enabled later via POST /api/users/:id/roles/solver.
 func RegisterUser(c *gin.Context) {
-	var user models.User
-	if err := c.ShouldBindJSON(&user); err != nil {
+	var req registerUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if req.Location != nil && req.Location.IsZero() {
+		req.Location = nil
+	}
+	if req.Location != nil && !utils.ValidateCoordinates(req.Location.Latitude(), req.Location.Longitude()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "location must have latitude in [-90, 90] and longitude in [-180, 180]"})
+		return
+	}
+	normalizedSkills, unknown := skills.NormalizeAll(req.Skills)
+	if len(unknown) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown skills: " + strings.Join(unknown, ", ")})
+		return
+	}
+	user := models.User{
+		Name:     req.Name,
+		Email:    strings.TrimSpace(req.Email),
+		Password: req.Password,
+		About:    req.About,
+		Offering: req.Offering,
+		Skills:   normalizedSkills,
+		Payout:   req.Payout,
+		Location: req.Location,
+	}
+	if !strings.Contains(user.Email, "@") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A valid email is required"})
+		return
+	}
+	if len(user.Password) < minPasswordLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("password must be at least %d characters", minPasswordLength)})
+		return
+	}
+
+	// Roles are granted by the server, never taken from the request
+	user.Roles = []string{models.RoleBuyer}
+	activeRole := models.RoleBuyer
+	solverSetupRequired := false
+	user.Payout = normalizePayout(user.Payout)
+	if req.Role == models.RoleSolver {
+		if err := validateSolverProfile(user.Skills, user.Payout); err != nil {
+			solverSetupRequired = true
+		} else {
+			user.Roles = append(user.Roles, models.RoleSolver)
+			activeRole = models.RoleSolver
+		}
+	}
 
 	// Hash password
 	hashedPassword, err := utils.HashPassword(user.Password)
@@ -32,33 +105,72 @@ func RegisterUser(c *gin.Context) {
 	user.Password = hashedPassword
 	user.CreatedAt = time.Now().Unix()
 	user.Reliability = 1.0 // Start with perfect score
+	user.EmailVerified = false
+	user.TwoFactor = models.TwoFactorSettings{}
+
+	// The ID is assigned up front because it is bound into the payout ciphertext
+	user.ID = primitive.NewObjectID()
+	if user.Payout, err = utils.EncryptPayout(user.ID, user.Payout); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to secure payout details"})
+		return
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	if _, err := findUserByEmail(ctx, user.Email); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "An account with this email already exists"})
+		return
+	}
+
 	userCollection := config.DB.Collection("users")
 	result, err := userCollection.InsertOne(ctx, user)
 	if err != nil {
+		// The unique index on users.email catches concurrent registrations
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "An account with this email already exists"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register user"})
 		return
 	}
 
-	// Generate JWT
-	token, _ := utils.GenerateJWT(user.Email, user.Role)
+	// Send the verification email in the background
+	go func(u models.User) {
+		mailCtx, mailCancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer mailCancel()
+		if err := sendVerificationEmail(mailCtx, u); err != nil {
+			fmt.Printf("[RegisterUser] failed to send verification email to %s: %v\n", u.Email, err)
+		}
+	}(user)
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "User registered successfully",
-		"id":      result.InsertedID,
-		"token":   token,
-	})
+	// Start a session and issue tokens
+	resp, err := issueTokens(ctx, c, user.ID, user.Email, activeRole)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	resp["message"] = "User registered successfully"
+	resp["id"] = result.InsertedID
+	resp["roles"] = user.Roles
+	resp["active_role"] = activeRole
+	resp["email_verified"] = false
+	if solverSetupRequired {
+		resp["solver_setup_required"] = true
+		resp["solver_setup_message"] = "Add skills and payout details via POST /api/users/:id/roles/solver to enable the solver role"
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 // POST /api/auth/login
+// "role" selects the active role for this session; it is only stored in the
+// issued token and never changes the user's granted roles.
 func LoginUser(c *gin.Context) {
 	var loginReq struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
-		Role     string `json:"role"`
+		Email      string `json:"email"`
+		Password   string `json:"password"`
+		ActiveRole string `json:"role"`
 	}
 	if err := c.ShouldBindJSON(&loginReq); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -68,45 +180,208 @@ func LoginUser(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	userCollection := config.DB.Collection("users")
-	var user models.User
-	err := userCollection.FindOne(ctx, bson.M{"email": loginReq.Email}).Decode(&user)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+	// Locked accounts are rejected before the password is checked
+	if lockedFor, err := ratelimit.LoginLockedFor(ctx, loginReq.Email); err != nil {
+		fmt.Printf("[LoginUser] lockout check failed: %v\n", err)
+	} else if lockedFor > 0 {
+		respondLoginLocked(c, lockedFor)
 		return
 	}
 
-	// Check password
-	if !utils.CheckPassword(user.Password, loginReq.Password) {
+	// Unknown emails count as failures too, so lockout does not reveal
+	// which accounts exist
+	user, err := findUserByEmail(ctx, loginReq.Email)
+	if err != nil || !utils.CheckPassword(user.Password, loginReq.Password) {
+		lockedFor, lockErr := ratelimit.RecordLoginFailure(ctx, loginReq.Email)
+		if lockErr != nil {
+			fmt.Printf("[LoginUser] failed to record login failure: %v\n", lockErr)
+		}
+		if lockedFor > 0 {
+			respondLoginLocked(c, lockedFor)
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
-	if user.Role != loginReq.Role {
-		// Update role in DB to match requested login role
-		_, err := userCollection.UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{"$set": bson.M{"role": loginReq.Role}})
+	// With 2FA enabled the failure count is only reset once the second
+	// factor succeeds, so wrong codes keep counting across logins
+	if !user.TwoFactor.Enabled {
+		if err := ratelimit.ResetLoginFailures(ctx, loginReq.Email); err != nil {
+			fmt.Printf("[LoginUser] failed to reset login failures: %v\n", err)
+		}
+	}
+
+	activeRole := loginReq.ActiveRole
+	if activeRole == "" {
+		activeRole = models.RoleBuyer
+	}
+	if !user.HasRole(activeRole) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": fmt.Sprintf("The %s role is not enabled for this account", activeRole),
+			"id":    user.ID,
+			"roles": user.Roles,
+		})
+		return
+	}
+
+	// With 2FA enabled, hand back a challenge instead of tokens
+	if user.TwoFactor.Enabled {
+		challenge, err := startTwoFactorChallenge(ctx, user.ID, activeRole)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start two-factor challenge"})
 			return
 		}
-		user.Role = loginReq.Role
-		c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Role updated to %s", loginReq.Role), "id": user.ID})
+		c.JSON(http.StatusOK, gin.H{
+			"message":         "Two-factor code required; call POST /api/auth/2fa/verify",
+			"2fa_required":    true,
+			"challenge_token": challenge,
+			"id":              user.ID,
+		})
+		return
+	}
+
+	// Start a session and issue tokens
+	resp, err := issueTokens(ctx, c, user.ID, user.Email, activeRole)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	resp["message"] = "Login successful"
+	resp["id"] = user.ID
+	resp["roles"] = user.Roles
+	resp["active_role"] = activeRole
+	resp["email_verified"] = user.EmailVerified
+	c.JSON(http.StatusOK, resp)
+}
+
+// respondLoginLocked tells the client how long to wait before retrying.
+func respondLoginLocked(c *gin.Context, lockedFor time.Duration) {
+	retry := int(math.Ceil(lockedFor.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(retry))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       "Too many failed login attempts, please try again later",
+		"retry_after": retry,
+	})
+}
+
+// POST /api/users/:id/roles/solver - Enable the solver role
+// Body: { "skills": ["Python"], "payout": { "upi": "name@bank" } }
+func EnableSolverRole(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+	if !isSelf(callerID, objID) {
+		forbidden(c, "You can only change your own roles")
+		return
+	}
+
+	var req struct {
+		Skills      []string             `json:"skills"`
+		About       string               `json:"about"`
+		PricePerJob float64              `json:"price_per_job"`
+		Payout      models.PayoutDetails `json:"payout"`
+		TOTPCode    string               `json:"totp_code"` // required when 2FA is enabled
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	payout := normalizePayout(req.Payout)
+	if err := validateSolverProfile(req.Skills, payout); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	current, err := loadUser(ctx, objID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err := requireFreshTOTP(ctx, current, req.TOTPCode); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "2fa_required"})
+		return
+	}
+
+	sealedPayout, err := utils.EncryptPayout(objID, payout)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to secure payout details"})
+		return
+	}
+	normalized, _ := skills.NormalizeAll(req.Skills)
+	set := bson.M{
+		"skills": normalized,
+		"payout": sealedPayout,
+	}
+	if req.About != "" {
+		set["about"] = req.About
+	}
+	if req.PricePerJob < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "price_per_job must be positive"})
+		return
+	}
+	if req.PricePerJob > 0 {
+		set["pricePerJob"] = req.PricePerJob
+	}
+
+	userCollection := config.DB.Collection("users")
+	var user models.User
+	err = userCollection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": objID},
+		bson.M{"$set": set, "$addToSet": bson.M{"roles": models.RoleSolver}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&user)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
-	// Generate JWT
-	token, _ := utils.GenerateJWT(user.Email, user.Role)
+
+	if !current.HasRole(models.RoleSolver) {
+		recordAudit(c, audit.ActionRoleGrant, audit.TargetUser, objID.Hex(),
+			gin.H{"roles": current.Roles},
+			gin.H{"role": models.RoleSolver, "roles": user.Roles},
+		)
+	}
+	recordPayoutChange(c, current, payout)
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Login successful",
-		"id":      user.ID,
-		"token":   token,
+		"message": "Solver role enabled. Call POST /api/auth/refresh with \"role\": \"solver\" to switch.",
+		"roles":   user.Roles,
 	})
 }
 
+// validateSolverProfile checks the fields a user must provide before acting as a solver.
+func validateSolverProfile(skillList []string, payout models.PayoutDetails) error {
+	if len(skillList) == 0 {
+		return fmt.Errorf("at least one skill is required to enable the solver role")
+	}
+	if _, unknown := skills.NormalizeAll(skillList); len(unknown) > 0 {
+		return fmt.Errorf("unknown skills: %s", strings.Join(unknown, ", "))
+	}
+	return validatePayoutDetails(payout)
+}
+
 // GET /api/users/:id
+// The owner gets their private profile with masked payout details; everyone
+// else gets the public profile.
 func GetUser(c *gin.Context) {
 	userID := c.Param("id")
 	objID, _ := primitive.ObjectIDFromHex(userID)
-	fmt.Printf("user: %+v\n", userID)
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -119,25 +394,164 @@ func GetUser(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
+	if !isSelf(callerID, objID) {
+		c.JSON(http.StatusOK, user.Public())
+		return
+	}
+	if user.Payout, err = utils.DecryptPayout(user.ID, user.Payout); err != nil {
+		fmt.Printf("[GetUser] failed to decrypt payout for %s: %v\n", user.ID.Hex(), err)
+		user.Payout = models.PayoutDetails{}
+	}
+	c.JSON(http.StatusOK, user.Private())
+}
+
+// userSorts are the sort orders accepted by GetAllUsers.
+var userSorts = map[string]sortSpec{
+	"newest":     {Field: "createdAt", Desc: true},
+	"rating":     {Field: "avgRating", Desc: true},
+	"completed":  {Field: "completedJobs", Desc: true},
+	"price_asc":  {Field: "pricePerJob"},
+	"price_desc": {Field: "pricePerJob", Desc: true},
+	"name":       {Field: "name"},
 }
 
-// GET /api/users
+// GET /api/users - Search public profiles
+// Query: role, skills (comma list) with skills_match=any|all, min_rating,
+// min_price, max_price, q (text search on name/about/offering),
+// sort (newest | rating | completed | price_asc | price_desc | name), limit, cursor.
 func GetAllUsers(c *gin.Context) {
+	filter, err := userSearchFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	page, err := parsePageRequest(c, userSorts, "newest")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	userCollection := config.DB.Collection("users")
-	cursor, err := userCollection.Find(ctx, bson.M{})
+	var users []models.User
+	next, err := findPage(ctx, config.DB.Collection("users"), filter, page, publicUserProjection, &users)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
 		return
 	}
 
-	var users []models.User
-	cursor.All(ctx, &users)
+	resp := gin.H{"users": publicUsers(users)}
+	if next != "" {
+		resp["next_cursor"] = next
+	}
+	c.JSON(http.StatusOK, resp)
+}
 
-	c.JSON(http.StatusOK, users)
+// userSearchFilter builds the Mongo filter for GetAllUsers.
+func userSearchFilter(c *gin.Context) (bson.M, error) {
+	filter := bson.M{}
+
+	switch role := c.Query("role"); role {
+	case "":
+	case models.RoleBuyer, models.RoleSolver:
+		filter["roles"] = role
+	default:
+		return nil, fmt.Errorf("role must be %s or %s", models.RoleBuyer, models.RoleSolver)
+	}
+
+	if raw := splitList(c.Query("skills")); len(raw) > 0 {
+		normalized, unknown := skills.NormalizeAll(raw)
+		if len(unknown) > 0 {
+			return nil, fmt.Errorf("unknown skills: %s", strings.Join(unknown, ", "))
+		}
+		switch c.DefaultQuery("skills_match", "any") {
+		case "any":
+			filter["skills"] = bson.M{"$in": normalized}
+		case "all":
+			filter["skills"] = bson.M{"$all": normalized}
+		default:
+			return nil, fmt.Errorf("skills_match must be any or all")
+		}
+	}
+
+	if v, ok, err := queryFloat(c, "min_rating"); err != nil {
+		return nil, err
+	} else if ok {
+		filter["avgRating"] = bson.M{"$gte": v}
+	}
+	price := bson.M{}
+	if v, ok, err := queryFloat(c, "min_price"); err != nil {
+		return nil, err
+	} else if ok {
+		price["$gte"] = v
+	}
+	if v, ok, err := queryFloat(c, "max_price"); err != nil {
+		return nil, err
+	} else if ok {
+		price["$lte"] = v
+	}
+	if len(price) > 0 {
+		filter["pricePerJob"] = price
+	}
+
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		if len(q) > 200 {
+			return nil, fmt.Errorf("q must be at most 200 characters")
+		}
+		filter["$text"] = bson.M{"$search": q}
+	}
+	return filter, nil
+}
+
+// publicUserProjection keeps credentials and payout details out of queries
+// whose results are only shown as public profiles.
+var publicUserProjection = bson.M{"password": 0, "payout": 0, "twoFactor": 0, "email": 0}
+
+// publicUsers converts users to their public profiles.
+func publicUsers(users []models.User) []models.PublicUser {
+	out := make([]models.PublicUser, 0, len(users))
+	for _, u := range users {
+		out = append(out, u.Public())
+	}
+	return out
+}
+
+// updateUserRequest lists the profile fields a user may change themselves.
+// Pointer fields distinguish "not sent" from a zero value.
+type updateUserRequest struct {
+	Name            *string               `json:"name"`
+	Email           *string               `json:"email"` // accepted only if unchanged
+	About           *string               `json:"about"`
+	Offering        *string               `json:"offering"`
+	Skills          *[]string             `json:"skills"`
+	PricePerJob     *float64              `json:"price_per_job"`
+	Speed           *float64              `json:"speed"`
+	Location        *models.GeoPoint      `json:"location"`
+	EthereumAddress *string               `json:"ethereumAddress"`
+	Payout          *models.PayoutDetails `json:"payout"`
+	TOTPCode        string                `json:"totp_code"`
+}
+
+// Fields that are computed by the platform or managed by dedicated endpoints
+var protectedUserFields = map[string]string{
+	"id":            "is immutable",
+	"_id":           "is immutable",
+	"password":      "must be changed via PUT /api/users/:id/password",
+	"roles":         "must be changed via POST /api/users/:id/roles/solver",
+	"twoFactor":     "must be changed via /api/auth/2fa",
+	"emailVerified": "is set by email verification",
+	"avg_rating":    "is computed by the system",
+	"avgRating":     "is computed by the system",
+	"rating_count":  "is computed by the system",
+	"ratingCount":   "is computed by the system",
+	"avg_response":  "is computed by the system",
+	"avgResponse":   "is computed by the system",
+	"avg_speed":     "is computed by the system",
+	"avgSpeed":      "is computed by the system",
+	"completedJobs": "is computed by the system",
+	"reliability":   "is computed by the system",
+	"createdAt":     "is computed by the system",
 }
 
 // PUT /api/users/:id
@@ -145,21 +559,283 @@ func UpdateUser(c *gin.Context) {
 	userID := c.Param("id")
 	objID, _ := primitive.ObjectIDFromHex(userID)
 
-	var updateData bson.M
-	if err := c.ShouldBindJSON(&updateData); err != nil {
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+	if !isSelf(callerID, objID) {
+		forbidden(c, "You can only update your own profile")
+		return
+	}
+
+	// Reject protected and unknown keys before decoding into the typed request
+	var raw map[string]json.RawMessage
+	if err := c.ShouldBindJSON(&raw); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	for key := range raw {
+		if reason, ok := protectedUserFields[key]; ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("field %q %s", key, reason)})
+			return
+		}
+	}
+	body, _ := json.Marshal(raw)
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	var req updateUserRequest
+	if err := decoder.Decode(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid update: " + err.Error()})
+		return
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	user, err := loadUser(ctx, objID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if user.Payout, err = utils.DecryptPayout(user.ID, user.Payout); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read payout details"})
+		return
+	}
+
+	set, err := buildUserUpdate(req, user)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(set) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No updatable fields provided"})
+		return
+	}
+
+	// Payout and wallet changes redirect money, so they need a fresh 2FA code
+	if touchesPaymentDetails(set) {
+		if err := requireFreshTOTP(ctx, user, req.TOTPCode); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "2fa_required"})
+			return
+		}
+	}
+
+	if payout, ok := set["payout"].(models.PayoutDetails); ok {
+		if set["payout"], err = utils.EncryptPayout(objID, payout); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to secure payout details"})
+			return
+		}
+	}
+
 	userCollection := config.DB.Collection("users")
-	result, err := userCollection.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": updateData})
+	result, err := userCollection.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": set})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
 		return
 	}
 
+	if _, ok := set["payout"]; ok {
+		recordPayoutChange(c, user, normalizePayout(*req.Payout))
+	}
+	if addr, ok := set["ethereumAddress"]; ok {
+		recordAudit(c, audit.ActionWalletChange, audit.TargetUser, objID.Hex(),
+			gin.H{"ethereumAddress": user.EthereumAddress},
+			gin.H{"ethereumAddress": addr},
+		)
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "User updated", "modified_count": result.ModifiedCount})
 }
+
+// buildUserUpdate validates each provided field and returns the $set document.
+func buildUserUpdate(req updateUserRequest, current models.User) (bson.M, error) {
+	set := bson.M{}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" || len(name) > 100 {
+			return nil, fmt.Errorf("name must be 1-100 characters")
+		}
+		set["name"] = name
+	}
+	if req.Email != nil && !strings.EqualFold(strings.TrimSpace(*req.Email), current.Email) {
+		return nil, fmt.Errorf("email cannot be changed through profile updates")
+	}
+	if req.About != nil {
+		if len(*req.About) > 2000 {
+			return nil, fmt.Errorf("about must be at most 2000 characters")
+		}
+		set["about"] = strings.TrimSpace(*req.About)
+	}
+	if req.Offering != nil {
+		if len(*req.Offering) > 100 {
+			return nil, fmt.Errorf("offering must be at most 100 characters")
+		}
+		set["offering"] = strings.TrimSpace(*req.Offering)
+	}
+	if req.Skills != nil {
+		normalized, unknown := skills.NormalizeAll(*req.Skills)
+		if len(unknown) > 0 {
+			return nil, fmt.Errorf("unknown skills: %s", strings.Join(unknown, ", "))
+		}
+		if normalized == nil {
+			normalized = []string{}
+		}
+		set["skills"] = normalized
+	}
+	if req.PricePerJob != nil {
+		if *req.PricePerJob <= 0 {
+			return nil, fmt.Errorf("price_per_job must be positive")
+		}
+		set["pricePerJob"] = *req.PricePerJob
+	}
+	if req.Speed != nil {
+		if *req.Speed < 0 {
+			return nil, fmt.Errorf("speed must not be negative")
+		}
+		set["speed"] = *req.Speed
+	}
+	if req.Location != nil && req.Location.IsZero() {
+		set["location"] = nil
+	} else if req.Location != nil {
+		if !utils.ValidateCoordinates(req.Location.Latitude(), req.Location.Longitude()) {
+			return nil, fmt.Errorf("location must have latitude in [-90, 90] and longitude in [-180, 180]")
+		}
+		set["location"] = req.Location
+	}
+	if req.EthereumAddress != nil {
+		addr := strings.TrimSpace(*req.EthereumAddress)
+		if addr != "" && !utils.ValidateEthereumAddress(addr) {
+			return nil, fmt.Errorf("ethereumAddress is not a valid Ethereum address")
+		}
+		if addr != current.EthereumAddress {
+			set["ethereumAddress"] = addr
+		}
+	}
+	if req.Payout != nil {
+		payout := normalizePayout(*req.Payout)
+		if err := validatePayoutDetails(payout); err != nil {
+			return nil, err
+		}
+		if payout != current.Payout {
+			set["payout"] = payout
+		}
+	}
+
+	return set, nil
+}
+
+// PUT /api/users/:id/password - Change password
+// Body: { "current_password": "...", "new_password": "..." }
+func ChangePassword(c *gin.Context) {
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	callerID, ok := requireCaller(c)
+	if !ok {
+		return
+	}
+	if !isSelf(callerID, objID) {
+		forbidden(c, "You can only change your own password")
+		return
+	}
+
+	var req struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		NewPassword     string `json:"new_password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "current_password and new_password are required"})
+		return
+	}
+	if len(req.NewPassword) < minPasswordLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("password must be at least %d characters", minPasswordLength)})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	user, err := loadUser(ctx, objID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if !utils.CheckPassword(user.Password, req.CurrentPassword) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect"})
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+	if _, err := config.DB.Collection("users").UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": bson.M{"password": hashedPassword}}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+		return
+	}
+
+	// Keep this device signed in; sign out every other session
+	currentSession, _ := middleware.CurrentSessionID(c)
+	revoked, err := sessions.RevokeAllExcept(ctx, objID, currentSession, sessions.ReasonPasswordChange)
+	if err != nil {
+		fmt.Printf("[ChangePassword] failed to revoke sessions for %s: %v\n", objID.Hex(), err)
+	}
+	recordAudit(c, audit.ActionPasswordChange, audit.TargetUser, objID.Hex(), nil, gin.H{"other_sessions_revoked": revoked})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed", "other_sessions_revoked": revoked})
+}
+
+// recordPayoutChange audits a change of payout details. Only masked values
+// are recorded so the log never holds full account numbers. current.Payout
+// may be sealed or already decrypted.
+func recordPayoutChange(c *gin.Context, current models.User, next models.PayoutDetails) {
+	before, err := utils.DecryptPayout(current.ID, current.Payout)
+	if err != nil {
+		before = models.PayoutDetails{}
+	}
+	recordAudit(c, audit.ActionPayoutDetailsChange, audit.TargetUser, current.ID.Hex(),
+		gin.H{"payout": before.Masked()},
+		gin.H{"payout": next.Masked()},
+	)
+}
+
+// normalizePayout trims payout fields and upper-cases the IFSC.
+func normalizePayout(p models.PayoutDetails) models.PayoutDetails {
+	return models.PayoutDetails{
+		AccountHolderName: strings.TrimSpace(p.AccountHolderName),
+		AccountNumber:     strings.ReplaceAll(strings.TrimSpace(p.AccountNumber), " ", ""),
+		IFSC:              strings.ToUpper(strings.TrimSpace(p.IFSC)),
+		BankName:          strings.TrimSpace(p.BankName),
+		UPI:               strings.TrimSpace(p.UPI),
+	}
+}
+
+// validatePayoutDetails checks that payout details are complete and well-formed.
+func validatePayoutDetails(p models.PayoutDetails) error {
+	if p.UPI != "" && !utils.ValidateUPI(p.UPI) {
+		return fmt.Errorf("upi is not a valid UPI ID")
+	}
+	if p.AccountNumber != "" && !utils.ValidateAccountNumber(p.AccountNumber) {
+		return fmt.Errorf("accountNumber must be 9-18 digits")
+	}
+	if p.IFSC != "" && !utils.ValidateIFSC(p.IFSC) {
+		return fmt.Errorf("ifsc must look like ABCD0123456")
+	}
+	if !p.IsComplete() {
+		return fmt.Errorf("payout details are required: a UPI ID, or account holder name, account number and IFSC")
+	}
+	return nil
+}
+
+// touchesPaymentDetails reports whether an update changes where the user is paid.
+func touchesPaymentDetails(update bson.M) bool {
+	_, payout := update["payout"]
+	_, wallet := update["ethereumAddress"]
+	return payout || wallet
+}
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// Skills outside the taxonomy are rejected at registration whichever role is
+// requested, as they are by UpdateUser.
+func TestRegisterUserRejectsUnknownSkills(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		name string
+		body string
+	}{
+		{name: "buyer", body: `{"name":"A","email":"a@example.com","password":"long enough password","skills":["python","basket weaving"]}`},
+		{name: "solver", body: `{"name":"A","email":"a@example.com","password":"long enough password","role":"solver",` +
+			`"skills":["basket weaving"],"payout":{"accountHolderName":"A","upi":"a@upi"}}`},
+	}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	for _, tc := range cases {
+		mt.Run(tc.name, func(mt *mtest.T) {
+			config.DB = mt.Client.Database("test")
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			RegisterUser(c)
+
+			if w.Code != http.StatusBadRequest {
+				mt.Fatalf("status = %d, want %d; body %s", w.Code, http.StatusBadRequest, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), "basket weaving") {
+				mt.Fatalf("body %s, want it to name the unknown skill", w.Body.String())
+			}
+			if n := len(startedCommands(mt)); n != 0 {
+				mt.Fatalf("%d commands sent, want the user not to be stored", n)
+			}
+		})
+	}
+}
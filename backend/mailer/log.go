@@ -0,0 +1,44 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LogMailer is the development transport. With an empty Dir it prints
+// messages to stdout; otherwise it writes each message to Dir as a .eml file.
+type LogMailer struct {
+	Dir string
+}
+
+func NewLogMailer(dir string) *LogMailer {
+	return &LogMailer{Dir: dir}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	raw := buildMessage(fromAddress(), msg)
+
+	if m.Dir == "" {
+		fmt.Printf("[mailer] ---- outgoing mail ----\n%s\n[mailer] -----------------------\n", strings.ReplaceAll(string(raw), "\r\n", "\n"))
+		return nil
+	}
+
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create mail dir: %w", err)
+	}
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), sanitize(msg.To))
+	return os.WriteFile(filepath.Join(m.Dir, name), raw, 0o644)
+}
+
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '@' || r == '.' || r == '-' || r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,61 @@
+package mailer
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers email. Implementations must be safe for concurrent use.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+var defaultMailer Mailer = NewLogMailer("")
+
+// Init selects the transport from MAIL_TRANSPORT:
+//   - "smtp": SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD (works with MailHog)
+//   - "file": writes .eml files to MAIL_DIR (default ./tmp/mail)
+//   - anything else: logs messages to stdout
+func Init() {
+	switch strings.ToLower(os.Getenv("MAIL_TRANSPORT")) {
+	case "smtp":
+		m, err := NewSMTPMailerFromEnv()
+		if err != nil {
+			log.Fatal("Invalid SMTP configuration: ", err)
+		}
+		defaultMailer = m
+		log.Println("Mail transport: smtp")
+	case "file":
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "tmp/mail"
+		}
+		defaultMailer = NewLogMailer(dir)
+		log.Println("Mail transport: file (" + dir + ")")
+	default:
+		defaultMailer = NewLogMailer("")
+		log.Println("Mail transport: log")
+	}
+}
+
+// Send delivers a message through the configured transport.
+func Send(ctx context.Context, msg Message) error {
+	return defaultMailer.Send(ctx, msg)
+}
+
+func fromAddress() string {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "no-reply@homeworld.local"
+	}
+	return from
+}
@@ -1,14 +1,26 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"strings"
+	// Embedded zone database so solver time zones resolve on images without one
+	_ "time/tzdata"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 
 	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/controllers"
+	"github.com/Aashishvatwani/homeworld/mailer"
+	"github.com/Aashishvatwani/homeworld/migrations"
+	"github.com/Aashishvatwani/homeworld/ratelimit"
 	"github.com/Aashishvatwani/homeworld/routes"
+	"github.com/Aashishvatwani/homeworld/scheduler"
+	"github.com/Aashishvatwani/homeworld/sessions"
+	"github.com/Aashishvatwani/homeworld/skills"
+	"github.com/Aashishvatwani/homeworld/storage"
 	"github.com/gin-contrib/cors"
 )
 
@@ -21,10 +33,37 @@ func main() {
 
 	// Connect to MongoDB
 	config.ConnectDB()
+	config.LoadAuthConfig()
+	config.LoadEncryptionConfig()
+	config.ConnectRedis()
+	sessions.Init()
+	ratelimit.Init()
+	mailer.Init()
+	storage.Init()
+
+	// Bring existing documents up to the current schema
+	migrations.Run()
+	skills.Init()
+
+	// Background jobs; a lease keeps each one to a single replica at a time
+	scheduler.Init()
+	jobs := append(controllers.DeadlineJobs(), controllers.DisputeJobs()...)
+	jobs = append(jobs, controllers.OfferJobs()...)
+	scheduler.Start(context.Background(), jobs...)
 
 	// Setup Gin router
 	r := gin.Default()
 
+	// Rate limits key on the client IP, so only honour X-Forwarded-For from
+	// known proxies (e.g. the nginx container)
+	var trustedProxies []string
+	if v := os.Getenv("TRUSTED_PROXIES"); v != "" {
+		trustedProxies = strings.Split(v, ",")
+	}
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES: ", err)
+	}
+
 	// Configure CORS to allow all origins
 	config := cors.DefaultConfig()
 	config.AllowAllOrigins = true
@@ -43,6 +82,10 @@ func main() {
 	routes.ChatRoutes(r)
 	routes.NotificationRoutes(r)
 	routes.ContractTestRoutes(r) // Smart contract test endpoints
+	routes.AdminRoutes(r)
+	routes.AttachmentRoutes(r)
+	routes.DisputeRoutes(r)
+	routes.OfferRoutes(r)
 
 	log.Println("✅ Server running on port:", port)
 	if err := r.Run(":" + port); err != nil {
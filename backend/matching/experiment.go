@@ -0,0 +1,85 @@
+package matching
+
+import (
+	"hash/fnv"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Ranking variants compared by the A/B split
+const (
+	VariantHeuristic = "heuristic"
+	VariantLearned   = "learned"
+)
+
+// defaultLearnedPercent is the share of assignments ranked by the learned
+// model when MATCH_LEARNED_PERCENT is unset.
+const defaultLearnedPercent = 50
+
+var (
+	learnedEngine  *Engine
+	learnedPercent int
+	learnedOnce    sync.Once
+)
+
+// loadLearned loads the model at MATCH_MODEL_PATH, if set. A model that
+// fails to load leaves every assignment on the heuristic.
+func loadLearned() {
+	path := os.Getenv("MATCH_MODEL_PATH")
+	if path == "" {
+		return
+	}
+	model, err := LoadModel(path)
+	if err != nil {
+		log.Printf("matching: ignoring MATCH_MODEL_PATH: %v", err)
+		return
+	}
+
+	learnedPercent = defaultLearnedPercent
+	if v := os.Getenv("MATCH_LEARNED_PERCENT"); v != "" {
+		p, err := strconv.Atoi(v)
+		if err != nil || p < 0 || p > 100 {
+			log.Printf("matching: ignoring MATCH_LEARNED_PERCENT %q; it must be 0-100", v)
+		} else {
+			learnedPercent = p
+		}
+	}
+	learnedEngine = NewLearned(model, BuiltinScorers()...)
+	log.Printf("matching: model %s ranks %d%% of assignments", model.Version, learnedPercent)
+}
+
+// For returns the engine that ranks solvers for unit, the assignment's ID.
+// Assignments are split between the heuristic and the learned model by a
+// hash of the ID, so every ranking of an assignment comes from the same
+// variant and its outcomes can be credited to it. Without a model, and for
+// unsaved assignments, it is the Default engine.
+func For(unit primitive.ObjectID) *Engine {
+	learnedOnce.Do(loadLearned)
+	if learnedEngine == nil || unit.IsZero() || bucket("ranker", unit) >= learnedPercent {
+		return Default()
+	}
+	return learnedEngine
+}
+
+// bucket maps id to 0-99. Splits with different salts are independent of
+// each other.
+func bucket(salt string, id primitive.ObjectID) int {
+	h := fnv.New32a()
+	h.Write([]byte(salt))
+	h.Write(id[:])
+	return int(h.Sum32() % 100)
+}
+
+// LearnedShare is the loaded model's version and the percentage of
+// assignments it ranks; "" and 0 without a model.
+func LearnedShare() (string, int) {
+	learnedOnce.Do(loadLearned)
+	if learnedEngine == nil {
+		return "", 0
+	}
+	return learnedEngine.ModelVersion(), learnedPercent
+}
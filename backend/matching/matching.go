@@ -0,0 +1,279 @@
+// Package matching ranks solvers for an assignment. An Engine combines
+// Scorers, each rating one feature of the fit between 0 and 1, into a
+// weighted score and keeps the per-feature breakdown so buyers can see why a
+// solver was suggested. For a share of assignments the weights come from a
+// Model learned from past outcomes instead; see For.
+package matching
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/models"
+)
+
+// Candidate is a solver being ranked. DistanceKm is negative when either
+// the solver or the assignment has no location. ActiveAssignments is the
+// solver's accepted and in-progress work, and Now the time the ranking is
+// for, so availability is judged against a fixed instant.
+type Candidate struct {
+	Solver            models.User
+	DistanceKm        float64
+	ActiveAssignments int
+	Now               time.Time
+}
+
+// Available reports whether the candidate can take on the assignment at
+// c.Now: not away, under their concurrency cap and, when the assignment's
+// deadline is still ahead, due to be working before it.
+func Available(assignment models.Assignment, c Candidate) bool {
+	av := c.Solver.Availability
+	if av == nil {
+		return true
+	}
+	if av.AwayAt(c.Now) || !av.HasCapacity(c.ActiveAssignments) {
+		return false
+	}
+	if assignment.Deadline.After(c.Now) {
+		next := av.NextWorkingTime(c.Now)
+		return !next.IsZero() && next.Before(assignment.Deadline)
+	}
+	return true
+}
+
+// Scorer rates one feature of how well a candidate fits an assignment.
+// Score must return a value in [0, 1] and depend only on its arguments (and,
+// for skills, the taxonomy), so rankings are reproducible.
+type Scorer interface {
+	Name() string
+	Score(assignment models.Assignment, candidate Candidate) float64
+}
+
+// FeatureScore is one scorer's contribution to a match. Contribution is the
+// share of the total score it accounts for: Score * Weight / sum of weights.
+// For a learned engine Weight is the model's weight and Contribution the
+// feature's term in the model's log-odds, Score * Weight.
+type FeatureScore struct {
+	Feature      string  `json:"feature"`
+	Score        float64 `json:"score"`
+	Weight       float64 `json:"weight"`
+	Contribution float64 `json:"contribution"`
+}
+
+// Match is a ranked solver. Score is in [0, 1].
+type Match struct {
+	Solver     models.PublicUser `json:"solver"`
+	Score      float64           `json:"score"`
+	DistanceKm *float64          `json:"distance_km,omitempty"`
+	Breakdown  []FeatureScore    `json:"breakdown"`
+}
+
+// Weights maps scorer names to their relative weight. Weights need not add
+// up to 1; scores are divided by their sum.
+type Weights map[string]float64
+
+// DefaultWeights favour skill coverage, then cost, distance, turnaround and
+// availability.
+var DefaultWeights = Weights{
+	"skills":       0.35,
+	"price":        0.15,
+	"speed":        0.10,
+	"distance":     0.15,
+	"rating":       0.10,
+	"reliability":  0.05,
+	"availability": 0.10,
+}
+
+type weightedScorer struct {
+	scorer Scorer
+	weight float64
+}
+
+// Engine ranks candidates with a fixed set of weighted scorers. It is safe
+// for concurrent use.
+type Engine struct {
+	scorers []weightedScorer
+	total   float64
+	model   *Model // nil for the hand-weighted heuristic
+}
+
+// New builds an engine from scorers weighted by weights. Scorers without a
+// positive weight are left out.
+func New(weights Weights, scorers ...Scorer) *Engine {
+	e := &Engine{}
+	for _, s := range scorers {
+		w := weights[s.Name()]
+		if w <= 0 {
+			continue
+		}
+		e.scorers = append(e.scorers, weightedScorer{scorer: s, weight: w})
+		e.total += w
+	}
+	return e
+}
+
+// NewLearned builds an engine that scores candidates with model. Scorers
+// the model does not weigh are left out.
+func NewLearned(model *Model, scorers ...Scorer) *Engine {
+	e := &Engine{model: model}
+	for _, s := range scorers {
+		w, ok := model.Weights[s.Name()]
+		if !ok || w == 0 {
+			continue
+		}
+		e.scorers = append(e.scorers, weightedScorer{scorer: s, weight: w})
+	}
+	return e
+}
+
+// Variant names the ranker for A/B comparisons: VariantHeuristic or
+// VariantLearned.
+func (e *Engine) Variant() string {
+	if e.model != nil {
+		return VariantLearned
+	}
+	return VariantHeuristic
+}
+
+// ModelVersion is the learned model's version, "" for the heuristic.
+func (e *Engine) ModelVersion() string {
+	if e.model == nil {
+		return ""
+	}
+	return e.model.Version
+}
+
+// Rank scores every candidate and returns the best limit of them, highest
+// first; limit <= 0 returns all. Ties are broken by solver ID so the order
+// never depends on the order candidates were loaded in.
+func (e *Engine) Rank(assignment models.Assignment, candidates []Candidate, limit int) []Match {
+	matches := make([]Match, 0, len(candidates))
+	for _, c := range candidates {
+		matches = append(matches, e.Explain(assignment, c))
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Solver.ID.Hex() < matches[j].Solver.ID.Hex()
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// Explain scores a single candidate.
+func (e *Engine) Explain(assignment models.Assignment, candidate Candidate) Match {
+	m := Match{Solver: candidate.Solver.Public(), Breakdown: make([]FeatureScore, 0, len(e.scorers))}
+	if candidate.DistanceKm >= 0 {
+		d := round(candidate.DistanceKm)
+		m.DistanceKm = &d
+	}
+	if e.model != nil {
+		z := e.model.Bias
+		for _, ws := range e.scorers {
+			s := clamp(ws.scorer.Score(assignment, candidate))
+			z += s * ws.weight
+			m.Breakdown = append(m.Breakdown, FeatureScore{
+				Feature:      ws.scorer.Name(),
+				Score:        round(s),
+				Weight:       round(ws.weight),
+				Contribution: round(s * ws.weight),
+			})
+		}
+		m.Score = round(sigmoid(z))
+		return m
+	}
+	for _, ws := range e.scorers {
+		s := clamp(ws.scorer.Score(assignment, candidate))
+		contribution := s * ws.weight / e.total
+		m.Score += contribution
+		m.Breakdown = append(m.Breakdown, FeatureScore{
+			Feature:      ws.scorer.Name(),
+			Score:        round(s),
+			Weight:       round(ws.weight / e.total),
+			Contribution: round(contribution),
+		})
+	}
+	m.Score = round(m.Score)
+	return m
+}
+
+var (
+	defaultEngine     *Engine
+	defaultEngineOnce sync.Once
+)
+
+// Default returns the engine used by the API: the built-in scorers weighted
+// by DefaultWeights, overridden per feature by MATCH_WEIGHTS, e.g.
+// "skills=0.5,distance=0.1".
+func Default() *Engine {
+	defaultEngineOnce.Do(func() {
+		weights, err := ParseWeights(os.Getenv("MATCH_WEIGHTS"), DefaultWeights)
+		if err != nil {
+			log.Printf("matching: ignoring MATCH_WEIGHTS: %v", err)
+			weights = DefaultWeights
+		}
+		defaultEngine = New(weights, BuiltinScorers()...)
+	})
+	return defaultEngine
+}
+
+// ParseWeights applies "name=weight" pairs, separated by commas, on top of
+// base. Only the names of built-in scorers are accepted.
+func ParseWeights(spec string, base Weights) (Weights, error) {
+	weights := Weights{}
+	for k, v := range base {
+		weights[k] = v
+	}
+	known := map[string]bool{}
+	for _, s := range BuiltinScorers() {
+		known[s.Name()] = true
+	}
+
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || !known[name] {
+			return nil, fmt.Errorf("unknown feature in %q", pair)
+		}
+		w, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || w < 0 || math.IsInf(w, 0) {
+			return nil, fmt.Errorf("invalid weight in %q", pair)
+		}
+		weights[name] = w
+	}
+
+	total := 0.0
+	for _, w := range weights {
+		total += w
+	}
+	if total <= 0 {
+		return nil, fmt.Errorf("at least one weight must be positive")
+	}
+	return weights, nil
+}
+
+func clamp(v float64) float64 {
+	if math.IsNaN(v) || v < 0 {
+		return 0
+	}
+	return math.Min(v, 1)
+}
+
+// round keeps four decimals so scores read cleanly in the API.
+func round(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
@@ -0,0 +1,145 @@
+package matching
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/Aashishvatwani/homeworld/skills"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// goldenMatch is the part of a Match the golden file pins down.
+type goldenMatch struct {
+	SolverID   string         `json:"solverId"`
+	Name       string         `json:"name"`
+	Score      float64        `json:"score"`
+	DistanceKm *float64       `json:"distanceKm,omitempty"`
+	Breakdown  []FeatureScore `json:"breakdown"`
+}
+
+func fixedID(t *testing.T, hex string) primitive.ObjectID {
+	t.Helper()
+	id, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// rankingFixture is an assignment and a candidate set covering each
+// scorer's edge cases: exact and related skills, missing data, distance
+// unknown, a concurrency cap, working hours ahead, an away period before
+// the deadline and an exact tie.
+func rankingFixture(t *testing.T) (models.Assignment, []Candidate) {
+	now := time.Date(2026, time.March, 4, 10, 0, 0, 0, time.UTC) // a Wednesday
+	assignment := models.Assignment{
+		Title:    "Train a PyTorch classifier",
+		Skills:   []string{"pytorch", "python"},
+		Price:    100,
+		Deadline: now.Add(72 * time.Hour),
+	}
+
+	solver := func(hex, name string) models.User {
+		return models.User{ID: fixedID(t, hex), Name: name, Roles: []string{models.RoleBuyer, models.RoleSolver}}
+	}
+
+	exact := solver("000000000000000000000001", "Exact")
+	exact.Skills = []string{"pytorch", "python"}
+	exact.PricePerJob = 80
+	exact.Speed = 24
+	exact.AvgRating = 4.5
+	exact.Reliability = 0.95
+
+	related := solver("000000000000000000000002", "Related")
+	related.Skills = []string{"machine-learning"}
+	related.PricePerJob = 150
+	related.Availability = &models.Availability{
+		Weekly: []models.WorkingHours{{Day: time.Wednesday, Start: "14:00", End: "18:00"}},
+	}
+
+	busy := solver("000000000000000000000003", "Busy")
+	busy.Skills = []string{"python", "web"}
+	busy.PricePerJob = 100
+	busy.AvgSpeed = 96
+	busy.AvgRating = 3
+	busy.Reliability = 0.6
+	busy.MissedDeadlines = 2
+	busy.Availability = &models.Availability{
+		MaxConcurrent: 2,
+		Away:          []models.AwayPeriod{{From: now.Add(24 * time.Hour), Until: now.Add(48 * time.Hour)}},
+	}
+
+	newcomer := solver("000000000000000000000004", "Newcomer")
+	twin := solver("000000000000000000000005", "Twin")
+	newcomer.Skills = []string{"deep-learning"}
+	twin.Skills = []string{"deep-learning"}
+
+	return assignment, []Candidate{
+		{Solver: twin, DistanceKm: 150, Now: now},
+		{Solver: busy, DistanceKm: 250, ActiveAssignments: 1, Now: now},
+		{Solver: related, DistanceKm: -1, Now: now},
+		{Solver: exact, DistanceKm: 10, Now: now},
+		{Solver: newcomer, DistanceKm: 150, Now: now},
+	}
+}
+
+func TestRankDefaultWeightsGolden(t *testing.T) {
+	registry, err := skills.Build(skills.Builtin())
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := skills.Current()
+	skills.Use(registry)
+	defer skills.Use(previous)
+
+	assignment, candidates := rankingFixture(t)
+	engine := New(DefaultWeights, BuiltinScorers()...)
+
+	var got []goldenMatch
+	for _, m := range engine.Rank(assignment, candidates, 0) {
+		got = append(got, goldenMatch{
+			SolverID:   m.Solver.ID.Hex(),
+			Name:       m.Solver.Name,
+			Score:      m.Score,
+			DistanceKm: m.DistanceKm,
+			Breakdown:  m.Breakdown,
+		})
+	}
+	data, err := json.MarshalIndent(got, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = append(data, '\n')
+
+	path := filepath.Join("testdata", "default_ranking.golden.json")
+	if *update {
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test ./matching -update to create it)", err)
+	}
+	if string(data) != string(want) {
+		t.Errorf("ranking differs from %s; if the change is intended, rerun with -update and review the diff\ngot:\n%s", path, data)
+	}
+
+	// Shuffling the input must not change the result
+	reversed := make([]Candidate, len(candidates))
+	for i, c := range candidates {
+		reversed[len(candidates)-1-i] = c
+	}
+	for i, m := range engine.Rank(assignment, reversed, 0) {
+		if m.Solver.ID.Hex() != got[i].SolverID {
+			t.Fatalf("position %d is %s with candidates reversed, want %s", i+1, m.Solver.Name, got[i].Name)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package matching
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"time"
+)
+
+// Model is a linear model over the feature scores of the built-in scorers,
+// fitted offline by cmd/trainranker from logged match impressions and their
+// outcomes. A candidate's score is the logistic of Bias plus the weighted
+// sum of its feature scores; pairwise models leave Bias at 0 since only
+// the order matters.
+type Model struct {
+	Version   string             `json:"version"`
+	Objective string             `json:"objective"` // ObjectivePointwise or ObjectivePairwise
+	TrainedAt time.Time          `json:"trainedAt"`
+	Samples   int                `json:"samples"` // rows or pairs it was fitted on
+	Bias      float64            `json:"bias"`
+	Weights   map[string]float64 `json:"weights"` // by scorer name
+}
+
+// Training objectives
+const (
+	// ObjectivePointwise fits the probability that a shown solver converts.
+	ObjectivePointwise = "pointwise"
+	// ObjectivePairwise fits the probability that one solver shown for an
+	// assignment converts further than another.
+	ObjectivePairwise = "pairwise"
+)
+
+// LoadModel reads a model written by Model.Save and checks that it only
+// weighs built-in features.
+func LoadModel(path string) (*Model, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m Model
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := m.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &m, nil
+}
+
+// Save writes the model as indented JSON.
+func (m *Model) Save(path string) error {
+	if err := m.validate(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+func (m *Model) validate() error {
+	if m.Version == "" {
+		return fmt.Errorf("model has no version")
+	}
+	if len(m.Weights) == 0 {
+		return fmt.Errorf("model has no weights")
+	}
+	known := map[string]bool{}
+	for _, s := range BuiltinScorers() {
+		known[s.Name()] = true
+	}
+	if math.IsNaN(m.Bias) || math.IsInf(m.Bias, 0) {
+		return fmt.Errorf("model bias is not finite")
+	}
+	for name, w := range m.Weights {
+		if !known[name] {
+			return fmt.Errorf("model weighs unknown feature %q", name)
+		}
+		if math.IsNaN(w) || math.IsInf(w, 0) {
+			return fmt.Errorf("model weight for %q is not finite", name)
+		}
+	}
+	return nil
+}
+
+// Logit is the model's log-odds for the given feature scores. Features the
+// model does not weigh are ignored and missing ones count as 0.
+func (m *Model) Logit(features map[string]float64) float64 {
+	z := m.Bias
+	for name, w := range m.Weights {
+		z += w * features[name]
+	}
+	return z
+}
+
+func sigmoid(z float64) float64 {
+	return 1 / (1 + math.Exp(-z))
+}
@@ -0,0 +1,177 @@
+package matching
+
+import (
+	"math"
+
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/Aashishvatwani/homeworld/skills"
+)
+
+const (
+	// neutralScore is given when there is no data to judge a feature by,
+	// so missing data neither helps nor sinks a solver.
+	neutralScore = 0.5
+	// halfScoreTurnaroundHours is the average turnaround that scores 0.5.
+	halfScoreTurnaroundHours = 48.0
+	// MaxDistanceKm is the distance at which proximity stops counting.
+	MaxDistanceKm = 300.0
+	// halfScoreWaitHours is the wait for the solver's working hours that
+	// scores 0.5.
+	halfScoreWaitHours = 12.0
+	// halfScoreWorkload is the number of active assignments at which a
+	// solver without a concurrency cap scores 0.5 for capacity.
+	halfScoreWorkload = 3.0
+)
+
+// BuiltinScorers returns the scorers the default engine uses.
+func BuiltinScorers() []Scorer {
+	return []Scorer{
+		SkillScorer{},
+		PriceScorer{},
+		SpeedScorer{},
+		DistanceScorer{MaxKm: MaxDistanceKm},
+		RatingScorer{},
+		ReliabilityScorer{},
+		AvailabilityScorer{},
+	}
+}
+
+// SkillScorer is how well the solver's skills cover the assignment's,
+// averaged over the required skills. Each required skill takes the best
+// Similarity among the solver's skills in the current taxonomy, so related
+// skills earn partial credit. Without listed skills it uses the taxonomy
+// skills named in the description.
+type SkillScorer struct{}
+
+func (SkillScorer) Name() string { return "skills" }
+
+func (SkillScorer) Score(a models.Assignment, c Candidate) float64 {
+	if len(c.Solver.Skills) == 0 {
+		return 0
+	}
+	required := skills.Canonicalize(a.Skills)
+	if len(required) == 0 {
+		required = skills.Extract(a.Description)
+	}
+	if len(required) == 0 {
+		return 0
+	}
+
+	total := 0.0
+	for _, r := range required {
+		best := 0.0
+		for _, h := range c.Solver.Skills {
+			best = math.Max(best, skills.Similarity(r, h))
+		}
+		total += best
+	}
+	return total / float64(len(required))
+}
+
+// PriceScorer compares the solver's usual price with the assignment's
+// budget: 1 at or under budget, falling off in proportion above it.
+type PriceScorer struct{}
+
+func (PriceScorer) Name() string { return "price" }
+
+func (PriceScorer) Score(a models.Assignment, c Candidate) float64 {
+	budget := a.Price
+	if a.BidAmount > 0 {
+		budget = a.BidAmount
+	}
+	if budget <= 0 || c.Solver.PricePerJob <= 0 {
+		return neutralScore
+	}
+	if c.Solver.PricePerJob <= budget {
+		return 1
+	}
+	return budget / c.Solver.PricePerJob
+}
+
+// SpeedScorer favours solvers with a short average turnaround, scoring 0.5
+// at halfScoreTurnaroundHours.
+type SpeedScorer struct{}
+
+func (SpeedScorer) Name() string { return "speed" }
+
+func (SpeedScorer) Score(_ models.Assignment, c Candidate) float64 {
+	hours := c.Solver.Speed
+	if hours <= 0 {
+		hours = c.Solver.AvgSpeed
+	}
+	if hours <= 0 {
+		return neutralScore
+	}
+	return 1 / (1 + hours/halfScoreTurnaroundHours)
+}
+
+// DistanceScorer falls linearly from 1 next to the assignment to 0 at MaxKm.
+// Candidates without a known distance get no proximity credit.
+type DistanceScorer struct {
+	MaxKm float64
+}
+
+func (DistanceScorer) Name() string { return "distance" }
+
+func (s DistanceScorer) Score(_ models.Assignment, c Candidate) float64 {
+	if c.DistanceKm < 0 || s.MaxKm <= 0 {
+		return 0
+	}
+	return 1 - c.DistanceKm/s.MaxKm
+}
+
+// RatingScorer is the solver's average rating out of 5.
+type RatingScorer struct{}
+
+func (RatingScorer) Name() string { return "rating" }
+
+func (RatingScorer) Score(_ models.Assignment, c Candidate) float64 {
+	if c.Solver.AvgRating <= 0 {
+		return neutralScore
+	}
+	return c.Solver.AvgRating / 5
+}
+
+// ReliabilityScorer is the solver's 0-1 reliability. Solvers who have never
+// been penalized may have none recorded and count as fully reliable.
+type ReliabilityScorer struct{}
+
+func (ReliabilityScorer) Name() string { return "reliability" }
+
+func (ReliabilityScorer) Score(_ models.Assignment, c Candidate) float64 {
+	if c.Solver.Reliability == 0 && c.Solver.MissedDeadlines == 0 && c.Solver.Disputes.Lost == 0 {
+		return 1
+	}
+	return c.Solver.Reliability
+}
+
+// AvailabilityScorer favours solvers with spare capacity who are working
+// now or soon, taken together. Capacity is the unused share of the
+// solver's concurrency cap, or falls off with workload when there is none.
+// An away period starting before the deadline halves the score. Solvers
+// without working hours count as always working.
+type AvailabilityScorer struct{}
+
+func (AvailabilityScorer) Name() string { return "availability" }
+
+func (AvailabilityScorer) Score(a models.Assignment, c Candidate) float64 {
+	av := c.Solver.Availability
+	if av == nil {
+		av = &models.Availability{}
+	}
+
+	capacity := 1 / (1 + float64(c.ActiveAssignments)/halfScoreWorkload)
+	if av.MaxConcurrent > 0 {
+		capacity = 1 - float64(c.ActiveAssignments)/float64(av.MaxConcurrent)
+	}
+
+	next := av.NextWorkingTime(c.Now)
+	if next.IsZero() {
+		return 0
+	}
+	timing := 1 / (1 + next.Sub(c.Now).Hours()/halfScoreWaitHours)
+	if a.Deadline.After(c.Now) && av.AwayBetween(c.Now, a.Deadline) {
+		timing /= 2
+	}
+	return capacity * timing
+}
@@ -0,0 +1,135 @@
+package matching
+
+import (
+	"context"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ImpressionCollection holds the models.MatchImpression log.
+func ImpressionCollection() *mongo.Collection {
+	return config.DB.Collection("match_impressions")
+}
+
+// OutcomeCollection holds the models.MatchOutcome records.
+func OutcomeCollection() *mongo.Collection {
+	return config.DB.Collection("match_outcomes")
+}
+
+// ShownPair is a solver shown for an assignment and what they did after
+// they were first shown. It is a row of training data for the ranker.
+type ShownPair struct {
+	AssignmentID primitive.ObjectID `bson:"assignmentId" json:"assignmentId"`
+	SolverID     primitive.ObjectID `bson:"solverId" json:"solverId"`
+	Variant      string             `bson:"variant" json:"variant"`
+	Position     int                `bson:"position" json:"position"` // best position shown at
+	Features     map[string]float64 `bson:"features" json:"features"` // as first shown
+	ShownAt      time.Time          `bson:"shownAt" json:"shownAt"`
+	Bid          bool               `bson:"-" json:"bid"`
+	Accepted     bool               `bson:"-" json:"accepted"`
+	Completed    bool               `bson:"-" json:"completed"`
+	OnTime       bool               `bson:"-" json:"onTime"`
+	Rating       int                `bson:"-" json:"rating,omitempty"`
+}
+
+// EachShownPair calls fn for every solver shown for an assignment since
+// the given time, once per assignment and solver, with the outcomes
+// recorded after they were first shown.
+func EachShownPair(ctx context.Context, since time.Time, fn func(ShownPair) error) error {
+	cursor, err := ImpressionCollection().Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"createdAt": bson.M{"$gte": since}}}},
+		{{Key: "$sort", Value: bson.M{"createdAt": 1}}},
+		{{Key: "$unwind", Value: "$results"}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      bson.M{"a": "$assignmentId", "s": "$results.solverId"},
+			"variant":  bson.M{"$first": "$variant"},
+			"position": bson.M{"$min": "$results.position"},
+			"features": bson.M{"$first": "$results.features"},
+			"shownAt":  bson.M{"$first": "$createdAt"},
+		}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": OutcomeCollection().Name(),
+			"let":  bson.M{"a": "$_id.a", "s": "$_id.s", "t": "$shownAt"},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{"$expr": bson.M{"$and": bson.A{
+					bson.M{"$eq": bson.A{"$assignmentId", "$$a"}},
+					bson.M{"$eq": bson.A{"$solverId", "$$s"}},
+					bson.M{"$gte": bson.A{"$at", "$$t"}},
+				}}}},
+			},
+			"as": "outcomes",
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":          0,
+			"assignmentId": "$_id.a",
+			"solverId":     "$_id.s",
+			"variant":      1,
+			"position":     1,
+			"features":     1,
+			"shownAt":      1,
+			"outcomes":     1,
+		}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var row struct {
+			ShownPair `bson:",inline"`
+			Outcomes  []models.MatchOutcome `bson:"outcomes"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			return err
+		}
+		pair := row.ShownPair
+		for _, o := range row.Outcomes {
+			switch o.Event {
+			case models.OutcomeBid:
+				pair.Bid = true
+			case models.OutcomeAccepted:
+				pair.Accepted = true
+			case models.OutcomeCompleted:
+				pair.Completed = true
+				pair.OnTime = o.OnTime != nil && *o.OnTime
+			case models.OutcomeRated:
+				pair.Rating = o.Rating
+			}
+		}
+		if err := fn(pair); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// Relevance grades what the solver did: 0 nothing, 1 bid, 2 accepted, 3
+// completed on time. Once accepted, a rating of 4-5 adds a grade and 1-2
+// takes one away.
+func (p ShownPair) Relevance() int {
+	r := 0
+	switch {
+	case p.Completed && p.OnTime:
+		r = 3
+	case p.Accepted || p.Completed:
+		r = 2
+	case p.Bid:
+		r = 1
+	}
+	if r >= 2 && p.Rating > 0 {
+		switch {
+		case p.Rating >= 4:
+			r++
+		case p.Rating <= 2:
+			r--
+		}
+	}
+	return r
+}
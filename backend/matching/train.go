@@ -0,0 +1,213 @@
+package matching
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TrainOptions configure Train. Zero values take the defaults noted.
+type TrainOptions struct {
+	Objective string // ObjectivePointwise (default) or ObjectivePairwise
+	// MinRelevance is the grade a pointwise example needs to count as a
+	// conversion; default 2, accepted
+	MinRelevance int
+	Epochs       int     // default 500
+	LearningRate float64 // default 0.5
+	L2           float64 // weight decay; default 0.001
+}
+
+func (o *TrainOptions) defaults() {
+	if o.Objective == "" {
+		o.Objective = ObjectivePointwise
+	}
+	if o.MinRelevance <= 0 {
+		o.MinRelevance = 2
+	}
+	if o.Epochs <= 0 {
+		o.Epochs = 500
+	}
+	if o.LearningRate <= 0 {
+		o.LearningRate = 0.5
+	}
+	if o.L2 <= 0 {
+		o.L2 = 0.001
+	}
+}
+
+// example is one logistic regression input: feature values and a 0/1 label.
+type example struct {
+	x []float64
+	y float64
+}
+
+// Train fits a logistic model to shown pairs by batch gradient descent.
+// Pointwise it predicts whether a shown solver converts; pairwise it
+// predicts which of two solvers shown for the same assignment converts
+// further, which only learns the order and needs no bias.
+func Train(pairs []ShownPair, opts TrainOptions) (*Model, error) {
+	opts.defaults()
+	features := featureNames()
+
+	var examples []example
+	switch opts.Objective {
+	case ObjectivePointwise:
+		for _, p := range pairs {
+			y := 0.0
+			if p.Relevance() >= opts.MinRelevance {
+				y = 1
+			}
+			examples = append(examples, example{x: featureVector(features, p.Features), y: y})
+		}
+	case ObjectivePairwise:
+		for _, group := range groupByAssignment(pairs) {
+			for i := range group {
+				for j := range group {
+					if group[i].Relevance() <= group[j].Relevance() {
+						continue
+					}
+					better := featureVector(features, group[i].Features)
+					worse := featureVector(features, group[j].Features)
+					diff := make([]float64, len(features))
+					for k := range diff {
+						diff[k] = better[k] - worse[k]
+					}
+					examples = append(examples, example{x: diff, y: 1})
+				}
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown objective %q", opts.Objective)
+	}
+	if len(examples) == 0 {
+		return nil, fmt.Errorf("no training examples; more impressions with outcomes are needed")
+	}
+
+	withBias := opts.Objective == ObjectivePointwise
+	weights := make([]float64, len(features))
+	bias := 0.0
+	n := float64(len(examples))
+	for epoch := 0; epoch < opts.Epochs; epoch++ {
+		grad := make([]float64, len(features))
+		gradBias := 0.0
+		for _, ex := range examples {
+			z := bias
+			for k, v := range ex.x {
+				z += weights[k] * v
+			}
+			e := sigmoid(z) - ex.y
+			for k, v := range ex.x {
+				grad[k] += e * v
+			}
+			gradBias += e
+		}
+		for k := range weights {
+			weights[k] -= opts.LearningRate * (grad[k]/n + opts.L2*weights[k])
+		}
+		if withBias {
+			bias -= opts.LearningRate * gradBias / n
+		}
+	}
+
+	now := time.Now().UTC()
+	model := &Model{
+		Version:   fmt.Sprintf("%s-%s", opts.Objective, now.Format("20060102T150405Z")),
+		Objective: opts.Objective,
+		TrainedAt: now,
+		Samples:   len(examples),
+		Bias:      round(bias),
+		Weights:   map[string]float64{},
+	}
+	for k, name := range features {
+		model.Weights[name] = round(weights[k])
+	}
+	return model, nil
+}
+
+// PairwiseAccuracy is the share of pairs shown for the same assignment, with
+// different relevance, that score orders correctly. Ties count as half.
+// It returns NaN when there are no such pairs.
+func PairwiseAccuracy(pairs []ShownPair, score func(features map[string]float64) float64) float64 {
+	correct, total := 0.0, 0
+	for _, group := range groupByAssignment(pairs) {
+		for i := range group {
+			for j := range group {
+				if group[i].Relevance() <= group[j].Relevance() {
+					continue
+				}
+				total++
+				si, sj := score(group[i].Features), score(group[j].Features)
+				switch {
+				case si > sj:
+					correct++
+				case si == sj:
+					correct += 0.5
+				}
+			}
+		}
+	}
+	if total == 0 {
+		return math.NaN()
+	}
+	return correct / float64(total)
+}
+
+// HeuristicScore scores logged features with weights the way New does.
+func HeuristicScore(weights Weights) func(map[string]float64) float64 {
+	return func(features map[string]float64) float64 {
+		score, total := 0.0, 0.0
+		for name, w := range weights {
+			if w <= 0 {
+				continue
+			}
+			score += w * features[name]
+			total += w
+		}
+		if total == 0 {
+			return 0
+		}
+		return score / total
+	}
+}
+
+// InHoldout reports whether an assignment belongs to the evaluation split
+// of percent of assignments, chosen by ID so it is stable across runs.
+func InHoldout(assignmentID primitive.ObjectID, percent int) bool {
+	return bucket("holdout", assignmentID) < percent
+}
+
+func featureNames() []string {
+	scorers := BuiltinScorers()
+	names := make([]string, len(scorers))
+	for i, s := range scorers {
+		names[i] = s.Name()
+	}
+	return names
+}
+
+// featureVector orders features by names. Features missing from a logged
+// result, such as those a zero weight left out of the ranking, count as 0.
+func featureVector(names []string, features map[string]float64) []float64 {
+	x := make([]float64, len(names))
+	for i, name := range names {
+		x[i] = features[name]
+	}
+	return x
+}
+
+func groupByAssignment(pairs []ShownPair) [][]ShownPair {
+	index := map[primitive.ObjectID]int{}
+	var groups [][]ShownPair
+	for _, p := range pairs {
+		i, ok := index[p.AssignmentID]
+		if !ok {
+			i = len(groups)
+			index[p.AssignmentID] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], p)
+	}
+	return groups
+}
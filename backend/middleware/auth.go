@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/sessions"
+	"github.com/Aashishvatwani/homeworld/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Context keys set by AuthRequired
+const (
+	ContextUserID    = "userId"
+	ContextSessionID = "sessionId"
+	ContextEmail     = "email"
+	ContextRole      = "role"
+	ContextClaims    = "claims"
+)
+
+// AuthRequired rejects requests without a valid "Authorization: Bearer <token>"
+// header and stores the caller's identity in the gin context.
+func AuthRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+			return
+		}
+
+		parts := strings.SplitN(header, " ", 2)
+		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") || strings.TrimSpace(parts[1]) == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header must be: Bearer <token>"})
+			return
+		}
+
+		claims, err := utils.ValidateJWT(strings.TrimSpace(parts[1]))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			return
+		}
+
+		// ValidateJWT guarantees both IDs are valid hex ObjectIDs
+		userID, _ := primitive.ObjectIDFromHex(claims.UserID)
+		sessionID, _ := primitive.ObjectIDFromHex(claims.SessionID)
+
+		// Reject tokens whose session was logged out or revoked
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		active, err := sessions.IsActive(ctx, sessionID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify session"})
+			return
+		}
+		if !active {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Session has been revoked"})
+			return
+		}
+
+		c.Set(ContextUserID, userID)
+		c.Set(ContextSessionID, sessionID)
+		c.Set(ContextEmail, claims.Email)
+		c.Set(ContextRole, claims.Role)
+		c.Set(ContextClaims, claims)
+		c.Next()
+	}
+}
+
+// CurrentUserID returns the authenticated caller's ID.
+func CurrentUserID(c *gin.Context) (primitive.ObjectID, bool) {
+	v, ok := c.Get(ContextUserID)
+	if !ok {
+		return primitive.NilObjectID, false
+	}
+	id, ok := v.(primitive.ObjectID)
+	return id, ok
+}
+
+// CurrentSessionID returns the session the caller's access token belongs to.
+func CurrentSessionID(c *gin.Context) (primitive.ObjectID, bool) {
+	v, ok := c.Get(ContextSessionID)
+	if !ok {
+		return primitive.NilObjectID, false
+	}
+	id, ok := v.(primitive.ObjectID)
+	return id, ok
+}
+
+// CurrentRole returns the role carried in the caller's token.
+func CurrentRole(c *gin.Context) string {
+	return c.GetString(ContextRole)
+}
+
+// RequireRole rejects callers whose active role is not role. Register it
+// after AuthRequired.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if CurrentRole(c) != role {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "This action requires the " + role + " role"})
+			return
+		}
+		c.Next()
+	}
+}
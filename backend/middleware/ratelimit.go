@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+type limitCheck struct {
+	key   string
+	limit int
+}
+
+// RateLimit enforces a policy's per-IP budget and, for authenticated
+// requests, its per-user budget. Register it after AuthRequired so the
+// caller is known. If the store is unavailable the request is let through.
+func RateLimit(p *ratelimit.Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		checks := []limitCheck{{p.Name + ":ip:" + c.ClientIP(), p.PerIP}}
+		if userID, ok := CurrentUserID(c); ok {
+			checks = append(checks, limitCheck{p.Name + ":user:" + userID.Hex(), p.PerUser})
+		}
+
+		remaining := math.MaxInt
+		limit := 0
+		for _, check := range checks {
+			if check.limit <= 0 {
+				continue
+			}
+			res, err := ratelimit.Allow(ctx, check.key, check.limit, p.Window)
+			if err != nil {
+				log.Printf("[ratelimit] %s check failed, allowing request: %v", p.Name, err)
+				continue
+			}
+			if !res.Allowed {
+				retry := int(math.Ceil(res.RetryAfter.Seconds()))
+				c.Header("Retry-After", strconv.Itoa(retry))
+				c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+					"error":       "Too many requests, please try again later",
+					"retry_after": retry,
+				})
+				return
+			}
+			if res.Remaining < remaining {
+				remaining = res.Remaining
+				limit = check.limit
+			}
+		}
+
+		if limit > 0 {
+			c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
+			c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,564 @@
+package migrations
+
+import (
+	"context"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/audit"
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/matching"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/Aashishvatwani/homeworld/skills"
+	"github.com/Aashishvatwani/homeworld/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Run applies idempotent data migrations at startup. Each step must be safe
+// to run repeatedly against an already-migrated database.
+func Run() {
+	steps := []struct {
+		name string
+		fn   func(ctx context.Context) (int64, error)
+	}{
+		{"backfill user roles", backfillUserRoles},
+		{"create user indexes", createUserIndexes},
+		{"backfill chat messages", backfillChatMessages},
+		{"encrypt payout details", encryptPayoutDetails},
+		{"create audit indexes", createAuditIndexes},
+		{"grant admin roles", grantAdminRoles},
+		{"normalize assignment statuses", normalizeAssignmentStatuses},
+		{"create bid indexes", createBidIndexes},
+		{"create search indexes", createSearchIndexes},
+		{"convert locations to GeoJSON", convertLocationsToGeoJSON},
+		{"create geo indexes", createGeoIndexes},
+		{"create deliverable indexes", createDeliverableIndexes},
+		{"create attachment indexes", createAttachmentIndexes},
+		{"backfill assignment due dates", backfillAssignmentDueDates},
+		{"create deadline indexes", createDeadlineIndexes},
+		{"create cancellation indexes", createCancellationIndexes},
+		{"create dispute indexes", createDisputeIndexes},
+		{"create offer indexes", createOfferIndexes},
+		{"create match log indexes", createMatchLogIndexes},
+		{"seed skill taxonomy", skills.Seed},
+		{"normalize stored skills", normalizeStoredSkills},
+	}
+
+	for _, step := range steps {
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		n, err := step.fn(ctx)
+		cancel()
+		if err != nil {
+			log.Printf("migration %q failed: %v", step.name, err)
+			continue
+		}
+		if n > 0 {
+			log.Printf("migration %q updated %d documents", step.name, n)
+		}
+	}
+}
+
+// backfillUserRoles converts the legacy single "role" string into the
+// "roles" set. Everyone keeps buyer access; former solvers also keep solver.
+func backfillUserRoles(ctx context.Context) (int64, error) {
+	users := config.DB.Collection("users")
+
+	solvers, err := users.UpdateMany(ctx,
+		bson.M{"roles": bson.M{"$exists": false}, "role": "solver"},
+		bson.M{"$set": bson.M{"roles": bson.A{models.RoleBuyer, models.RoleSolver}}, "$unset": bson.M{"role": ""}},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	others, err := users.UpdateMany(ctx,
+		bson.M{"roles": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"roles": bson.A{models.RoleBuyer}}, "$unset": bson.M{"role": ""}},
+	)
+	if err != nil {
+		return solvers.ModifiedCount, err
+	}
+
+	return solvers.ModifiedCount + others.ModifiedCount, nil
+}
+
+// createUserIndexes adds the case-insensitive unique index on users.email
+// and expires used/expired email tokens. Index creation fails if duplicate
+// emails already exist; those must be merged by hand.
+func createUserIndexes(ctx context.Context) (int64, error) {
+	_, err := config.DB.Collection("users").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true).SetCollation(config.EmailCollation).SetName("email_unique_ci"),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = config.DB.Collection("user_tokens").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "tokenHash", Value: 1}}},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return 0, err
+}
+
+// backfillChatMessages replaces the null messages array of older chats with
+// an empty one so messages can be pushed onto it.
+func backfillChatMessages(ctx context.Context) (int64, error) {
+	result, err := config.DB.Collection("chats").UpdateMany(ctx,
+		bson.M{"messages": nil},
+		bson.M{"$set": bson.M{"messages": bson.A{}}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// encryptPayoutDetails encrypts legacy plaintext payout fields and
+// re-encrypts fields sealed with a retired key under the active key. To
+// rotate keys, add the new key to FIELD_ENCRYPTION_KEYS, point
+// FIELD_ENCRYPTION_KEY_ID at it and restart; the old key can be removed once
+// this step reports no further updates.
+func encryptPayoutDetails(ctx context.Context) (int64, error) {
+	users := config.DB.Collection("users")
+	cursor, err := users.Find(ctx,
+		bson.M{"payout": bson.M{"$exists": true}},
+		options.Find().SetProjection(bson.M{"payout": 1}),
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var updated int64
+	for cursor.Next(ctx) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			return updated, err
+		}
+		if !utils.PayoutNeedsReencryption(user.Payout) {
+			continue
+		}
+
+		plain, err := utils.DecryptPayout(user.ID, user.Payout)
+		if err != nil {
+			log.Printf("cannot decrypt payout for user %s: %v", user.ID.Hex(), err)
+			continue
+		}
+		sealed, err := utils.EncryptPayout(user.ID, plain)
+		if err != nil {
+			return updated, err
+		}
+
+		// Match the old values so a concurrent profile update is not overwritten
+		filter := bson.M{"_id": user.ID}
+		set := bson.M{}
+		for field, pair := range map[string][2]string{
+			"accountNumber": {user.Payout.AccountNumber, sealed.AccountNumber},
+			"ifsc":          {user.Payout.IFSC, sealed.IFSC},
+			"upi":           {user.Payout.UPI, sealed.UPI},
+		} {
+			if pair[0] != "" {
+				filter["payout."+field] = pair[0]
+				set["payout."+field] = pair[1]
+			}
+		}
+		result, err := users.UpdateOne(ctx, filter, bson.M{"$set": set})
+		if err != nil {
+			return updated, err
+		}
+		updated += result.ModifiedCount
+	}
+	return updated, cursor.Err()
+}
+
+func createAuditIndexes(ctx context.Context) (int64, error) {
+	return 0, audit.EnsureIndexes(ctx)
+}
+
+// grantAdminRoles adds the admin role to the accounts listed in the
+// comma-separated ADMIN_EMAILS once they have verified their email, so
+// registering an unclaimed address from the list does not grant admin.
+// Roles are only ever added here; revoking admin access means removing the
+// role from the user document.
+func grantAdminRoles(ctx context.Context) (int64, error) {
+	users := config.DB.Collection("users")
+	var granted int64
+	for _, email := range strings.Split(os.Getenv("ADMIN_EMAILS"), ",") {
+		email = strings.TrimSpace(email)
+		if email == "" {
+			continue
+		}
+
+		var user models.User
+		err := users.FindOneAndUpdate(ctx,
+			bson.M{"email": email, "emailVerified": true, "roles": bson.M{"$ne": models.RoleAdmin}},
+			bson.M{"$addToSet": bson.M{"roles": models.RoleAdmin}},
+			options.FindOneAndUpdate().SetCollation(config.EmailCollation).SetReturnDocument(options.After),
+		).Decode(&user)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			return granted, err
+		}
+		granted++
+
+		err = audit.Record(ctx, audit.Event{
+			Action:     audit.ActionRoleGrant,
+			TargetType: audit.TargetUser,
+			TargetID:   user.ID.Hex(),
+			After:      map[string]interface{}{"role": models.RoleAdmin, "roles": user.Roles, "source": "ADMIN_EMAILS"},
+		})
+		if err != nil {
+			log.Printf("failed to audit admin grant for %s: %v", user.ID.Hex(), err)
+		}
+	}
+	return granted, nil
+}
+
+// normalizeAssignmentStatuses rewrites free-form legacy statuses such as
+// "Pending" or "Completed" to lifecycle states, recording the rewrite in each
+// assignment's history. Values that cannot be mapped are logged and left for
+// manual review.
+func normalizeAssignmentStatuses(ctx context.Context) (int64, error) {
+	assignments := config.DB.Collection("assignments")
+
+	// Older documents were written with a null history, which $push rejects
+	nulls, err := assignments.UpdateMany(ctx,
+		bson.M{"statusHistory": bson.M{"$type": "null"}},
+		bson.M{"$set": bson.M{"statusHistory": bson.A{}}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	updated := nulls.ModifiedCount
+
+	statuses, err := assignments.Distinct(ctx, "status", bson.M{})
+	if err != nil {
+		return updated, err
+	}
+	// Distinct skips documents without the field; treat them as empty
+	statuses = append(statuses, nil)
+
+	for _, raw := range statuses {
+		legacy, _ := raw.(string)
+		if raw != nil && models.IsAssignmentStatus(legacy) {
+			continue
+		}
+		status, ok := models.NormalizeAssignmentStatus(legacy)
+		if !ok {
+			log.Printf("assignment status %q has no lifecycle equivalent; left unchanged", legacy)
+			continue
+		}
+
+		filter := bson.M{"status": legacy}
+		if raw == nil {
+			filter = bson.M{"status": bson.M{"$in": bson.A{nil, ""}}}
+		}
+		now := time.Now()
+		result, err := assignments.UpdateMany(ctx, filter, bson.M{
+			"$set": bson.M{"status": status, "statusUpdatedAt": now},
+			"$push": bson.M{"statusHistory": models.AssignmentStatusChange{
+				From:   legacy,
+				To:     status,
+				At:     now,
+				Reason: "normalized legacy status",
+			}},
+		})
+		if err != nil {
+			return updated, err
+		}
+		updated += result.ModifiedCount
+	}
+	return updated, nil
+}
+
+// createBidIndexes allows one pending bid per solver per assignment and
+// supports the lowest-bid lookup used by reverse auctions.
+func createBidIndexes(ctx context.Context) (int64, error) {
+	_, err := config.DB.Collection("bids").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "assignmentId", Value: 1}, {Key: "solverId", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("one_pending_bid_per_solver").
+				SetPartialFilterExpression(bson.M{"status": models.BidPending}),
+		},
+		{Keys: bson.D{{Key: "assignmentId", Value: 1}, {Key: "status", Value: 1}, {Key: "amount", Value: 1}}},
+	})
+	return 0, err
+}
+
+// createSearchIndexes backs the filters, sort orders and text search of the
+// assignment and user listings. Each sort index ends in _id to match the
+// tie-breaker used by cursor pagination.
+func createSearchIndexes(ctx context.Context) (int64, error) {
+	_, err := config.DB.Collection("assignments").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "title", Value: "text"}, {Key: "description", Value: "text"}},
+			Options: options.Index().SetName("assignment_text").SetWeights(bson.D{{Key: "title", Value: 5}, {Key: "description", Value: 1}}),
+		},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "deadline", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "price", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "skills", Value: 1}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = config.DB.Collection("users").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "name", Value: "text"}, {Key: "about", Value: "text"}, {Key: "offering", Value: "text"}},
+			Options: options.Index().SetName("user_text"),
+		},
+		{Keys: bson.D{{Key: "roles", Value: 1}, {Key: "avgRating", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "roles", Value: 1}, {Key: "pricePerJob", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "roles", Value: 1}, {Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "skills", Value: 1}}},
+	})
+	return 0, err
+}
+
+// legacyLocation is the shape locations had before they were stored as
+// GeoJSON, together with the separate lat/lng fields assignments also carried.
+type legacyLocation struct {
+	ID       primitive.ObjectID `bson:"_id"`
+	Location struct {
+		Type      string  `bson:"type"` // "Point" once converted
+		Latitude  float64 `bson:"latitude"`
+		Longitude float64 `bson:"longitude"`
+	} `bson:"location"`
+	Lat float64 `bson:"lat"`
+	Lng float64 `bson:"lng"`
+}
+
+// convertLocationsToGeoJSON rewrites {latitude, longitude} locations on
+// users and assignments as GeoJSON points and drops the assignment lat/lng
+// copies. 0,0 and out-of-range values are treated as "no location", since a
+// 2dsphere index rejects invalid points.
+func convertLocationsToGeoJSON(ctx context.Context) (int64, error) {
+	var updated int64
+	for _, name := range []string{"users", "assignments"} {
+		coll := config.DB.Collection(name)
+		cursor, err := coll.Find(ctx, bson.M{"$or": bson.A{
+			bson.M{"location.latitude": bson.M{"$exists": true}},
+			bson.M{"location": bson.M{"$type": "null"}},
+			bson.M{"lat": bson.M{"$exists": true}},
+			bson.M{"lng": bson.M{"$exists": true}},
+		}}, options.Find().SetProjection(bson.M{"location": 1, "lat": 1, "lng": 1}))
+		if err != nil {
+			return updated, err
+		}
+
+		for cursor.Next(ctx) {
+			var doc legacyLocation
+			if err := cursor.Decode(&doc); err != nil {
+				cursor.Close(ctx)
+				return updated, err
+			}
+
+			lat, lng := doc.Location.Latitude, doc.Location.Longitude
+			if lat == 0 && lng == 0 {
+				lat, lng = doc.Lat, doc.Lng
+			}
+			update := bson.M{"$unset": bson.M{"lat": "", "lng": ""}}
+			switch {
+			case doc.Location.Type == "Point":
+				// Already converted; only the lat/lng copies are left
+			case (lat != 0 || lng != 0) && utils.ValidateCoordinates(lat, lng):
+				update["$set"] = bson.M{"location": models.NewGeoPoint(lat, lng)}
+			default:
+				update["$unset"].(bson.M)["location"] = ""
+			}
+
+			result, err := coll.UpdateOne(ctx, bson.M{"_id": doc.ID}, update)
+			if err != nil {
+				cursor.Close(ctx)
+				return updated, err
+			}
+			updated += result.ModifiedCount
+		}
+		if err := cursor.Err(); err != nil {
+			cursor.Close(ctx)
+			return updated, err
+		}
+		cursor.Close(ctx)
+	}
+	return updated, nil
+}
+
+// createGeoIndexes adds the 2dsphere indexes used by $geoNear. It must run
+// after convertLocationsToGeoJSON; legacy locations would fail to index.
+func createGeoIndexes(ctx context.Context) (int64, error) {
+	for _, name := range []string{"users", "assignments"} {
+		_, err := config.DB.Collection(name).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys: bson.D{{Key: "location", Value: "2dsphere"}},
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return 0, nil
+}
+
+// createDeliverableIndexes numbers deliverables uniquely per assignment, so
+// two concurrent submissions cannot claim the same version.
+func createDeliverableIndexes(ctx context.Context) (int64, error) {
+	_, err := config.DB.Collection("deliverables").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "assignmentId", Value: 1}, {Key: "version", Value: -1}},
+		Options: options.Index().SetUnique(true).SetName("one_deliverable_per_version"),
+	})
+	return 0, err
+}
+
+// createAttachmentIndexes supports looking up the files of an assignment or
+// chat and resolving the attachments sent with a message or deliverable.
+func createAttachmentIndexes(ctx context.Context) (int64, error) {
+	_, err := config.DB.Collection("attachments").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "parentType", Value: 1}, {Key: "parentId", Value: 1}, {Key: "createdAt", Value: -1}},
+	})
+	return 0, err
+}
+
+// backfillAssignmentDueDates gives assignments already in progress the
+// deadline the scheduler enforces. Those already past it are marked overdue
+// straight away so solvers are not penalized retroactively.
+func backfillAssignmentDueDates(ctx context.Context) (int64, error) {
+	assignments := config.DB.Collection("assignments")
+	active := bson.A{models.AssignmentAccepted, models.AssignmentInProgress, models.AssignmentRevisionRequested}
+	now := time.Now()
+
+	past, err := assignments.UpdateMany(ctx,
+		bson.M{"status": bson.M{"$in": active}, "dueAt": bson.M{"$exists": false}, "deadline": bson.M{"$gt": time.Time{}, "$lte": now}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{"dueAt": "$deadline", "overdueAt": now}}}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	upcoming, err := assignments.UpdateMany(ctx,
+		bson.M{"status": bson.M{"$in": active}, "dueAt": bson.M{"$exists": false}, "deadline": bson.M{"$gt": now}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{"dueAt": "$deadline"}}}},
+	)
+	if err != nil {
+		return past.ModifiedCount, err
+	}
+	return past.ModifiedCount + upcoming.ModifiedCount, nil
+}
+
+// createDeadlineIndexes backs the scheduler's reminder and overdue scans.
+func createDeadlineIndexes(ctx context.Context) (int64, error) {
+	_, err := config.DB.Collection("assignments").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "dueAt", Value: 1}},
+	})
+	return 0, err
+}
+
+// createCancellationIndexes allows one open cancellation per assignment.
+func createCancellationIndexes(ctx context.Context) (int64, error) {
+	_, err := config.DB.Collection("cancellations").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "assignmentId", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("one_open_cancellation_per_assignment").
+			SetPartialFilterExpression(bson.M{"open": true}),
+	})
+	return 0, err
+}
+
+// createDisputeIndexes allows one open dispute per assignment and backs the
+// admin queue, which is ordered by ruling deadline, and the SLA scans.
+func createDisputeIndexes(ctx context.Context) (int64, error) {
+	_, err := config.DB.Collection("disputes").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "assignmentId", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("one_open_dispute_per_assignment").
+				SetPartialFilterExpression(bson.M{"open": true}),
+		},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "resolveBy", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "evidenceDueAt", Value: 1}}},
+	})
+	return 0, err
+}
+
+// createOfferIndexes allows one pending offer per solver per assignment and
+// backs the offer listings, the expiry job and the auto-assign cascade.
+func createOfferIndexes(ctx context.Context) (int64, error) {
+	_, err := config.DB.Collection("offers").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "assignmentId", Value: 1}, {Key: "solverId", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("one_pending_offer_per_solver").
+				SetPartialFilterExpression(bson.M{"status": models.OfferPending}),
+		},
+		{Keys: bson.D{{Key: "assignmentId", Value: 1}, {Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "expiresAt", Value: 1}}},
+		{Keys: bson.D{{Key: "solverId", Value: 1}, {Key: "status", Value: 1}, {Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	_, err = config.DB.Collection("assignments").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "autoAssign.active", Value: 1}},
+		Options: options.Index().SetPartialFilterExpression(bson.M{"autoAssign.active": true}),
+	})
+	return 0, err
+}
+
+// createMatchLogIndexes keeps one outcome per assignment, solver and event
+// and backs the time-windowed scans of the impression log.
+func createMatchLogIndexes(ctx context.Context) (int64, error) {
+	_, err := matching.ImpressionCollection().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "createdAt", Value: 1}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	_, err = matching.OutcomeCollection().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "assignmentId", Value: 1}, {Key: "solverId", Value: 1}, {Key: "event", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return 0, err
+}
+
+// normalizeStoredSkills rewrites user and assignment skills to their
+// canonical taxonomy names, e.g. "ml" to "Machine Learning". Entries
+// outside the taxonomy are kept as they are.
+func normalizeStoredSkills(ctx context.Context) (int64, error) {
+	if err := skills.Reload(ctx); err != nil {
+		return 0, err
+	}
+
+	var updated int64
+	for _, name := range []string{"users", "assignments"} {
+		coll := config.DB.Collection(name)
+		cursor, err := coll.Find(ctx, bson.M{"skills.0": bson.M{"$exists": true}},
+			options.Find().SetProjection(bson.M{"skills": 1}))
+		if err != nil {
+			return updated, err
+		}
+		var docs []struct {
+			ID     primitive.ObjectID `bson:"_id"`
+			Skills []string           `bson:"skills"`
+		}
+		if err := cursor.All(ctx, &docs); err != nil {
+			return updated, err
+		}
+
+		for _, doc := range docs {
+			canonical := skills.Canonicalize(doc.Skills)
+			if slices.Equal(canonical, doc.Skills) {
+				continue
+			}
+			result, err := coll.UpdateOne(ctx, bson.M{"_id": doc.ID}, bson.M{"$set": bson.M{"skills": canonical}})
+			if err != nil {
+				return updated, err
+			}
+			updated += result.ModifiedCount
+		}
+	}
+	return updated, nil
+}
@@ -13,18 +13,56 @@ type Assignment struct {
 	Description string             `bson:"description" json:"description"`
 	Pages       int                `bson:"pages" json:"pages"`
 	Urgency     string             `bson:"urgency" json:"urgency"`
-	Location    LocationCoords     `bson:"location" json:"location"`
+	Location    *GeoPoint          `bson:"location,omitempty" json:"location,omitempty"`
 	Price       float64            `bson:"price" json:"price"`
-	Status      string             `bson:"status" json:"status"`
+	Status      string             `bson:"status" json:"status"` // one of the Assignment* lifecycle states
 	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
-	Lat         float64            `bson:"lat" json:"lat"`
-	Lng         float64            `bson:"lng" json:"lng"`
 	Skills      []string           `bson:"skills" json:"skills"`
 	Deadline    time.Time          `bson:"deadline" json:"deadline"`
-	BidAmount   float64            `bson:"bidAmount" json:"bidAmount"`
+	BidAmount   float64            `bson:"bidAmount" json:"bidAmount"` // amount of the accepted bid
+
+	// SolverID is the solver the buyer accepted; empty until then
+	SolverID        primitive.ObjectID       `bson:"solverId,omitempty" json:"solverId,omitempty"`
+	StatusUpdatedAt time.Time                `bson:"statusUpdatedAt" json:"statusUpdatedAt"`
+	StatusHistory   []AssignmentStatusChange `bson:"statusHistory" json:"statusHistory"`
+	RevisionRounds  int                      `bson:"revisionRounds" json:"revisionRounds"` // revisions requested so far
+
+	Bidding       BiddingSettings    `bson:"bidding" json:"bidding"`
+	AcceptedBidID primitive.ObjectID `bson:"acceptedBidId,omitempty" json:"acceptedBidId,omitempty"`
+
+	// Attachments are the brief files the buyer uploaded
+	Attachments []AttachmentRef `bson:"attachments,omitempty" json:"attachments,omitempty"`
+
+	// DueAt is the deadline the accepted solver is held to: the deadline
+	// agreed in chat, or Deadline when none was negotiated
+	DueAt         time.Time `bson:"dueAt,omitempty" json:"dueAt,omitempty"`
+	RemindersSent []string  `bson:"remindersSent,omitempty" json:"-"`               // deadline reminders already sent
+	OverdueAt     time.Time `bson:"overdueAt,omitempty" json:"overdueAt,omitempty"` // when DueAt passed without a delivery
+
+	// Milestones split a large assignment into separately paid stages; when
+	// set, their amounts add up to the agreed price
+	Milestones []Milestone `bson:"milestones,omitempty" json:"milestones,omitempty"`
+
+	// AutoAssign is set when the buyer had the platform offer the assignment
+	// to solvers; only urgent assignments qualify
+	AutoAssign *AutoAssignSettings `bson:"autoAssign,omitempty" json:"autoAssign,omitempty"`
+
+	// Rating is the buyer's review of the solver once the work is completed
+	Rating *AssignmentRating `bson:"rating,omitempty" json:"rating,omitempty"`
 }
 
-type LocationCoords struct {
-	Latitude  float64 `json:"latitude" bson:"latitude"`
-	Longitude float64 `json:"longitude" bson:"longitude"`
+// AssignmentRating is the buyer's 1-5 rating of a completed assignment.
+type AssignmentRating struct {
+	Stars   int       `bson:"stars" json:"stars"`
+	Comment string    `bson:"comment,omitempty" json:"comment,omitempty"`
+	RatedAt time.Time `bson:"ratedAt" json:"ratedAt"`
+}
+
+// UrgencyHigh marks an urgent assignment.
+const UrgencyHigh = "High"
+
+// BiddingSettings controls how solvers bid on an assignment.
+type BiddingSettings struct {
+	Mode     string    `bson:"mode,omitempty" json:"mode,omitempty"`         // BiddingOpen (default) or BiddingReverseAuction
+	ClosesAt time.Time `bson:"closesAt,omitempty" json:"closesAt,omitempty"` // required for reverse auctions
 }
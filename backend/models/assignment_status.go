@@ -0,0 +1,138 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Assignment lifecycle states
+const (
+	AssignmentDraft             = "draft"
+	AssignmentPosted            = "posted"
+	AssignmentMatched           = "matched"
+	AssignmentAccepted          = "accepted"
+	AssignmentInProgress        = "in_progress"
+	AssignmentDelivered         = "delivered"
+	AssignmentRevisionRequested = "revision_requested"
+	AssignmentCompleted         = "completed"
+	AssignmentCancelled         = "cancelled"
+	AssignmentDisputed          = "disputed"
+)
+
+// assignmentTransitions lists the states each state may move to. Completed
+// and cancelled are terminal.
+var assignmentTransitions = map[string][]string{
+	AssignmentDraft:             {AssignmentPosted, AssignmentCancelled},
+	AssignmentPosted:            {AssignmentMatched, AssignmentAccepted, AssignmentCancelled},
+	AssignmentMatched:           {AssignmentPosted, AssignmentAccepted, AssignmentCancelled},
+	AssignmentAccepted:          {AssignmentInProgress, AssignmentCancelled, AssignmentDisputed},
+	AssignmentInProgress:        {AssignmentDelivered, AssignmentCancelled, AssignmentDisputed},
+	AssignmentDelivered:         {AssignmentCompleted, AssignmentRevisionRequested, AssignmentDisputed},
+	AssignmentRevisionRequested: {AssignmentDelivered, AssignmentCancelled, AssignmentDisputed},
+	AssignmentDisputed:          {AssignmentInProgress, AssignmentCompleted, AssignmentCancelled},
+	AssignmentCompleted:         {},
+	AssignmentCancelled:         {},
+}
+
+// AssignmentStatusChange is one entry in an assignment's status history.
+type AssignmentStatusChange struct {
+	From    string             `bson:"from" json:"from"` // empty for the initial state
+	To      string             `bson:"to" json:"to"`
+	At      time.Time          `bson:"at" json:"at"`
+	ActorID primitive.ObjectID `bson:"actorId,omitempty" json:"actorId,omitempty"` // empty for system changes
+	Reason  string             `bson:"reason,omitempty" json:"reason,omitempty"`
+}
+
+// IllegalTransitionError is returned when a status change is not allowed
+// from the assignment's current state.
+type IllegalTransitionError struct {
+	From string
+	To   string
+}
+
+func (e *IllegalTransitionError) Error() string {
+	return fmt.Sprintf("assignment cannot move from %q to %q", e.From, e.To)
+}
+
+// IsAssignmentStatus reports whether s is one of the lifecycle states.
+func IsAssignmentStatus(s string) bool {
+	_, ok := assignmentTransitions[s]
+	return ok
+}
+
+// CanTransitionAssignment reports whether an assignment in state from may move to state to.
+func CanTransitionAssignment(from, to string) bool {
+	for _, next := range assignmentTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// NextAssignmentStatuses returns the states reachable from the given state.
+func NextAssignmentStatuses(from string) []string {
+	return append([]string(nil), assignmentTransitions[from]...)
+}
+
+// IsTerminal reports whether the assignment can no longer change state.
+func (a Assignment) IsTerminal() bool {
+	return a.Status == AssignmentCompleted || a.Status == AssignmentCancelled
+}
+
+// NewStatusChange validates a move from the assignment's current state to
+// to and returns the history entry to record for it.
+func (a Assignment) NewStatusChange(to string, actorID primitive.ObjectID, reason string) (AssignmentStatusChange, error) {
+	if !CanTransitionAssignment(a.Status, to) {
+		return AssignmentStatusChange{}, &IllegalTransitionError{From: a.Status, To: to}
+	}
+	return AssignmentStatusChange{
+		From:    a.Status,
+		To:      to,
+		At:      time.Now(),
+		ActorID: actorID,
+		Reason:  reason,
+	}, nil
+}
+
+// InitStatus sets the initial state of a new assignment and starts its
+// history. Only draft and posted are valid starting states.
+func (a *Assignment) InitStatus(status string, actorID primitive.ObjectID) error {
+	if status != AssignmentDraft && status != AssignmentPosted {
+		return fmt.Errorf("new assignments must be %q or %q", AssignmentDraft, AssignmentPosted)
+	}
+	now := time.Now()
+	a.Status = status
+	a.StatusUpdatedAt = now
+	a.StatusHistory = []AssignmentStatusChange{{To: status, At: now, ActorID: actorID}}
+	return nil
+}
+
+// legacyAssignmentStatuses maps status strings written before the state
+// machine existed, lower-cased, to lifecycle states.
+var legacyAssignmentStatuses = map[string]string{
+	"":            AssignmentPosted,
+	"pending":     AssignmentPosted,
+	"open":        AssignmentPosted,
+	"assigned":    AssignmentAccepted,
+	"in progress": AssignmentInProgress,
+	"in-progress": AssignmentInProgress,
+	"inprogress":  AssignmentInProgress,
+	"submitted":   AssignmentDelivered,
+	"done":        AssignmentCompleted,
+	"canceled":    AssignmentCancelled,
+}
+
+// NormalizeAssignmentStatus maps a legacy or differently-cased status to a
+// lifecycle state. It reports false when the value cannot be mapped.
+func NormalizeAssignmentStatus(s string) (string, bool) {
+	key := strings.ToLower(strings.TrimSpace(s))
+	if IsAssignmentStatus(key) {
+		return key, true
+	}
+	status, ok := legacyAssignmentStatuses[key]
+	return status, ok
+}
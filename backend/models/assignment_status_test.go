@@ -0,0 +1,91 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAssignmentTransitions(t *testing.T) {
+	cases := []struct {
+		from, to string
+		legal    bool
+	}{
+		{AssignmentDraft, AssignmentPosted, true},
+		{AssignmentDraft, AssignmentAccepted, false},
+		{AssignmentPosted, AssignmentMatched, true},
+		{AssignmentPosted, AssignmentAccepted, true},
+		{AssignmentPosted, AssignmentDelivered, false},
+		{AssignmentMatched, AssignmentPosted, true},
+		{AssignmentAccepted, AssignmentInProgress, true},
+		{AssignmentAccepted, AssignmentCompleted, false},
+		{AssignmentInProgress, AssignmentDelivered, true},
+		{AssignmentInProgress, AssignmentPosted, false},
+		{AssignmentDelivered, AssignmentCompleted, true},
+		{AssignmentDelivered, AssignmentRevisionRequested, true},
+		{AssignmentDelivered, AssignmentCancelled, false},
+		{AssignmentRevisionRequested, AssignmentDelivered, true},
+		{AssignmentDisputed, AssignmentInProgress, true},
+		{AssignmentDisputed, AssignmentCompleted, true},
+		{AssignmentDisputed, AssignmentCancelled, true},
+		{AssignmentDisputed, AssignmentDelivered, false},
+		{AssignmentCompleted, AssignmentCancelled, false},
+		{AssignmentCancelled, AssignmentPosted, false},
+		{AssignmentPosted, AssignmentPosted, false},
+		{"unknown", AssignmentPosted, false},
+		{AssignmentPosted, "unknown", false},
+	}
+	for _, tc := range cases {
+		if got := CanTransitionAssignment(tc.from, tc.to); got != tc.legal {
+			t.Errorf("CanTransitionAssignment(%q, %q) = %v, want %v", tc.from, tc.to, got, tc.legal)
+		}
+
+		actor := primitive.NewObjectID()
+		change, err := Assignment{Status: tc.from}.NewStatusChange(tc.to, actor, "test")
+		if !tc.legal {
+			var illegal *IllegalTransitionError
+			if !errors.As(err, &illegal) || illegal.From != tc.from || illegal.To != tc.to {
+				t.Errorf("NewStatusChange(%q -> %q) error = %v, want IllegalTransitionError", tc.from, tc.to, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("NewStatusChange(%q -> %q) = %v", tc.from, tc.to, err)
+			continue
+		}
+		if change.From != tc.from || change.To != tc.to || change.ActorID != actor || change.At.IsZero() {
+			t.Errorf("NewStatusChange(%q -> %q) = %+v", tc.from, tc.to, change)
+		}
+	}
+}
+
+func TestTerminalStatesHaveNoExits(t *testing.T) {
+	for _, status := range []string{AssignmentCompleted, AssignmentCancelled} {
+		if next := NextAssignmentStatuses(status); len(next) != 0 {
+			t.Errorf("%s can move to %v, want terminal", status, next)
+		}
+		if !(Assignment{Status: status}).IsTerminal() {
+			t.Errorf("%s is not terminal", status)
+		}
+	}
+}
+
+func TestNormalizeAssignmentStatus(t *testing.T) {
+	cases := map[string]string{
+		"Posted":      AssignmentPosted,
+		"":            AssignmentPosted,
+		"open":        AssignmentPosted,
+		"In Progress": AssignmentInProgress,
+		"canceled":    AssignmentCancelled,
+		" done ":      AssignmentCompleted,
+	}
+	for in, want := range cases {
+		if got, ok := NormalizeAssignmentStatus(in); !ok || got != want {
+			t.Errorf("NormalizeAssignmentStatus(%q) = %q, %v; want %q", in, got, ok, want)
+		}
+	}
+	if _, ok := NormalizeAssignmentStatus("archived"); ok {
+		t.Errorf("NormalizeAssignmentStatus(archived) should fail")
+	}
+}
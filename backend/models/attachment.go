@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Attachment is an uploaded file. Access follows the assignment, chat or
+// dispute it was uploaded to; the body lives in the configured storage
+// backend under StorageKey.
+type Attachment struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UploaderID  primitive.ObjectID `bson:"uploaderId" json:"uploaderId"`
+	ParentType  string             `bson:"parentType" json:"parentType"` // "assignment", "chat" or "dispute"
+	ParentID    primitive.ObjectID `bson:"parentId" json:"parentId"`
+	Purpose     string             `bson:"purpose" json:"purpose"` // one of the Attachment* purposes
+	Name        string             `bson:"name" json:"name"`
+	ContentType string             `bson:"contentType" json:"contentType"`
+	Size        int64              `bson:"size" json:"size"`
+	SHA256      string             `bson:"sha256" json:"sha256"` // hex digest of the content
+	StorageKey  string             `bson:"storageKey" json:"-"`
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// AttachmentRef is the copy of an attachment's metadata kept on the
+// assignment, message or deliverable that references it.
+type AttachmentRef struct {
+	ID          primitive.ObjectID `bson:"id" json:"id"`
+	Name        string             `bson:"name" json:"name"`
+	ContentType string             `bson:"contentType" json:"contentType"`
+	Size        int64              `bson:"size" json:"size"`
+	SHA256      string             `bson:"sha256" json:"sha256"`
+}
+
+// Attachment parent types
+const (
+	AttachmentParentAssignment = "assignment"
+	AttachmentParentChat       = "chat"
+	AttachmentParentDispute    = "dispute"
+)
+
+// Attachment purposes
+const (
+	AttachmentBrief       = "brief"       // buyer's material on the assignment
+	AttachmentDeliverable = "deliverable" // solver's work, referenced from a deliverable
+	AttachmentChat        = "chat"        // sent with a chat message
+	AttachmentEvidence    = "evidence"    // backs a statement in a dispute
+)
+
+// Ref returns the metadata stored on referencing records.
+func (a Attachment) Ref() AttachmentRef {
+	return AttachmentRef{ID: a.ID, Name: a.Name, ContentType: a.ContentType, Size: a.Size, SHA256: a.SHA256}
+}
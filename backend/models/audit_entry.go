@@ -0,0 +1,30 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditEntry is one record in the append-only audit log. Entries form a hash
+// chain: Hash covers every other field plus PrevHash, so editing, deleting
+// or reordering an entry breaks verification of everything after it.
+type AuditEntry struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Seq        int64              `bson:"seq" json:"seq"`
+	Action     string             `bson:"action" json:"action"`
+	ActorID    primitive.ObjectID `bson:"actorId,omitempty" json:"actorId,omitempty"` // empty for system actions
+	ActorRole  string             `bson:"actorRole,omitempty" json:"actorRole,omitempty"`
+	TargetType string             `bson:"targetType" json:"targetType"`
+	TargetID   string             `bson:"targetId" json:"targetId"`
+	// Before and After are JSON snapshots, stored as bytes so the hashed
+	// representation round-trips exactly
+	Before    json.RawMessage `bson:"before,omitempty" json:"before,omitempty"`
+	After     json.RawMessage `bson:"after,omitempty" json:"after,omitempty"`
+	IP        string          `bson:"ip,omitempty" json:"ip,omitempty"`
+	UserAgent string          `bson:"userAgent,omitempty" json:"userAgent,omitempty"`
+	CreatedAt time.Time       `bson:"createdAt" json:"createdAt"`
+	PrevHash  string          `bson:"prevHash" json:"prevHash"`
+	Hash      string          `bson:"hash" json:"hash"`
+}
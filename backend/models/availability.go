@@ -0,0 +1,129 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Availability is when a solver takes on work. A solver without one, or
+// without working hours, counts as available at any time.
+type Availability struct {
+	TimeZone string         `bson:"timeZone" json:"timeZone"` // IANA name, e.g. "Asia/Kolkata"
+	Weekly   []WorkingHours `bson:"weekly" json:"weekly"`
+	// MaxConcurrent caps the solver's accepted and in-progress assignments;
+	// 0 means no cap
+	MaxConcurrent int          `bson:"maxConcurrent" json:"maxConcurrent"`
+	Away          []AwayPeriod `bson:"away" json:"away"`
+	UpdatedAt     time.Time    `bson:"updatedAt" json:"updatedAt"`
+}
+
+// WorkingHours is a weekly window in the solver's time zone. An End at or
+// before Start runs past midnight into the next day.
+type WorkingHours struct {
+	Day   time.Weekday `bson:"day" json:"day"`     // 0 = Sunday
+	Start string       `bson:"start" json:"start"` // "09:00"
+	End   string       `bson:"end" json:"end"`     // "17:30"; "24:00" for end of day
+}
+
+// AwayPeriod is a stretch, such as a holiday, when the solver takes no work.
+type AwayPeriod struct {
+	From  time.Time `bson:"from" json:"from"`
+	Until time.Time `bson:"until" json:"until"`
+}
+
+// ParseClock converts "HH:MM" to minutes after midnight. "24:00" is
+// accepted as the end of the day.
+func ParseClock(s string) (int, error) {
+	if s == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("time %q must be HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Location is the solver's time zone, UTC when unset or unknown.
+func (a Availability) Location() *time.Location {
+	if loc, err := time.LoadLocation(a.TimeZone); err == nil && a.TimeZone != "" {
+		return loc
+	}
+	return time.UTC
+}
+
+// AwayAt reports whether t falls in an away period.
+func (a Availability) AwayAt(t time.Time) bool {
+	for _, p := range a.Away {
+		if !t.Before(p.From) && t.Before(p.Until) {
+			return true
+		}
+	}
+	return false
+}
+
+// AwayBetween reports whether any away period overlaps [from, to).
+func (a Availability) AwayBetween(from, to time.Time) bool {
+	for _, p := range a.Away {
+		if p.From.Before(to) && p.Until.After(from) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextWorkingTime is the earliest moment from t on that falls inside the
+// working hours, ignoring away periods. It is t itself when the solver is
+// working then or has no working hours, and the zero time when no window
+// parses.
+func (a Availability) NextWorkingTime(t time.Time) time.Time {
+	if len(a.Weekly) == 0 {
+		return t
+	}
+	loc := a.Location()
+	local := t.In(loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+
+	var next time.Time
+	// Start a day back to catch windows that began yesterday and run past midnight
+	for d := -1; d <= 7; d++ {
+		day := midnight.AddDate(0, 0, d)
+		for _, w := range a.Weekly {
+			if w.Day != day.Weekday() {
+				continue
+			}
+			start, err1 := ParseClock(w.Start)
+			end, err2 := ParseClock(w.End)
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			if end <= start {
+				end += 24 * 60
+			}
+			from := time.Date(day.Year(), day.Month(), day.Day(), 0, start, 0, 0, loc)
+			until := time.Date(day.Year(), day.Month(), day.Day(), 0, end, 0, 0, loc)
+			if !until.After(t) {
+				continue
+			}
+			candidate := from
+			if candidate.Before(t) {
+				candidate = t
+			}
+			if next.IsZero() || candidate.Before(next) {
+				next = candidate
+			}
+		}
+	}
+	return next
+}
+
+// WorkingAt reports whether t falls inside the working hours.
+func (a Availability) WorkingAt(t time.Time) bool {
+	return a.NextWorkingTime(t).Equal(t)
+}
+
+// HasCapacity reports whether a solver with active assignments in
+// progress may take on another.
+func (a Availability) HasCapacity(active int) bool {
+	return a.MaxConcurrent <= 0 || active < a.MaxConcurrent
+}
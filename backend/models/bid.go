@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Bid is a solver's offer to complete a posted assignment.
+type Bid struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	AssignmentID primitive.ObjectID `bson:"assignmentId" json:"assignmentId"`
+	SolverID     primitive.ObjectID `bson:"solverId" json:"solverId"`
+	Amount       float64            `bson:"amount" json:"amount"`
+	ETAHours     int                `bson:"etaHours" json:"etaHours"` // promised delivery time after acceptance
+	CoverNote    string             `bson:"coverNote" json:"coverNote"`
+	Status       string             `bson:"status" json:"status"` // one of the Bid* statuses
+	// Revisions holds the previous terms each time the solver revises the bid
+	Revisions []BidRevision `bson:"revisions,omitempty" json:"revisions,omitempty"`
+	CreatedAt time.Time     `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time     `bson:"updatedAt" json:"updatedAt"`
+	DecidedAt time.Time     `bson:"decidedAt,omitempty" json:"decidedAt,omitempty"` // accepted, rejected or withdrawn
+}
+
+// BidRevision records the terms a bid had before it was revised.
+type BidRevision struct {
+	Amount    float64   `bson:"amount" json:"amount"`
+	ETAHours  int       `bson:"etaHours" json:"etaHours"`
+	CoverNote string    `bson:"coverNote" json:"coverNote"`
+	RevisedAt time.Time `bson:"revisedAt" json:"revisedAt"`
+}
+
+// Bid statuses
+const (
+	BidPending   = "pending"
+	BidAccepted  = "accepted"
+	BidRejected  = "rejected"
+	BidWithdrawn = "withdrawn"
+)
+
+// Assignment bidding modes
+const (
+	// BiddingOpen takes sealed bids; the buyer may accept one at any time.
+	BiddingOpen = "open"
+	// BiddingReverseAuction shows solvers the lowest bid, requires each new
+	// bid to undercut it, and lets the buyer accept only after bidding closes.
+	BiddingReverseAuction = "reverse_auction"
+)
+
+// BiddingMode returns the assignment's bidding mode, defaulting to open.
+func (a Assignment) BiddingMode() string {
+	if a.Bidding.Mode == "" {
+		return BiddingOpen
+	}
+	return a.Bidding.Mode
+}
+
+// BiddingOpenAt reports whether solvers may place or revise bids at t.
+func (a Assignment) BiddingOpenAt(t time.Time) bool {
+	if a.Status != AssignmentPosted && a.Status != AssignmentMatched {
+		return false
+	}
+	return a.Bidding.ClosesAt.IsZero() || t.Before(a.Bidding.ClosesAt)
+}
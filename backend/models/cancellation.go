@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Cancellation is a buyer's request to cancel an assignment the solver has
+// already been paid into escrow for. The proposed split only takes effect
+// once the solver accepts it, or the response window lapses.
+type Cancellation struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	AssignmentID primitive.ObjectID `bson:"assignmentId" json:"assignmentId"`
+	BuyerID      primitive.ObjectID `bson:"buyerId" json:"buyerId"`
+	SolverID     primitive.ObjectID `bson:"solverId" json:"solverId"`
+	PaymentID    primitive.ObjectID `bson:"paymentId" json:"paymentId"`
+	Reason       string             `bson:"reason" json:"reason"`
+	Rating       *int               `bson:"rating,omitempty" json:"rating,omitempty"` // buyer's 1-5 rating of the work so far
+	DelayDays    int                `bson:"delayDays" json:"delayDays"`               // whole days past DueAt when requested
+	Split        RefundSplit        `bson:"split" json:"split"`
+	Status       string             `bson:"status" json:"status"` // one of the Cancellation* statuses
+	// Open is set until the cancellation is settled; a unique index on it
+	// allows one open cancellation per assignment
+	Open bool `bson:"open,omitempty" json:"open"`
+	// SolverComment is the solver's explanation when contesting the split
+	SolverComment string    `bson:"solverComment,omitempty" json:"solverComment,omitempty"`
+	RespondBy     time.Time `bson:"respondBy" json:"respondBy"` // the buyer may execute the split after this
+	CreatedAt     time.Time `bson:"createdAt" json:"createdAt"`
+	RespondedAt   time.Time `bson:"respondedAt,omitempty" json:"respondedAt,omitempty"`
+	ExecutedAt    time.Time `bson:"executedAt,omitempty" json:"executedAt,omitempty"`
+
+	// Settlement progress; each step is recorded as it completes so a failed
+	// execution can be retried without repeating the steps that succeeded
+	RefundID  string `bson:"refundId,omitempty" json:"refundId,omitempty"`
+	PayoutID  string `bson:"payoutId,omitempty" json:"payoutId,omitempty"`
+	LastError string `bson:"lastError,omitempty" json:"lastError,omitempty"`
+}
+
+// RefundSplit divides a payment between the buyer, the solver and the
+// platform. RefundPercent applies to the amount left after the platform fee.
+type RefundSplit struct {
+	Source         string  `bson:"source" json:"source"` // RefundSourceModel or RefundSourceRules
+	RefundPercent  float64 `bson:"refundPercent" json:"refundPercent"`
+	PlatformFee    float64 `bson:"platformFee" json:"platformFee"`
+	CustomerRefund float64 `bson:"customerRefund" json:"customerRefund"`
+	SolverAmount   float64 `bson:"solverAmount" json:"solverAmount"`
+}
+
+// Refund split sources
+const (
+	RefundSourceModel = "model" // suggested by the refund prediction service
+	RefundSourceRules = "rules" // fixed rules, used when the service is unavailable
+)
+
+// Cancellation statuses
+const (
+	CancellationProposed  = "proposed"
+	CancellationExecuting = "executing"
+	CancellationExecuted  = "executed"
+	CancellationFailed    = "failed" // execution stopped part way; it can be retried
+	CancellationContested = "contested"
+)
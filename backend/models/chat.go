@@ -25,4 +25,7 @@ type Message struct {
 	SenderRole string             `bson:"senderRole" json:"senderRole"` // "buyer" or "solver"
 	Content    string             `bson:"content" json:"content"`
 	Timestamp  time.Time          `bson:"timestamp" json:"timestamp"`
+
+	// Attachments are files sent with the message
+	Attachments []AttachmentRef `bson:"attachments,omitempty" json:"attachments,omitempty"`
 }
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Deliverable is one submission of work for an assignment. Every submission
+// is kept; Version counts up from 1 per assignment, across its milestones.
+type Deliverable struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	AssignmentID primitive.ObjectID `bson:"assignmentId" json:"assignmentId"`
+	SolverID     primitive.ObjectID `bson:"solverId" json:"solverId"`
+	Version      int                `bson:"version" json:"version"`
+	Note         string             `bson:"note" json:"note"`
+	Files        []DeliverableFile  `bson:"files" json:"files"`             // external links
+	Attachments  []AttachmentRef    `bson:"attachments" json:"attachments"` // uploaded files
+	Status       string             `bson:"status" json:"status"`           // one of the Deliverable* statuses
+	// ReviewComment is the buyer's feedback when requesting a revision
+	ReviewComment string    `bson:"reviewComment,omitempty" json:"reviewComment,omitempty"`
+	SubmittedAt   time.Time `bson:"submittedAt" json:"submittedAt"`
+	ReviewedAt    time.Time `bson:"reviewedAt,omitempty" json:"reviewedAt,omitempty"`
+	// MilestoneID is the milestone this delivers, on milestone assignments
+	MilestoneID primitive.ObjectID `bson:"milestoneId,omitempty" json:"milestoneId,omitempty"`
+}
+
+// DeliverableFile references a file handed in with a deliverable.
+type DeliverableFile struct {
+	Name        string `bson:"name" json:"name"`
+	URL         string `bson:"url" json:"url"`
+	ContentType string `bson:"contentType,omitempty" json:"contentType,omitempty"`
+	Size        int64  `bson:"size,omitempty" json:"size,omitempty"`
+}
+
+// Deliverable statuses
+const (
+	DeliverableSubmitted         = "submitted"
+	DeliverableAccepted          = "accepted"
+	DeliverableRevisionRequested = "revision_requested"
+)
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Dispute is a disagreement between the buyer and solver over an assignment
+// whose payment is held in escrow. Opening one freezes the payment until an
+// admin rules on it.
+type Dispute struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	AssignmentID primitive.ObjectID `bson:"assignmentId" json:"assignmentId"`
+	PaymentID    primitive.ObjectID `bson:"paymentId" json:"paymentId"`
+	BuyerID      primitive.ObjectID `bson:"buyerId" json:"buyerId"`
+	SolverID     primitive.ObjectID `bson:"solverId" json:"solverId"`
+	OpenedBy     primitive.ObjectID `bson:"openedBy" json:"openedBy"`
+	Reason       string             `bson:"reason" json:"reason"`
+	// CancellationID is set when the dispute was opened by contesting a cancellation
+	CancellationID primitive.ObjectID `bson:"cancellationId,omitempty" json:"cancellationId,omitempty"`
+	Status         string             `bson:"status" json:"status"` // one of the Dispute* statuses
+	// Open is set until the ruling is executed; a unique index on it allows
+	// one open dispute per assignment
+	Open       bool               `bson:"open,omitempty" json:"open"`
+	AssignedTo primitive.ObjectID `bson:"assignedTo,omitempty" json:"assignedTo,omitempty"` // admin reviewing it
+	Evidence   []DisputeEvidence  `bson:"evidence" json:"evidence"`
+	Ruling     *DisputeRuling     `bson:"ruling,omitempty" json:"ruling,omitempty"`
+
+	// SLA timers: evidence is accepted until EvidenceDueAt and a ruling is
+	// due by ResolveBy
+	EvidenceDueAt    time.Time `bson:"evidenceDueAt" json:"evidenceDueAt"`
+	ResolveBy        time.Time `bson:"resolveBy" json:"resolveBy"`
+	EvidenceClosedAt time.Time `bson:"evidenceClosedAt,omitempty" json:"evidenceClosedAt,omitempty"`
+	SLABreachedAt    time.Time `bson:"slaBreachedAt,omitempty" json:"slaBreachedAt,omitempty"`
+	CreatedAt        time.Time `bson:"createdAt" json:"createdAt"`
+	ResolvedAt       time.Time `bson:"resolvedAt,omitempty" json:"resolvedAt,omitempty"`
+
+	// Settlement progress, as on Cancellation
+	RefundID  string `bson:"refundId,omitempty" json:"refundId,omitempty"`
+	PayoutID  string `bson:"payoutId,omitempty" json:"payoutId,omitempty"`
+	LastError string `bson:"lastError,omitempty" json:"lastError,omitempty"`
+}
+
+// DisputeEvidence is one statement from a party, with any files backing it.
+type DisputeEvidence struct {
+	ID          primitive.ObjectID `bson:"id" json:"id"`
+	PartyID     primitive.ObjectID `bson:"partyId" json:"partyId"`
+	Party       string             `bson:"party" json:"party"` // RoleBuyer or RoleSolver
+	Statement   string             `bson:"statement" json:"statement"`
+	Attachments []AttachmentRef    `bson:"attachments" json:"attachments"`
+	SubmittedAt time.Time          `bson:"submittedAt" json:"submittedAt"`
+}
+
+// DisputeRuling is an admin's decision and the split it produces.
+type DisputeRuling struct {
+	Outcome   string             `bson:"outcome" json:"outcome"` // one of the Ruling* outcomes
+	Split     RefundSplit        `bson:"split" json:"split"`
+	Notes     string             `bson:"notes" json:"notes"`
+	AdminID   primitive.ObjectID `bson:"adminId" json:"adminId"`
+	DecidedAt time.Time          `bson:"decidedAt" json:"decidedAt"`
+}
+
+// DisputeRecord is a user's dispute history, kept on their profile.
+type DisputeRecord struct {
+	Won   int `bson:"won" json:"won"`
+	Lost  int `bson:"lost" json:"lost"`
+	Split int `bson:"split" json:"split"`
+}
+
+// Dispute statuses
+const (
+	DisputeOpen        = "open"         // collecting evidence
+	DisputeUnderReview = "under_review" // an admin has taken it
+	DisputeExecuting   = "executing"    // the ruling's payments are being made
+	DisputeFailed      = "failed"       // executing the ruling stopped part way; it can be retried
+	DisputeResolved    = "resolved"
+)
+
+// Ruling outcomes
+const (
+	RulingRelease = "release" // the solver is paid in full
+	RulingRefund  = "refund"  // the buyer is refunded in full
+	RulingSplit   = "split"   // a percentage is refunded and the rest paid out
+)
+
+// RefundSourceRuling marks a split decided by an admin ruling.
+const RefundSourceRuling = "ruling"
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// GeoPoint is a GeoJSON Point, the format MongoDB's 2dsphere indexes expect.
+// Coordinates are [longitude, latitude].
+type GeoPoint struct {
+	Type        string    `bson:"type" json:"type"`
+	Coordinates []float64 `bson:"coordinates" json:"coordinates"`
+}
+
+// NewGeoPoint returns a GeoJSON point for the given latitude and longitude.
+func NewGeoPoint(lat, lng float64) *GeoPoint {
+	return &GeoPoint{Type: "Point", Coordinates: []float64{lng, lat}}
+}
+
+// Latitude returns the point's latitude, or 0 for a nil or malformed point.
+func (p *GeoPoint) Latitude() float64 {
+	if p == nil || len(p.Coordinates) != 2 {
+		return 0
+	}
+	return p.Coordinates[1]
+}
+
+// Longitude returns the point's longitude, or 0 for a nil or malformed point.
+func (p *GeoPoint) Longitude() float64 {
+	if p == nil || len(p.Coordinates) != 2 {
+		return 0
+	}
+	return p.Coordinates[0]
+}
+
+// IsZero reports whether the point is unset. Clients historically sent
+// 0,0 to mean "no location", so that is treated as unset too.
+func (p *GeoPoint) IsZero() bool {
+	return p == nil || len(p.Coordinates) != 2 || (p.Coordinates[0] == 0 && p.Coordinates[1] == 0)
+}
+
+// UnmarshalJSON accepts GeoJSON as well as the older
+// { "latitude": .., "longitude": .. } form that clients still send.
+func (p *GeoPoint) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Type        string    `json:"type"`
+		Coordinates []float64 `json:"coordinates"`
+		Latitude    *float64  `json:"latitude"`
+		Longitude   *float64  `json:"longitude"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	switch {
+	case raw.Latitude != nil || raw.Longitude != nil:
+		if raw.Latitude == nil || raw.Longitude == nil {
+			return fmt.Errorf("location needs both latitude and longitude")
+		}
+		*p = *NewGeoPoint(*raw.Latitude, *raw.Longitude)
+	case raw.Type == "Point" && len(raw.Coordinates) == 2:
+		*p = GeoPoint{Type: raw.Type, Coordinates: raw.Coordinates}
+	default:
+		return fmt.Errorf("location must be a GeoJSON Point or have latitude and longitude")
+	}
+	return nil
+}
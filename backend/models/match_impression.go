@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MatchImpression records a ranked list of solvers shown for an assignment,
+// with the feature scores behind each position, so rankings can be related
+// to what happened next.
+type MatchImpression struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	AssignmentID primitive.ObjectID `bson:"assignmentId" json:"assignmentId"`
+	ViewerID     primitive.ObjectID `bson:"viewerId,omitempty" json:"viewerId,omitempty"` // who was shown it; empty for offers
+	Surface      string             `bson:"surface" json:"surface"`                       // one of the Surface* values
+	Variant      string             `bson:"variant" json:"variant"`                       // ranker that produced it
+	ModelVersion string             `bson:"modelVersion,omitempty" json:"modelVersion,omitempty"`
+	Results      []ImpressionResult `bson:"results" json:"results"`
+	CreatedAt    time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// ImpressionResult is one ranked solver. Position starts at 1.
+type ImpressionResult struct {
+	SolverID primitive.ObjectID `bson:"solverId" json:"solverId"`
+	Position int                `bson:"position" json:"position"`
+	Score    float64            `bson:"score" json:"score"`
+	Features map[string]float64 `bson:"features" json:"features"` // scorer name to feature score
+}
+
+// Where a ranking was shown
+const (
+	SurfaceMatch  = "match"  // POST /api/match/solvers
+	SurfaceCreate = "create" // top solvers returned when posting an assignment
+	SurfaceOffer  = "offer"  // solvers sent an auto-assign offer
+)
+
+// MatchOutcome is something a solver went on to do for an assignment. There
+// is at most one per assignment, solver and event.
+type MatchOutcome struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	AssignmentID primitive.ObjectID `bson:"assignmentId" json:"assignmentId"`
+	SolverID     primitive.ObjectID `bson:"solverId" json:"solverId"`
+	Event        string             `bson:"event" json:"event"`                       // one of the Outcome* events
+	OnTime       *bool              `bson:"onTime,omitempty" json:"onTime,omitempty"` // completed only: by the due date
+	Rating       int                `bson:"rating,omitempty" json:"rating,omitempty"` // rated only: 1-5
+	At           time.Time          `bson:"at" json:"at"`
+}
+
+// Outcome events
+const (
+	OutcomeBid       = "bid"
+	OutcomeAccepted  = "accepted"
+	OutcomeCompleted = "completed"
+	OutcomeRated     = "rated"
+)
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Milestone is one separately paid stage of a large assignment. It is funded
+// by its own Payment and released when the buyer accepts its deliverable.
+type Milestone struct {
+	ID          primitive.ObjectID `bson:"id" json:"id"`
+	Index       int                `bson:"index" json:"index"` // 1-based position; names the milestone's escrow record
+	Title       string             `bson:"title" json:"title"`
+	Description string             `bson:"description,omitempty" json:"description,omitempty"`
+	Amount      float64            `bson:"amount" json:"amount"`
+	DueAt       time.Time          `bson:"dueAt" json:"dueAt"`
+	Status      string             `bson:"status" json:"status"` // one of the Milestone* statuses
+
+	PaymentID      primitive.ObjectID `bson:"paymentId,omitempty" json:"paymentId,omitempty"`         // the payment funding it
+	DeliverableID  primitive.ObjectID `bson:"deliverableId,omitempty" json:"deliverableId,omitempty"` // latest submission
+	RevisionRounds int                `bson:"revisionRounds,omitempty" json:"revisionRounds,omitempty"`
+	PayoutID       string             `bson:"payoutId,omitempty" json:"payoutId,omitempty"` // payout ID or escrow release transaction
+
+	FundedAt    time.Time `bson:"fundedAt,omitempty" json:"fundedAt,omitempty"`
+	SubmittedAt time.Time `bson:"submittedAt,omitempty" json:"submittedAt,omitempty"`
+	ReleasedAt  time.Time `bson:"releasedAt,omitempty" json:"releasedAt,omitempty"`
+	ClosedAt    time.Time `bson:"closedAt,omitempty" json:"closedAt,omitempty"`
+}
+
+// Milestone statuses. A revision request sends a submitted milestone back to
+// funded. Milestones still open when the assignment is cancelled or settled
+// by a dispute are closed; their payment records how the money went.
+const (
+	MilestonePending   = "pending"   // waiting for the buyer to fund it
+	MilestoneFunded    = "funded"    // paid into escrow, work under way
+	MilestoneSubmitted = "submitted" // deliverable awaiting the buyer's review
+	MilestoneReleasing = "releasing" // accepted, payment being released
+	MilestoneReleased  = "released"
+	MilestoneClosed    = "closed"
+)
+
+// MilestoneEscrowID is the escrow contract record holding a milestone's
+// payment. The contract keys records by an arbitrary string, so each
+// milestone gets its own record next to the assignment's.
+func MilestoneEscrowID(assignmentID primitive.ObjectID, index int) string {
+	return fmt.Sprintf("%s-m%d", assignmentID.Hex(), index)
+}
+
+// FindMilestone returns the milestone with the given ID.
+func (a Assignment) FindMilestone(id primitive.ObjectID) (Milestone, bool) {
+	for _, m := range a.Milestones {
+		if m.ID == id {
+			return m, true
+		}
+	}
+	return Milestone{}, false
+}
+
+// MilestonesIn reports whether every milestone other than except has one of
+// the given statuses.
+func (a Assignment) MilestonesIn(except primitive.ObjectID, statuses ...string) bool {
+	for _, m := range a.Milestones {
+		if m.ID == except {
+			continue
+		}
+		found := false
+		for _, s := range statuses {
+			if m.Status == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// MilestoneTotal is the sum of the milestone amounts.
+func (a Assignment) MilestoneTotal() float64 {
+	total := 0.0
+	for _, m := range a.Milestones {
+		total += m.Amount
+	}
+	return total
+}
@@ -29,6 +29,8 @@ const (
 	NotifTypePaymentConfirmed    = "payment_confirmed"    // Payment successful
 	NotifTypeAssignmentDelivered = "assignment_delivered" // Solver submitted work
 	NotifTypeAssignmentCompleted = "assignment_completed" // Assignment marked complete
+	NotifTypeBidReceived         = "bid_received"         // Solver bid on an assignment
+	NotifTypeCancelContested     = "cancel_contested"     // Solver contested the proposed refund split
 )
 
 // Notification types for solvers
@@ -39,6 +41,35 @@ const (
 	NotifTypeBuyerMessage        = "buyer_message"        // New message from buyer
 	NotifTypeAssignmentCancelled = "assignment_cancelled" // Buyer cancelled assignment
 	NotifTypeRatingReceived      = "rating_received"      // Received rating from buyer
+	NotifTypeBidAccepted         = "bid_accepted"         // Buyer accepted the solver's bid
+	NotifTypeBidRejected         = "bid_rejected"         // Buyer accepted another bid
+	NotifTypeRevisionRequested   = "revision_requested"   // Buyer asked for changes to a deliverable
+	NotifTypeDeadlineReminder    = "deadline_reminder"    // Assignment deadline is approaching
+	NotifTypeAssignmentOverdue   = "assignment_overdue"   // Assignment deadline passed without delivery
+	NotifTypeCancelRequested     = "cancel_requested"     // Buyer proposed cancelling with a refund split
+)
+
+// Notification types for disputes, sent to the parties and to admins
+const (
+	NotifTypeDisputeOpened   = "dispute_opened"   // A dispute was opened on an assignment
+	NotifTypeDisputeEvidence = "dispute_evidence" // The other party submitted evidence
+	NotifTypeDisputeUpdate   = "dispute_update"   // Evidence closed or the ruling is overdue
+	NotifTypeDisputeResolved = "dispute_resolved" // An admin ruled on the dispute
+)
+
+// Notification types for milestones, sent to the party who acts next
+const (
+	NotifTypeMilestoneFunded    = "milestone_funded"    // Buyer funded a milestone; the solver can start it
+	NotifTypeMilestoneSubmitted = "milestone_submitted" // Solver delivered a milestone for review
+	NotifTypeMilestoneReleased  = "milestone_released"  // Buyer accepted a milestone and its payment was released
+)
+
+// Notification types for auto-assign offers
+const (
+	NotifTypeOfferReceived       = "offer_received"        // Solver was offered an urgent assignment
+	NotifTypeOfferWithdrawn      = "offer_withdrawn"       // Offer closed before the solver answered
+	NotifTypeOfferAccepted       = "offer_accepted"        // A solver accepted the buyer's assignment
+	NotifTypeAutoAssignExhausted = "auto_assign_exhausted" // No solver accepted; auto-assign stopped
 )
 
 // Priority levels
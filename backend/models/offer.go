@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Offer invites a solver to take an urgent assignment the buyer asked the
+// platform to auto-assign. The solver has until ExpiresAt to accept; the
+// first acceptance assigns them the work.
+type Offer struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	AssignmentID primitive.ObjectID `bson:"assignmentId" json:"assignmentId"`
+	BuyerID      primitive.ObjectID `bson:"buyerId" json:"buyerId"`
+	SolverID     primitive.ObjectID `bson:"solverId" json:"solverId"`
+	Mode         string             `bson:"mode" json:"mode"`   // OfferExclusive or OfferBroadcast
+	Round        int                `bson:"round" json:"round"` // cascade round it was sent in, from 1
+	Score        float64            `bson:"score" json:"score"` // the solver's match score when offered
+	Amount       float64            `bson:"amount" json:"amount"`
+	Status       string             `bson:"status" json:"status"` // one of the Offer* statuses
+	// DeclineReason is the solver's optional note when declining
+	DeclineReason string    `bson:"declineReason,omitempty" json:"declineReason,omitempty"`
+	ExpiresAt     time.Time `bson:"expiresAt" json:"expiresAt"`
+	CreatedAt     time.Time `bson:"createdAt" json:"createdAt"`
+	DecidedAt     time.Time `bson:"decidedAt,omitempty" json:"decidedAt,omitempty"` // when it stopped being pending
+}
+
+// Offer statuses. An offer is withdrawn when the assignment is taken by
+// someone else or auto-assign stops before the solver answers.
+const (
+	OfferPending   = "pending"
+	OfferAccepted  = "accepted"
+	OfferDeclined  = "declined"
+	OfferExpired   = "expired"
+	OfferWithdrawn = "withdrawn"
+)
+
+// Auto-assign modes
+const (
+	// OfferExclusive offers the assignment to one solver at a time.
+	OfferExclusive = "exclusive"
+	// OfferBroadcast offers it to the next BroadcastSize solvers at once.
+	OfferBroadcast = "broadcast"
+)
+
+// Reasons auto-assign ended
+const (
+	AutoAssignClaimed   = "claimed"   // a solver was accepted
+	AutoAssignExhausted = "exhausted" // no available solver accepted in time
+	AutoAssignStopped   = "stopped"   // the buyer stopped it or the assignment moved on
+)
+
+// AutoAssignSettings track an assignment the platform is offering to
+// solvers on the buyer's behalf. Each round offers it to solvers not yet
+// offered, best match first; the next round starts once every offer in the
+// current one has been declined or has expired.
+type AutoAssignSettings struct {
+	Mode          string               `bson:"mode" json:"mode"`
+	BroadcastSize int                  `bson:"broadcastSize,omitempty" json:"broadcastSize,omitempty"`
+	WindowMinutes int                  `bson:"windowMinutes" json:"windowMinutes"` // time each solver has to answer
+	Active        bool                 `bson:"active" json:"active"`
+	Round         int                  `bson:"round" json:"round"`
+	Offered       []primitive.ObjectID `bson:"offered" json:"-"` // solvers already sent an offer
+	StartedAt     time.Time            `bson:"startedAt" json:"startedAt"`
+	EndedAt       time.Time            `bson:"endedAt,omitempty" json:"endedAt,omitempty"`
+	EndReason     string               `bson:"endReason,omitempty" json:"endReason,omitempty"` // one of the AutoAssign* reasons
+}
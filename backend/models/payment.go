@@ -29,6 +29,13 @@ type Payment struct {
 	RazorpayPayoutID string    `bson:"razorpayPayoutId,omitempty" json:"razorpayPayoutId,omitempty"`
 	PayoutStatus     string    `bson:"payoutStatus,omitempty" json:"payoutStatus,omitempty"` // e.g., pending, success, failed
 	ReleasedAt       time.Time `bson:"releasedAt,omitempty" json:"releasedAt,omitempty"`
+	// Refund tracking; RefundID is the Razorpay refund or the escrow refund transaction
+	RefundID     string    `bson:"refundId,omitempty" json:"refundId,omitempty"`
+	RefundAmount float64   `bson:"refundAmount,omitempty" json:"refundAmount,omitempty"`
+	RefundedAt   time.Time `bson:"refundedAt,omitempty" json:"refundedAt,omitempty"`
+	// Milestone payments fund a single milestone, held in its own escrow record
+	MilestoneID primitive.ObjectID `bson:"milestoneId,omitempty" json:"milestoneId,omitempty"`
+	EscrowID    string             `bson:"escrowId,omitempty" json:"escrowId,omitempty"` // empty means the assignment ID
 }
 
 type RazorpayOrder struct {
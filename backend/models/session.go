@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Session is one logged-in device. It doubles as the refresh-token family:
+// every refresh rotates TokenHash, and presenting a stale token revokes the
+// whole session.
+type Session struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID `bson:"userId" json:"userId"`
+	TokenHash  string             `bson:"tokenHash" json:"-"` // sha256 of the current refresh secret
+	ActiveRole string             `bson:"activeRole" json:"activeRole"`
+	UserAgent  string             `bson:"userAgent" json:"userAgent"`
+	IP         string             `bson:"ip" json:"ip"`
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+	LastUsedAt time.Time          `bson:"lastUsedAt" json:"lastUsedAt"`
+	ExpiresAt  time.Time          `bson:"expiresAt" json:"expiresAt"`
+	RevokedAt  time.Time          `bson:"revokedAt,omitempty" json:"revokedAt,omitempty"`
+	// RevokeReason is "logout", "logout_all" or "reuse_detected"
+	RevokeReason string `bson:"revokeReason,omitempty" json:"revokeReason,omitempty"`
+}
+
+// IsActive reports whether the session can still be used.
+func (s Session) IsActive(now time.Time) bool {
+	return s.RevokedAt.IsZero() && now.Before(s.ExpiresAt)
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Skill is an entry in the skill taxonomy. Users and assignments store the
+// canonical Name; free-form input is mapped to it through the aliases.
+type Skill struct {
+	ID        string    `bson:"_id" json:"id"` // stable slug, e.g. "deep-learning"
+	Name      string    `bson:"name" json:"name"`
+	Aliases   []string  `bson:"aliases" json:"aliases"`                       // lower-case synonyms
+	ParentID  string    `bson:"parentId,omitempty" json:"parentId,omitempty"` // broader skill, e.g. "machine-learning"
+	CreatedAt time.Time `bson:"createdAt,omitempty" json:"createdAt,omitempty"`
+	UpdatedAt time.Time `bson:"updatedAt,omitempty" json:"updatedAt,omitempty"`
+}
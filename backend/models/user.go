@@ -1,39 +1,191 @@
 package models
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type User struct {
 	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	Name     string             `json:"name" bson:"name"`
 	Email    string             `json:"email" bson:"email"`
 	Password string             `json:"password,omitempty" bson:"password"`
-	Role     string             `json:"role" bson:"role"` // "buyer" or "solver"
+	Roles    []string           `json:"roles" bson:"roles"` // any of RoleBuyer, RoleSolver, RoleAdmin
 	Skills   []string           `json:"skills" bson:"skills"`
 	About    string             `json:"about" bson:"about"`
+	Offering string             `json:"offering,omitempty" bson:"offering,omitempty"`
+
+	EmailVerified   bool      `json:"emailVerified" bson:"emailVerified"`
+	EmailVerifiedAt time.Time `json:"emailVerifiedAt,omitempty" bson:"emailVerifiedAt,omitempty"`
 
-	AvgRating     float64  `json:"avg_rating" bson:"avgRating"`
-	AvgResponse   float64  `json:"avg_response" bson:"avgResponse"` // minutes
-	AvgSpeed      float64  `json:"avg_speed" bson:"avgSpeed"`       // hours
-	PricePerJob   float64  `json:"price_per_job" bson:"pricePerJob"`
-	Location      Location `json:"location" bson:"location"`
-	Speed         float64  `json:"speed" bson:"speed"` // avg time per assignment in hours
-	CreatedAt     int64    `json:"createdAt" bson:"createdAt"`
-	CompletedJobs int      `json:"completedJobs" bson:"completedJobs"`
-	Reliability   float64  `json:"reliability" bson:"reliability"` // 0-1 score
+	AvgRating     float64   `json:"avg_rating" bson:"avgRating"`
+	RatingCount   int       `json:"rating_count" bson:"ratingCount"` // completed assignments rated
+	AvgResponse   float64   `json:"avg_response" bson:"avgResponse"` // minutes
+	AvgSpeed      float64   `json:"avg_speed" bson:"avgSpeed"`       // hours
+	PricePerJob   float64   `json:"price_per_job" bson:"pricePerJob"`
+	Location      *GeoPoint `json:"location,omitempty" bson:"location,omitempty"`
+	Speed         float64   `json:"speed" bson:"speed"` // avg time per assignment in hours
+	CreatedAt     int64     `json:"createdAt" bson:"createdAt"`
+	CompletedJobs int       `json:"completedJobs" bson:"completedJobs"`
+	Reliability   float64   `json:"reliability" bson:"reliability"` // 0-1 score
+	// MissedDeadlines counts assignments that went overdue; each one lowers Reliability
+	MissedDeadlines int `json:"missedDeadlines" bson:"missedDeadlines"`
+	// Disputes tallies ruled disputes; a solver who loses one also loses Reliability
+	Disputes DisputeRecord `json:"disputes" bson:"disputes"`
+	// Availability is the solver's working hours, capacity and away periods
+	Availability *Availability `json:"availability,omitempty" bson:"availability,omitempty"`
 	// EthereumAddress stores the user's crypto address for on-chain escrow and payouts
 	EthereumAddress string `json:"ethereumAddress,omitempty" bson:"ethereumAddress"`
 
 	// Payout details for fiat payouts (Razorpay payouts / bank)
-	Payout struct {
-		AccountHolderName string `json:"accountHolderName,omitempty" bson:"accountHolderName,omitempty"`
-		AccountNumber     string `json:"accountNumber,omitempty" bson:"accountNumber,omitempty"`
-		IFSC              string `json:"ifsc,omitempty" bson:"ifsc,omitempty"`
-		BankName          string `json:"bankName,omitempty" bson:"bankName,omitempty"`
-		UPI               string `json:"upi,omitempty" bson:"upi,omitempty"` // UPI/VPA
-	} `json:"payout,omitempty" bson:"payout,omitempty"`
-}
-
-type Location struct {
-	Latitude  float64 `json:"latitude" bson:"latitude"`
-	Longitude float64 `json:"longitude" bson:"longitude"`
+	Payout PayoutDetails `json:"payout,omitempty" bson:"payout,omitempty"`
+
+	// Optional TOTP two-factor authentication
+	TwoFactor TwoFactorSettings `json:"twoFactor" bson:"twoFactor,omitempty"`
+}
+
+type TwoFactorSettings struct {
+	Enabled       bool      `json:"enabled" bson:"enabled"`
+	EnabledAt     time.Time `json:"enabledAt,omitempty" bson:"enabledAt,omitempty"`
+	Secret        string    `json:"-" bson:"secret,omitempty"`
+	PendingSecret string    `json:"-" bson:"pendingSecret,omitempty"` // set by enroll, promoted by enable
+	RecoveryCodes []string  `json:"-" bson:"recoveryCodes,omitempty"` // sha256 hashes, removed once used
+	LastUsedStep  int64     `json:"-" bson:"lastUsedStep,omitempty"`  // rejects replay of an accepted code
+}
+
+type PayoutDetails struct {
+	AccountHolderName string `json:"accountHolderName,omitempty" bson:"accountHolderName,omitempty"`
+	AccountNumber     string `json:"accountNumber,omitempty" bson:"accountNumber,omitempty"`
+	IFSC              string `json:"ifsc,omitempty" bson:"ifsc,omitempty"`
+	BankName          string `json:"bankName,omitempty" bson:"bankName,omitempty"`
+	UPI               string `json:"upi,omitempty" bson:"upi,omitempty"` // UPI/VPA
+}
+
+// User roles. A user may hold several roles at once; the role they are
+// acting as is carried in their JWT, not stored on the user document.
+// RoleAdmin is never self-service; it is granted from ADMIN_EMAILS at startup.
+const (
+	RoleBuyer  = "buyer"
+	RoleSolver = "solver"
+	RoleAdmin  = "admin"
+)
+
+// HasRole reports whether the user has been granted the given role.
+func (u User) HasRole(role string) bool {
+	for _, r := range u.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// IsComplete reports whether enough payout details are present to pay a
+// solver, either via UPI or via a bank account.
+func (p PayoutDetails) IsComplete() bool {
+	if p.UPI != "" {
+		return true
+	}
+	return p.AccountHolderName != "" && p.AccountNumber != "" && p.IFSC != ""
+}
+
+// PublicUser is the profile any authenticated user may see. List, search
+// and matching responses must use it instead of User.
+type PublicUser struct {
+	ID            primitive.ObjectID `json:"id"`
+	Name          string             `json:"name"`
+	Roles         []string           `json:"roles"`
+	Skills        []string           `json:"skills"`
+	About         string             `json:"about"`
+	Offering      string             `json:"offering,omitempty"`
+	AvgRating     float64            `json:"avg_rating"`
+	RatingCount   int                `json:"rating_count"`
+	AvgResponse   float64            `json:"avg_response"`
+	AvgSpeed      float64            `json:"avg_speed"`
+	PricePerJob   float64            `json:"price_per_job"`
+	Location      *GeoPoint          `json:"location,omitempty"`
+	Speed         float64            `json:"speed"`
+	CreatedAt     int64              `json:"createdAt"`
+	CompletedJobs int                `json:"completedJobs"`
+	Reliability   float64            `json:"reliability"`
+	Disputes      DisputeRecord      `json:"disputes"`
+	Availability  *Availability      `json:"availability,omitempty"`
+}
+
+// PrivateUser is the profile returned to the account owner. Payout numbers
+// are masked; the full values are never sent back to the client.
+type PrivateUser struct {
+	PublicUser
+	Email            string        `json:"email"`
+	EmailVerified    bool          `json:"emailVerified"`
+	EthereumAddress  string        `json:"ethereumAddress,omitempty"`
+	Payout           PayoutDetails `json:"payout"`
+	TwoFactorEnabled bool          `json:"twoFactorEnabled"`
+}
+
+// Public returns the user's public profile.
+func (u User) Public() PublicUser {
+	return PublicUser{
+		ID:            u.ID,
+		Name:          u.Name,
+		Roles:         u.Roles,
+		Skills:        u.Skills,
+		About:         u.About,
+		Offering:      u.Offering,
+		AvgRating:     u.AvgRating,
+		RatingCount:   u.RatingCount,
+		AvgResponse:   u.AvgResponse,
+		AvgSpeed:      u.AvgSpeed,
+		PricePerJob:   u.PricePerJob,
+		Location:      u.Location,
+		Speed:         u.Speed,
+		CreatedAt:     u.CreatedAt,
+		CompletedJobs: u.CompletedJobs,
+		Reliability:   u.Reliability,
+		Disputes:      u.Disputes,
+		Availability:  u.Availability,
+	}
+}
+
+// Private returns the owner's view of the user. u.Payout must already be
+// decrypted.
+func (u User) Private() PrivateUser {
+	return PrivateUser{
+		PublicUser:       u.Public(),
+		Email:            u.Email,
+		EmailVerified:    u.EmailVerified,
+		EthereumAddress:  u.EthereumAddress,
+		Payout:           u.Payout.Masked(),
+		TwoFactorEnabled: u.TwoFactor.Enabled,
+	}
+}
+
+// Masked hides all but the last four digits of the account number and most
+// of the UPI handle.
+func (p PayoutDetails) Masked() PayoutDetails {
+	p.AccountNumber = maskTail(p.AccountNumber, 4)
+	if name, handle, ok := strings.Cut(p.UPI, "@"); ok {
+		p.UPI = maskHead(name, 2) + "@" + handle
+	} else {
+		p.UPI = maskHead(p.UPI, 0)
+	}
+	return p
+}
+
+// maskTail keeps the last n characters of s and stars out the rest.
+func maskTail(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.Repeat("*", len(s)-n) + s[len(s)-n:]
+}
+
+// maskHead keeps the first n characters of s and stars out the rest.
+func maskHead(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + strings.Repeat("*", len(s)-n)
 }
@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Login lockout: after lockoutThreshold consecutive failures the account is
+// locked for lockoutBase, doubling with each further failure up to
+// lockoutMax. The failure count resets after failureWindow without failures
+// or on a successful login.
+const (
+	lockoutThreshold = 5
+	lockoutBase      = 30 * time.Second
+	lockoutMax       = time.Hour
+	failureWindow    = 24 * time.Hour
+)
+
+func failuresKey(account string) string {
+	return "login_failures:" + strings.ToLower(strings.TrimSpace(account))
+}
+
+func lockKey(account string) string {
+	return "login_lock:" + strings.ToLower(strings.TrimSpace(account))
+}
+
+// LoginLockedFor returns how long login to account remains locked.
+func LoginLockedFor(ctx context.Context, account string) (time.Duration, error) {
+	return store.LockedFor(ctx, lockKey(account), time.Now())
+}
+
+// RecordLoginFailure counts a failed login and locks the account once the
+// threshold is reached. It returns the lock duration applied, if any.
+func RecordLoginFailure(ctx context.Context, account string) (time.Duration, error) {
+	failures, err := store.Incr(ctx, failuresKey(account), failureWindow)
+	if err != nil {
+		return 0, err
+	}
+	if failures < lockoutThreshold {
+		return 0, nil
+	}
+
+	d := lockoutMax
+	if shift := failures - lockoutThreshold; shift < 16 {
+		d = min(lockoutBase<<shift, lockoutMax)
+	}
+	if err := store.Lock(ctx, lockKey(account), d); err != nil {
+		return 0, err
+	}
+	return d, nil
+}
+
+// ResetLoginFailures clears the failure count after a successful login.
+func ResetLoginFailures(ctx context.Context, account string) error {
+	return store.Delete(ctx, failuresKey(account), lockKey(account))
+}
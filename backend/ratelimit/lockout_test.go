@@ -0,0 +1,106 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func useMemoryStore(t *testing.T) *MemoryStore {
+	t.Helper()
+	m := NewMemoryStore()
+	prev := store
+	store = m
+	t.Cleanup(func() { store = prev })
+	return m
+}
+
+func TestLoginLockoutBacksOff(t *testing.T) {
+	useMemoryStore(t)
+	ctx := context.Background()
+	const account = "Solver@Example.com"
+
+	for i := 1; i < lockoutThreshold; i++ {
+		d, err := RecordLoginFailure(ctx, account)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d != 0 {
+			t.Fatalf("failure %d locked the account for %s, want no lock below the threshold", i, d)
+		}
+	}
+	if locked, _ := LoginLockedFor(ctx, account); locked != 0 {
+		t.Fatalf("locked for %s before the threshold", locked)
+	}
+
+	want := lockoutBase
+	for i := lockoutThreshold; i < lockoutThreshold+10; i++ {
+		d, err := RecordLoginFailure(ctx, account)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d != want {
+			t.Fatalf("failure %d locked for %s, want %s", i, d, want)
+		}
+		want = min(want*2, lockoutMax)
+	}
+	if want != lockoutMax {
+		t.Fatalf("backoff never reached the %s cap", lockoutMax)
+	}
+
+	// Accounts are matched case-insensitively, ignoring surrounding spaces
+	locked, err := LoginLockedFor(ctx, "  solver@example.COM ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if locked <= lockoutMax-time.Minute || locked > lockoutMax {
+		t.Fatalf("LoginLockedFor = %s, want about %s", locked, lockoutMax)
+	}
+	if locked, _ := LoginLockedFor(ctx, "buyer@example.com"); locked != 0 {
+		t.Fatalf("another account is locked for %s", locked)
+	}
+}
+
+func TestSuccessfulLoginResetsLockout(t *testing.T) {
+	useMemoryStore(t)
+	ctx := context.Background()
+	const account = "solver@example.com"
+
+	for i := 0; i < lockoutThreshold+2; i++ {
+		if _, err := RecordLoginFailure(ctx, account); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if locked, _ := LoginLockedFor(ctx, account); locked == 0 {
+		t.Fatal("account is not locked after repeated failures")
+	}
+
+	if err := ResetLoginFailures(ctx, account); err != nil {
+		t.Fatal(err)
+	}
+	if locked, _ := LoginLockedFor(ctx, account); locked != 0 {
+		t.Fatalf("still locked for %s after a successful login", locked)
+	}
+	// The count starts over rather than resuming at the old backoff
+	for i := 1; i < lockoutThreshold; i++ {
+		if d, _ := RecordLoginFailure(ctx, account); d != 0 {
+			t.Fatalf("failure %d after reset locked for %s", i, d)
+		}
+	}
+	if d, _ := RecordLoginFailure(ctx, account); d != lockoutBase {
+		t.Fatalf("first lock after reset = %s, want %s", d, lockoutBase)
+	}
+}
+
+func TestLockExpires(t *testing.T) {
+	m := useMemoryStore(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	if err := m.Lock(ctx, lockKey("solver@example.com"), lockoutBase); err != nil {
+		t.Fatal(err)
+	}
+	if locked, _ := m.LockedFor(ctx, lockKey("solver@example.com"), now.Add(lockoutBase+time.Second)); locked != 0 {
+		t.Fatalf("lock still held for %s after it expired", locked)
+	}
+}
@@ -0,0 +1,129 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps limits in process memory. It is used when Redis is not
+// configured, so limits are per instance and reset on restart.
+type MemoryStore struct {
+	mu       sync.Mutex
+	hits     map[string][]time.Time
+	counters map[string]memoryCounter
+	locks    map[string]time.Time
+	ops      int
+}
+
+type memoryCounter struct {
+	n         int64
+	expiresAt time.Time
+}
+
+// memorySweepEvery controls how often expired entries are purged.
+const memorySweepEvery = 1000
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		hits:     make(map[string][]time.Time),
+		counters: make(map[string]memoryCounter),
+		locks:    make(map[string]time.Time),
+	}
+}
+
+func (m *MemoryStore) Allow(ctx context.Context, key string, limit int, window time.Duration, now time.Time) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maybeSweep(now)
+
+	hits := pruneHits(m.hits[key], now.Add(-window))
+	if len(hits) >= limit {
+		m.hits[key] = hits
+		retry := time.Duration(0)
+		if len(hits) > 0 {
+			retry = hits[0].Add(window).Sub(now)
+		}
+		return Result{Allowed: false, Remaining: 0, RetryAfter: retry}, nil
+	}
+	m.hits[key] = append(hits, now)
+	return Result{Allowed: true, Remaining: limit - len(hits) - 1}, nil
+}
+
+func (m *MemoryStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	m.maybeSweep(now)
+
+	c := m.counters[key]
+	if now.After(c.expiresAt) {
+		c.n = 0
+	}
+	c.n++
+	c.expiresAt = now.Add(ttl)
+	m.counters[key] = c
+	return c.n, nil
+}
+
+func (m *MemoryStore) Lock(ctx context.Context, key string, d time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.locks[key] = time.Now().Add(d)
+	return nil
+}
+
+func (m *MemoryStore) LockedFor(ctx context.Context, key string, now time.Time) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	until, ok := m.locks[key]
+	if !ok || !until.After(now) {
+		return 0, nil
+	}
+	return until.Sub(now), nil
+}
+
+func (m *MemoryStore) Delete(ctx context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range keys {
+		delete(m.hits, key)
+		delete(m.counters, key)
+		delete(m.locks, key)
+	}
+	return nil
+}
+
+// pruneHits drops hits at or before cutoff; hits are in ascending order.
+func pruneHits(hits []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(hits) && !hits[i].After(cutoff) {
+		i++
+	}
+	return hits[i:]
+}
+
+// maybeSweep periodically removes expired entries so idle keys do not
+// accumulate. Callers must hold m.mu.
+func (m *MemoryStore) maybeSweep(now time.Time) {
+	m.ops++
+	if m.ops%memorySweepEvery != 0 {
+		return
+	}
+	for key, hits := range m.hits {
+		// Windows are at most an hour in practice; anything older is stale
+		if len(hits) == 0 || now.Sub(hits[len(hits)-1]) > time.Hour {
+			delete(m.hits, key)
+		}
+	}
+	for key, c := range m.counters {
+		if now.After(c.expiresAt) {
+			delete(m.counters, key)
+		}
+	}
+	for key, until := range m.locks {
+		if now.After(until) {
+			delete(m.locks, key)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/config"
+)
+
+// Result describes the outcome of a rate-limited request.
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration // zero when allowed
+}
+
+// Store keeps rate-limit and lockout state. Implementations must be safe
+// for concurrent use; the Redis store is also safe across instances.
+type Store interface {
+	// Allow records a hit for key if fewer than limit hits fall inside the
+	// sliding window ending at now.
+	Allow(ctx context.Context, key string, limit int, window time.Duration, now time.Time) (Result, error)
+	// Incr increments a counter that expires ttl after its last increment.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// Lock marks key as locked for d.
+	Lock(ctx context.Context, key string, d time.Duration) error
+	// LockedFor returns how long key stays locked, or zero.
+	LockedFor(ctx context.Context, key string, now time.Time) (time.Duration, error)
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// Policy is the request budget for one group of endpoints. PerIP and
+// PerUser are the number of requests allowed per Window; zero disables
+// that dimension.
+type Policy struct {
+	Name    string
+	PerIP   int
+	PerUser int
+	Window  time.Duration
+}
+
+// Budgets per endpoint group, overridable via RATE_LIMIT_<NAME>.
+var (
+	Auth    = &Policy{Name: "auth", PerIP: 20, Window: time.Minute}
+	NLP     = &Policy{Name: "nlp", PerIP: 30, PerUser: 10, Window: time.Minute}
+	Chat    = &Policy{Name: "chat", PerIP: 120, PerUser: 60, Window: time.Minute}
+	Payment = &Policy{Name: "payment", PerIP: 30, PerUser: 10, Window: time.Minute}
+	Upload  = &Policy{Name: "upload", PerIP: 60, PerUser: 30, Window: time.Minute}
+)
+
+var store Store = NewMemoryStore()
+
+// Init uses Redis when config.Redis is set so limits are shared between
+// instances, and otherwise keeps the in-memory store. It also applies
+// RATE_LIMIT_<NAME> overrides of the form "ip=20,user=10,window=1m".
+func Init() {
+	if config.Redis != nil {
+		store = NewRedisStore(config.Redis)
+		log.Println("Rate limit store: redis")
+	} else {
+		log.Println("Rate limit store: memory")
+	}
+
+	for _, p := range []*Policy{Auth, NLP, Chat, Payment, Upload} {
+		env := "RATE_LIMIT_" + strings.ToUpper(p.Name)
+		if v := os.Getenv(env); v != "" {
+			if err := p.parse(v); err != nil {
+				log.Fatalf("Invalid %s: %v", env, err)
+			}
+		}
+	}
+}
+
+// Allow records a hit against key using the configured store.
+func Allow(ctx context.Context, key string, limit int, window time.Duration) (Result, error) {
+	return store.Allow(ctx, "rl:"+key, limit, window, time.Now())
+}
+
+func (p *Policy) parse(v string) error {
+	for _, part := range strings.Split(v, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return fmt.Errorf("expected key=value, got %q", part)
+		}
+		switch name {
+		case "ip", "user":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return fmt.Errorf("%s must be a non-negative integer", name)
+			}
+			if name == "ip" {
+				p.PerIP = n
+			} else {
+				p.PerUser = n
+			}
+		case "window":
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return fmt.Errorf("window must be a positive duration")
+			}
+			p.Window = d
+		default:
+			return fmt.Errorf("unknown setting %q", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreSlidingWindow(t *testing.T) {
+	m := NewMemoryStore()
+	ctx := context.Background()
+	start := time.Date(2026, time.March, 4, 10, 0, 0, 0, time.UTC)
+	allow := func(at time.Duration) Result {
+		t.Helper()
+		res, err := m.Allow(ctx, "rl:auth:ip:1.2.3.4", 3, time.Minute, start.Add(at))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	for i, at := range []time.Duration{0, 10 * time.Second, 20 * time.Second} {
+		res := allow(at)
+		if !res.Allowed || res.Remaining != 2-i {
+			t.Fatalf("hit %d = %+v, want allowed with %d remaining", i+1, res, 2-i)
+		}
+	}
+
+	res := allow(30 * time.Second)
+	if res.Allowed || res.Remaining != 0 {
+		t.Fatalf("fourth hit = %+v, want it limited", res)
+	}
+	if res.RetryAfter != 30*time.Second {
+		t.Fatalf("RetryAfter = %s, want 30s until the first hit leaves the window", res.RetryAfter)
+	}
+
+	// Rejected hits do not extend the window
+	if res := allow(time.Minute); !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("hit once the first left the window = %+v, want allowed with 0 remaining", res)
+	}
+	if res := allow(time.Minute + 5*time.Second); res.Allowed || res.RetryAfter != 5*time.Second {
+		t.Fatalf("hit inside the slid window = %+v, want limited for 5s", res)
+	}
+	if res := allow(3 * time.Minute); !res.Allowed || res.Remaining != 2 {
+		t.Fatalf("hit after a quiet window = %+v, want a full budget", res)
+	}
+
+	// Keys are limited independently
+	if res, _ := m.Allow(ctx, "rl:auth:ip:5.6.7.8", 3, time.Minute, start.Add(30*time.Second)); !res.Allowed {
+		t.Fatal("another key was limited")
+	}
+}
+
+func TestPolicyParse(t *testing.T) {
+	p := Policy{Name: "chat", PerIP: 120, PerUser: 60, Window: time.Minute}
+	if err := p.parse("ip=10, user=0,window=30s"); err != nil {
+		t.Fatal(err)
+	}
+	if p.PerIP != 10 || p.PerUser != 0 || p.Window != 30*time.Second {
+		t.Fatalf("parsed policy = %+v", p)
+	}
+
+	for _, bad := range []string{"ip", "ip=-1", "user=lots", "window=0s", "window=soon", "burst=5"} {
+		p := Policy{Name: "chat"}
+		if err := p.parse(bad); err == nil {
+			t.Errorf("parse(%q) succeeded, want an error", bad)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"github.com/Aashishvatwani/homeworld/controllers"
+	"github.com/Aashishvatwani/homeworld/middleware"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/gin-gonic/gin"
+)
+
+func AdminRoutes(r *gin.Engine) {
+	admin := r.Group("/api/admin")
+	admin.Use(middleware.AuthRequired(), middleware.RequireRole(models.RoleAdmin))
+	{
+		admin.GET("/audit", controllers.GetAuditLog)
+		admin.GET("/audit/verify", controllers.VerifyAuditLog)
+
+		// Dispute arbitration
+		admin.GET("/disputes", controllers.ListDisputes)
+		admin.POST("/disputes/:id/assign", controllers.AssignDispute)
+		admin.POST("/disputes/:id/rule", controllers.RuleDispute)
+
+		// Skill taxonomy
+		admin.POST("/skills", controllers.CreateSkill)
+		admin.PUT("/skills/:id", controllers.UpdateSkill)
+		admin.DELETE("/skills/:id", controllers.DeleteSkill)
+
+		// Ranker A/B metrics
+		admin.GET("/matching/metrics", controllers.GetMatchMetrics)
+	}
+}
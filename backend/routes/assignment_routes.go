@@ -2,26 +2,65 @@
 
 import (
 	"github.com/Aashishvatwani/homeworld/controllers"
+	"github.com/Aashishvatwani/homeworld/middleware"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/Aashishvatwani/homeworld/ratelimit"
 	"github.com/gin-gonic/gin"
 )
 
 func AssignmentRoutes(r *gin.Engine) {
 	api := r.Group("/api")
+	api.Use(middleware.AuthRequired())
 	{
 		// Assignment CRUD
 		api.POST("/assignments/create", controllers.CreateAssignmentRoute)
 		api.POST("/assignment/create", controllers.CreateAssignmentRoute)
 		api.GET("/assignments", controllers.GetAssignments)
+		api.GET("/assignments/nearby", middleware.RequireRole(models.RoleSolver), controllers.GetNearbyAssignments)
 		api.GET("/assignments/:id", controllers.GetAssignment)
+		api.PUT("/assignments/:id/status", controllers.UpdateAssignmentStatus)
+		api.POST("/assignments/:id/cancel-overdue", controllers.CancelOverdueAssignment)
+
+		// Cancellation
+		api.POST("/assignments/:id/cancel", controllers.RequestCancellation)
+		api.GET("/assignments/:id/cancellation", controllers.GetCancellation)
+		api.POST("/assignments/:id/cancellation/accept", controllers.AcceptCancellation)
+		api.POST("/assignments/:id/cancellation/contest", controllers.ContestCancellation)
+
+		// Disputes
+		api.GET("/assignments/:id/disputes", controllers.GetAssignmentDisputes)
+		api.POST("/assignments/:id/disputes", controllers.OpenDispute)
+
+		// Bidding
+		api.GET("/assignments/:id/bids", controllers.GetBids)
+		api.POST("/assignments/:id/bids", middleware.RequireRole(models.RoleSolver), controllers.SubmitBid)
+		api.PUT("/assignments/:id/bids/:bidId", middleware.RequireRole(models.RoleSolver), controllers.ReviseBid)
+		api.DELETE("/assignments/:id/bids/:bidId", middleware.RequireRole(models.RoleSolver), controllers.WithdrawBid)
+		api.POST("/assignments/:id/bids/:bidId/accept", controllers.AcceptBid)
+
+		// Deliverables
+		api.GET("/assignments/:id/deliverables", controllers.GetDeliverables)
+		api.POST("/assignments/:id/deliverables", controllers.SubmitDeliverable)
+		api.POST("/assignments/:id/deliverables/:deliverableId/accept", controllers.AcceptDeliverable)
+		api.POST("/assignments/:id/deliverables/:deliverableId/revision", controllers.RequestRevision)
+
+		// Auto-assign (urgent assignments)
+		api.POST("/assignments/:id/auto-assign", controllers.StartAutoAssign)
+		api.DELETE("/assignments/:id/auto-assign", controllers.StopAutoAssign)
+		api.GET("/assignments/:id/offers", controllers.GetAssignmentOffers)
+
+		// Milestones
+		api.PUT("/assignments/:id/milestones", controllers.SetMilestones)
 
 		// AI-Powered Assignment Creation (from text message)
-		api.POST("/assignments/create-from-text", controllers.CreateAssignmentFromText)
+		api.POST("/assignments/create-from-text", middleware.RateLimit(ratelimit.NLP), controllers.CreateAssignmentFromText)
 
 		// NLP parsing only (no database save)
-		api.POST("/nlp/parse", controllers.ParseAssignmentNLP)
+		api.POST("/nlp/parse", middleware.RateLimit(ratelimit.NLP), controllers.ParseAssignmentNLP)
 
 		// Matching
 		api.POST("/match/solvers", controllers.MatchSolvers)
 		api.POST("/assignments/complete", controllers.AssignmentCompleted)
+		api.POST("/assignments/:id/rating", controllers.RateAssignment)
 	}
 }
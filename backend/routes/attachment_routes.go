@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/Aashishvatwani/homeworld/controllers"
+	"github.com/Aashishvatwani/homeworld/middleware"
+	"github.com/Aashishvatwani/homeworld/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+func AttachmentRoutes(r *gin.Engine) {
+	// Signed download links from the local storage backend carry their own authorization
+	r.GET("/api/files/*key", controllers.DownloadFile)
+
+	api := r.Group("/api")
+	api.Use(middleware.AuthRequired())
+	{
+		api.POST("/attachments", middleware.RateLimit(ratelimit.Upload), controllers.UploadAttachment)
+		api.GET("/attachments/:id", controllers.GetAttachment)
+	}
+}
@@ -2,17 +2,18 @@
 
 import (
 	"github.com/Aashishvatwani/homeworld/controllers"
+	"github.com/Aashishvatwani/homeworld/middleware"
+	"github.com/Aashishvatwani/homeworld/ratelimit"
 	"github.com/gin-gonic/gin"
 )
 
 func ChatRoutes(r *gin.Engine) {
 	api := r.Group("/api")
+	api.Use(middleware.AuthRequired())
 	{
 		api.POST("/chat/create", controllers.CreateChat)
-		api.POST("/chat/:id/message", controllers.SendMessage)
+		api.POST("/chat/:id/message", middleware.RateLimit(ratelimit.Chat), controllers.SendMessage)
 		api.GET("/chat/:id", controllers.GetChat)
 		api.PUT("/chat/:id/price", controllers.NegotiatePrice)
-		// Migration helper endpoint
-		api.POST("/chat/fix-messages", controllers.FixChatMessages)
 	}
 }
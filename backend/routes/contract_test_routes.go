@@ -2,11 +2,15 @@ package routes
 
 import (
 	"github.com/Aashishvatwani/homeworld/controllers"
+	"github.com/Aashishvatwani/homeworld/middleware"
+	"github.com/Aashishvatwani/homeworld/models"
 	"github.com/gin-gonic/gin"
 )
 
 func ContractTestRoutes(r *gin.Engine) {
 	api := r.Group("/api/contract")
+	// These endpoints move real escrow funds, so only admins may call them
+	api.Use(middleware.AuthRequired(), middleware.RequireRole(models.RoleAdmin))
 	{
 		// Test endpoints for smart contract integration
 		api.POST("/test-create-escrow", controllers.TestCreateEscrow)
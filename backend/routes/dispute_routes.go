@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/Aashishvatwani/homeworld/controllers"
+	"github.com/Aashishvatwani/homeworld/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func DisputeRoutes(r *gin.Engine) {
+	api := r.Group("/api")
+	api.Use(middleware.AuthRequired())
+	{
+		api.GET("/disputes/:id", controllers.GetDispute)
+		api.POST("/disputes/:id/evidence", controllers.SubmitDisputeEvidence)
+	}
+}
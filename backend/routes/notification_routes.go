@@ -2,14 +2,17 @@ package routes
 
 import (
 	"github.com/Aashishvatwani/homeworld/controllers"
+	"github.com/Aashishvatwani/homeworld/middleware"
+	"github.com/Aashishvatwani/homeworld/models"
 	"github.com/gin-gonic/gin"
 )
 
 func NotificationRoutes(r *gin.Engine) {
 	api := r.Group("/api")
+	api.Use(middleware.AuthRequired())
 	{
-		// Send notification
-		api.POST("/notifications/send", controllers.SendNotification)
+		// Send notification to any user; everyone else is notified by the system
+		api.POST("/notifications/send", middleware.RequireRole(models.RoleAdmin), controllers.SendNotification)
 
 		// Get notifications for a user
 		api.GET("/notifications/user/:userId", controllers.GetNotifications)
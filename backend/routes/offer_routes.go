@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/Aashishvatwani/homeworld/controllers"
+	"github.com/Aashishvatwani/homeworld/middleware"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/gin-gonic/gin"
+)
+
+func OfferRoutes(r *gin.Engine) {
+	api := r.Group("/api")
+	api.Use(middleware.AuthRequired(), middleware.RequireRole(models.RoleSolver))
+	{
+		api.GET("/offers", controllers.GetMyOffers)
+		api.POST("/offers/:id/accept", controllers.AcceptOffer)
+		api.POST("/offers/:id/decline", controllers.DeclineOffer)
+	}
+}
@@ -1,17 +1,26 @@
 ﻿package routes
 
 import (
+	"os"
+
 	"github.com/Aashishvatwani/homeworld/controllers"
+	"github.com/Aashishvatwani/homeworld/middleware"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/Aashishvatwani/homeworld/ratelimit"
 	"github.com/gin-gonic/gin"
 )
 
 func PaymentRoutes(r *gin.Engine) {
 	api := r.Group("/api")
+	api.Use(middleware.AuthRequired())
 	{
-		api.POST("/payment/create", controllers.CreatePayment)
+		api.POST("/payment/create", middleware.RateLimit(ratelimit.Payment), controllers.CreatePayment)
 		api.POST("/payment/verify", controllers.VerifyPayment)
 		api.GET("/payment/:id", controllers.GetPayment)
-		// Test helper endpoint (remove in production)
-		api.POST("/payment/generate-test-signature", controllers.GenerateTestSignature)
+		// Test helper endpoint. It signs any order/payment pair, so it is
+		// only registered in development and only admins may call it
+		if os.Getenv("RAZORPAY_TEST_SIGNATURES") == "true" {
+			api.POST("/payment/generate-test-signature", middleware.RequireRole(models.RoleAdmin), controllers.GenerateTestSignature)
+		}
 	}
 }
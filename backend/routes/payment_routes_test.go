@@ -0,0 +1,74 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/Aashishvatwani/homeworld/sessions"
+	"github.com/Aashishvatwani/homeworld/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// A buyer must not be able to mint Razorpay signatures, with or without the
+// development flag.
+func TestTestSignatureEndpointIsNotOpenToBuyers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config.JWTSecret = []byte("test-secret")
+
+	buyer := primitive.NewObjectID()
+	sessionID := primitive.NewObjectID()
+	token, err := utils.GenerateJWT(buyer, "buyer@example.com", models.RoleBuyer, sessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := bson.D{
+		{Key: "_id", Value: sessionID},
+		{Key: "userId", Value: buyer},
+		{Key: "expiresAt", Value: time.Now().Add(time.Hour)},
+	}
+
+	cases := []struct {
+		name string
+		flag string
+		want int
+	}{
+		{name: "flag unset", flag: "", want: http.StatusNotFound},
+		{name: "flag set", flag: "true", want: http.StatusForbidden},
+	}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	for _, tc := range cases {
+		mt.Run(tc.name, func(mt *mtest.T) {
+			t.Setenv("RAZORPAY_TEST_SIGNATURES", tc.flag)
+			config.DB = mt.Client.Database("test")
+			mt.AddMockResponses(mtest.CreateSuccessResponse()) // session indexes
+			sessions.Init()
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "test.sessions", mtest.FirstBatch, session))
+
+			r := gin.New()
+			PaymentRoutes(r)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/payment/generate-test-signature",
+				strings.NewReader(`{"order_id":"order_1","payment_id":"pay_forged"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+			r.ServeHTTP(w, req)
+
+			if w.Code != tc.want {
+				mt.Fatalf("status = %d, want %d; body %s", w.Code, tc.want, w.Body.String())
+			}
+			if strings.Contains(w.Body.String(), "signature") {
+				mt.Fatalf("response carries a signature: %s", w.Body.String())
+			}
+		})
+	}
+}
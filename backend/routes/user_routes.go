@@ -2,20 +2,46 @@
 
 import (
 	"github.com/Aashishvatwani/homeworld/controllers"
+	"github.com/Aashishvatwani/homeworld/middleware"
+	"github.com/Aashishvatwani/homeworld/ratelimit"
 	"github.com/gin-gonic/gin"
 )
 
 func UserRoutes(r *gin.Engine) {
+	// Public endpoints
+	public := r.Group("/api")
+	public.Use(middleware.RateLimit(ratelimit.Auth))
+	{
+		public.POST("/auth/register", controllers.RegisterUser)
+		public.POST("/auth/login", controllers.LoginUser)
+		public.POST("/auth/refresh", controllers.RefreshToken)
+		public.POST("/auth/verify-email/request", controllers.RequestEmailVerification)
+		public.POST("/auth/verify-email/confirm", controllers.ConfirmEmailVerification)
+		public.POST("/auth/password/forgot", controllers.ForgotPassword)
+		public.POST("/auth/password/reset", controllers.ResetPassword)
+		public.POST("/auth/2fa/verify", controllers.VerifyTwoFactorLogin)
+	}
+
 	api := r.Group("/api")
+	api.Use(middleware.AuthRequired())
 	{
-		api.POST("/auth/register", controllers.RegisterUser)
-		api.POST("/auth/login", controllers.LoginUser)
+		api.POST("/auth/logout", controllers.Logout)
+		api.POST("/auth/logout-all", controllers.LogoutAll)
+		api.POST("/auth/2fa/enroll", controllers.EnrollTwoFactor)
+		api.POST("/auth/2fa/enable", controllers.EnableTwoFactor)
+		api.POST("/auth/2fa/disable", controllers.DisableTwoFactor)
+		api.POST("/auth/2fa/recovery-codes", controllers.RegenerateRecoveryCodes)
 		api.GET("/users", controllers.GetAllUsers)
 		api.GET("/users/:id", controllers.GetUser)
 		// Accept both `/users/:id/update` and `/users/:id` for PUT to remain
 		// compatible with frontend code that may hit either path.
 		api.PUT("/users/:id/update", controllers.UpdateUser)
 		api.PUT("/users/:id", controllers.UpdateUser)
+		api.PUT("/users/:id/password", controllers.ChangePassword)
+		api.POST("/users/:id/roles/solver", controllers.EnableSolverRole)
+		api.GET("/users/:id/availability", controllers.GetAvailability)
+		api.PUT("/users/:id/availability", controllers.SetAvailability)
 		api.GET("/buyers/top", controllers.GetTopBuyers)
+		api.GET("/skills", controllers.ListSkills)
 	}
 }
@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoLease keeps one document per job: { _id: name, holder, expiresAt }.
+type MongoLease struct {
+	coll *mongo.Collection
+}
+
+func NewMongoLease(coll *mongo.Collection) *MongoLease {
+	return &MongoLease{coll: coll}
+}
+
+// Acquire upserts the lease if it is free, expired or already ours. When
+// another holder has it, the filter misses and the upsert collides with the
+// existing _id, which is reported as not acquired.
+func (m *MongoLease) Acquire(ctx context.Context, name, holder string, ttl time.Duration, now time.Time) (bool, error) {
+	_, err := m.coll.UpdateOne(ctx,
+		bson.M{"_id": name, "$or": bson.A{
+			bson.M{"holder": holder},
+			bson.M{"expiresAt": bson.M{"$lte": now}},
+		}},
+		bson.M{"$set": bson.M{"holder": holder, "expiresAt": now.Add(ttl), "acquiredAt": now}},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// RedisLease keeps one key per job holding the holder's name, expiring
+// after the lease TTL. Redis tracks expiry itself, so now is not used.
+type RedisLease struct {
+	client *redis.Client
+}
+
+func NewRedisLease(client *redis.Client) *RedisLease {
+	return &RedisLease{client: client}
+}
+
+// acquireScript sets the key when it is unset or already ours.
+var acquireScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if current and current ~= ARGV[1] then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+return 1
+`)
+
+func (r *RedisLease) Acquire(ctx context.Context, name, holder string, ttl time.Duration, now time.Time) (bool, error) {
+	n, err := acquireScript.Run(ctx, r.client, []string{"lease:" + name}, holder, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
@@ -0,0 +1,180 @@
+package scheduler
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+type fixedClock struct{ t time.Time }
+
+func (c *fixedClock) Now() time.Time { return c.t }
+
+// memoryLease has the semantics MongoLease and RedisLease implement, so
+// scheduler behaviour can be tested without a store.
+type memoryLease struct {
+	mu      sync.Mutex
+	holders map[string]string
+	expires map[string]time.Time
+}
+
+func newMemoryLease() *memoryLease {
+	return &memoryLease{holders: map[string]string{}, expires: map[string]time.Time{}}
+}
+
+func (m *memoryLease) Acquire(_ context.Context, name, holder string, ttl time.Duration, now time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if h, ok := m.holders[name]; ok && h != holder && now.Before(m.expires[name]) {
+		return false, nil
+	}
+	m.holders[name] = holder
+	m.expires[name] = now.Add(ttl)
+	return true, nil
+}
+
+func TestRunJobOnOneReplicaAtATime(t *testing.T) {
+	lease := newMemoryLease()
+	clock := &fixedClock{t: time.Date(2026, time.March, 4, 10, 0, 0, 0, time.UTC)}
+	a := New(lease, clock)
+	b := New(lease, clock)
+
+	var runs []time.Time
+	job := Job{Name: "test", Interval: time.Minute, Run: func(_ context.Context, now time.Time) error {
+		runs = append(runs, now)
+		return nil
+	}}
+
+	ctx := context.Background()
+	mustRun := func(s *Scheduler, want bool) {
+		t.Helper()
+		ran, err := s.RunJob(ctx, job)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ran != want {
+			t.Fatalf("%s ran = %v at %s, want %v", s.Holder, ran, clock.t.Format(time.RFC3339), want)
+		}
+	}
+
+	mustRun(a, true)
+	mustRun(b, false)
+	clock.t = clock.t.Add(time.Minute)
+	mustRun(a, true) // the holder renews on its next tick
+	mustRun(b, false)
+	clock.t = clock.t.Add(90 * time.Second)
+	mustRun(b, true) // a stopped renewing, so b takes over once the lease expires
+	mustRun(a, false)
+
+	if len(runs) != 3 || !runs[2].Equal(clock.t) {
+		t.Fatalf("runs = %v, want 3 ending at the clock's time %s", runs, clock.t)
+	}
+}
+
+func TestMongoLeaseContention(t *testing.T) {
+	now := time.Date(2026, time.March, 4, 10, 0, 0, 0, time.UTC)
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("held by another replica", func(mt *mtest.T) {
+		lease := NewMongoLease(mt.Coll)
+		ctx := context.Background()
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		if ok, err := lease.Acquire(ctx, "job", "a", time.Minute, now); err != nil || !ok {
+			mt.Fatalf("a: Acquire = %v, %v; want true", ok, err)
+		}
+
+		// b's filter misses a's unexpired lease, so its upsert hits the existing _id
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: "E11000 duplicate key error"}))
+		mt.ClearEvents()
+		if ok, err := lease.Acquire(ctx, "job", "b", time.Minute, now); err != nil || ok {
+			mt.Fatalf("b: Acquire = %v, %v; want false, nil", ok, err)
+		}
+		update := mt.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document()
+		filter := update.Lookup("q").Document()
+		if holder := filter.Lookup("$or", "0", "holder").StringValue(); holder != "b" {
+			mt.Fatalf("filter matches holder %q, want b", holder)
+		}
+		if expiry := filter.Lookup("$or", "1", "expiresAt", "$lte").Time(); !expiry.Equal(now) {
+			mt.Fatalf("filter takes over leases expired by %s, want %s", expiry, now)
+		}
+		if !update.Lookup("upsert").Boolean() {
+			mt.Fatalf("Acquire must upsert so a free lease can be taken")
+		}
+
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 91, Message: "shutting down"}))
+		if ok, err := lease.Acquire(ctx, "job", "b", time.Minute, now); err == nil || ok {
+			mt.Fatalf("b: Acquire = %v, %v; want the store error", ok, err)
+		}
+	})
+}
+
+// TestRedisLeaseContention runs against the Redis at REDIS_URL.
+func TestRedisLeaseContention(t *testing.T) {
+	url := os.Getenv("REDIS_URL")
+	if url == "" {
+		t.Skip("REDIS_URL not set")
+	}
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := redis.NewClient(opts)
+	defer client.Close()
+
+	ctx := context.Background()
+	name := "test-contention-" + newHolder()
+	defer client.Del(ctx, "lease:"+name)
+	lease := NewRedisLease(client)
+	ttl := 300 * time.Millisecond
+
+	acquire := func(holder string, want bool) {
+		t.Helper()
+		ok, err := lease.Acquire(ctx, name, holder, ttl, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != want {
+			t.Fatalf("%s: Acquire = %v, want %v", holder, ok, want)
+		}
+	}
+
+	acquire("a", true)
+	acquire("b", false)
+	acquire("a", true)
+	time.Sleep(ttl + 100*time.Millisecond)
+	acquire("b", true)
+	acquire("a", false)
+
+	// Concurrent attempts on a free lease: exactly one wins
+	client.Del(ctx, "lease:"+name)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	winners := 0
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(holder string) {
+			defer wg.Done()
+			ok, err := lease.Acquire(ctx, name, holder, time.Minute, time.Now())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if ok {
+				mu.Lock()
+				winners++
+				mu.Unlock()
+			}
+		}(newHolder())
+	}
+	wg.Wait()
+	if winners != 1 {
+		t.Fatalf("%d replicas acquired the lease, want 1", winners)
+	}
+}
@@ -0,0 +1,145 @@
+package scheduler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/config"
+)
+
+// Clock tells jobs the time. Tests substitute a fixed or stepped clock.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// SystemClock is the wall clock.
+var SystemClock Clock = systemClock{}
+
+// Job is a periodic task. Run receives the scheduler clock's time and must
+// be idempotent: after a crash or an expired lease the same work can be
+// attempted again, possibly by another replica.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context, now time.Time) error
+}
+
+// Lease lets one replica at a time run a job. Implementations must make
+// Acquire atomic across replicas.
+type Lease interface {
+	// Acquire takes or renews the lease on name for holder until now+ttl. It
+	// returns false while another holder's lease is unexpired.
+	Acquire(ctx context.Context, name, holder string, ttl time.Duration, now time.Time) (bool, error)
+}
+
+// Scheduler runs registered jobs on their intervals, each guarded by a lease.
+type Scheduler struct {
+	Lease  Lease
+	Clock  Clock
+	Holder string
+
+	mu   sync.Mutex
+	jobs []Job
+}
+
+// New returns a scheduler identified by a random holder name.
+func New(lease Lease, clock Clock) *Scheduler {
+	return &Scheduler{Lease: lease, Clock: clock, Holder: newHolder()}
+}
+
+func newHolder() string {
+	host, _ := os.Hostname()
+	b := make([]byte, 4)
+	rand.Read(b)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b))
+}
+
+func (s *Scheduler) Register(jobs ...Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, jobs...)
+}
+
+// RunJob runs job once if this replica holds, or can take, its lease. It
+// reports whether the job ran. The lease outlives the interval by half so
+// the current holder renews it before another replica can take over.
+func (s *Scheduler) RunJob(ctx context.Context, job Job) (bool, error) {
+	now := s.Clock.Now()
+	ok, err := s.Lease.Acquire(ctx, job.Name, s.Holder, job.Interval+job.Interval/2, now)
+	if err != nil || !ok {
+		return false, err
+	}
+	return true, job.Run(ctx, now)
+}
+
+// Start runs every registered job on its own ticker until ctx is cancelled.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	jobs := append([]Job(nil), s.jobs...)
+	s.mu.Unlock()
+
+	for _, job := range jobs {
+		go s.loop(ctx, job)
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+	for {
+		s.tick(ctx, job)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context, job Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[scheduler] job %q panicked: %v", job.Name, r)
+		}
+	}()
+	runCtx, cancel := context.WithTimeout(ctx, job.Interval)
+	defer cancel()
+	if _, err := s.RunJob(runCtx, job); err != nil {
+		log.Printf("[scheduler] job %q failed: %v", job.Name, err)
+	}
+}
+
+var defaultScheduler *Scheduler
+
+// Init picks the lease store: Redis when config.Redis is set, otherwise the
+// MongoDB "scheduler_leases" collection.
+func Init() {
+	if config.Redis != nil {
+		defaultScheduler = New(NewRedisLease(config.Redis), SystemClock)
+		log.Println("Scheduler lease: redis")
+		return
+	}
+	defaultScheduler = New(NewMongoLease(config.DB.Collection("scheduler_leases")), SystemClock)
+	log.Println("Scheduler lease: mongo")
+}
+
+// Start registers jobs with the default scheduler and starts it. Setting
+// SCHEDULER_DISABLED=true keeps a replica from running jobs at all.
+func Start(ctx context.Context, jobs ...Job) {
+	if os.Getenv("SCHEDULER_DISABLED") == "true" {
+		log.Println("Scheduler disabled")
+		return
+	}
+	defaultScheduler.Register(jobs...)
+	defaultScheduler.Start(ctx)
+}
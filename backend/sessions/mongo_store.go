@@ -0,0 +1,86 @@
+package sessions
+
+import (
+	"context"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore keeps sessions in a MongoDB collection.
+type MongoStore struct {
+	coll *mongo.Collection
+}
+
+func NewMongoStore(coll *mongo.Collection) *MongoStore {
+	return &MongoStore{coll: coll}
+}
+
+// EnsureIndexes creates the user lookup index and a TTL index that lets
+// MongoDB drop sessions once their refresh token has expired.
+func (m *MongoStore) EnsureIndexes(ctx context.Context) error {
+	_, err := m.coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}}},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return err
+}
+
+func (m *MongoStore) Create(ctx context.Context, s *models.Session) error {
+	_, err := m.coll.InsertOne(ctx, s)
+	return err
+}
+
+func (m *MongoStore) Get(ctx context.Context, id primitive.ObjectID) (*models.Session, error) {
+	var s models.Session
+	if err := m.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&s); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (m *MongoStore) Rotate(ctx context.Context, id primitive.ObjectID, oldHash, newHash, activeRole string, now, expiresAt time.Time) (bool, error) {
+	result, err := m.coll.UpdateOne(ctx,
+		bson.M{"_id": id, "tokenHash": oldHash, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{
+			"tokenHash":  newHash,
+			"activeRole": activeRole,
+			"lastUsedAt": now,
+			"expiresAt":  expiresAt,
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+func (m *MongoStore) Revoke(ctx context.Context, id primitive.ObjectID, reason string, now time.Time) error {
+	_, err := m.coll.UpdateOne(ctx,
+		bson.M{"_id": id, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revokedAt": now, "revokeReason": reason}},
+	)
+	return err
+}
+
+func (m *MongoStore) RevokeAllForUser(ctx context.Context, userID, exceptID primitive.ObjectID, reason string, now time.Time) (int64, error) {
+	filter := bson.M{"userId": userID, "revokedAt": bson.M{"$exists": false}}
+	if !exceptID.IsZero() {
+		filter["_id"] = bson.M{"$ne": exceptID}
+	}
+	result, err := m.coll.UpdateMany(ctx,
+		filter,
+		bson.M{"$set": bson.M{"revokedAt": now, "revokeReason": reason}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
@@ -0,0 +1,150 @@
+package sessions
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/models"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RedisStore keeps each session in a hash at "session:<id>" and indexes a
+// user's sessions in the set "user_sessions:<userId>". Keys expire with the
+// refresh token.
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// rotateScript performs the compare-and-swap of the refresh token hash.
+var rotateScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then return 0 end
+if redis.call('HGET', KEYS[1], 'revokedAt') ~= false then return 0 end
+if redis.call('HGET', KEYS[1], 'tokenHash') ~= ARGV[1] then return 0 end
+redis.call('HSET', KEYS[1], 'tokenHash', ARGV[2], 'activeRole', ARGV[3], 'lastUsedAt', ARGV[4], 'expiresAt', ARGV[5])
+redis.call('PEXPIREAT', KEYS[1], ARGV[5])
+return 1
+`)
+
+func sessionKey(id primitive.ObjectID) string {
+	return "session:" + id.Hex()
+}
+
+func userSessionsKey(userID primitive.ObjectID) string {
+	return "user_sessions:" + userID.Hex()
+}
+
+func (r *RedisStore) Create(ctx context.Context, s *models.Session) error {
+	pipe := r.client.TxPipeline()
+	pipe.HSet(ctx, sessionKey(s.ID), map[string]interface{}{
+		"userId":     s.UserID.Hex(),
+		"tokenHash":  s.TokenHash,
+		"activeRole": s.ActiveRole,
+		"userAgent":  s.UserAgent,
+		"ip":         s.IP,
+		"createdAt":  s.CreatedAt.UnixMilli(),
+		"lastUsedAt": s.LastUsedAt.UnixMilli(),
+		"expiresAt":  s.ExpiresAt.UnixMilli(),
+	})
+	pipe.ExpireAt(ctx, sessionKey(s.ID), s.ExpiresAt)
+	pipe.SAdd(ctx, userSessionsKey(s.UserID), s.ID.Hex())
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisStore) Get(ctx context.Context, id primitive.ObjectID) (*models.Session, error) {
+	fields, err := r.client.HGetAll(ctx, sessionKey(id)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, ErrNotFound
+	}
+
+	userID, _ := primitive.ObjectIDFromHex(fields["userId"])
+	s := &models.Session{
+		ID:           id,
+		UserID:       userID,
+		TokenHash:    fields["tokenHash"],
+		ActiveRole:   fields["activeRole"],
+		UserAgent:    fields["userAgent"],
+		IP:           fields["ip"],
+		CreatedAt:    millisToTime(fields["createdAt"]),
+		LastUsedAt:   millisToTime(fields["lastUsedAt"]),
+		ExpiresAt:    millisToTime(fields["expiresAt"]),
+		RevokedAt:    millisToTime(fields["revokedAt"]),
+		RevokeReason: fields["revokeReason"],
+	}
+	return s, nil
+}
+
+func (r *RedisStore) Rotate(ctx context.Context, id primitive.ObjectID, oldHash, newHash, activeRole string, now, expiresAt time.Time) (bool, error) {
+	n, err := rotateScript.Run(ctx, r.client, []string{sessionKey(id)},
+		oldHash, newHash, activeRole, now.UnixMilli(), expiresAt.UnixMilli()).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (r *RedisStore) Revoke(ctx context.Context, id primitive.ObjectID, reason string, now time.Time) error {
+	key := sessionKey(id)
+	exists, err := r.client.Exists(ctx, key).Result()
+	if err != nil || exists == 0 {
+		return err
+	}
+	// HSETNX keeps the first revocation time and reason
+	pipe := r.client.TxPipeline()
+	pipe.HSetNX(ctx, key, "revokedAt", now.UnixMilli())
+	pipe.HSetNX(ctx, key, "revokeReason", reason)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisStore) RevokeAllForUser(ctx context.Context, userID, exceptID primitive.ObjectID, reason string, now time.Time) (int64, error) {
+	ids, err := r.client.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	var revoked int64
+	for _, hexID := range ids {
+		id, err := primitive.ObjectIDFromHex(hexID)
+		if err != nil || id == exceptID {
+			continue
+		}
+		s, err := r.Get(ctx, id)
+		if err == ErrNotFound {
+			// Expired; drop it from the index
+			r.client.SRem(ctx, userSessionsKey(userID), hexID)
+			continue
+		}
+		if err != nil {
+			return revoked, err
+		}
+		if !s.RevokedAt.IsZero() {
+			continue
+		}
+		if err := r.Revoke(ctx, id, reason, now); err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+	return revoked, nil
+}
+
+func millisToTime(v string) time.Time {
+	if v == "" {
+		return time.Time{}
+	}
+	ms, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
@@ -0,0 +1,216 @@
+package sessions
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrNotFound     = errors.New("session not found")
+	ErrInvalidToken = errors.New("invalid refresh token")
+	ErrRevoked      = errors.New("session has been revoked or has expired")
+	ErrTokenReused  = errors.New("refresh token reuse detected; session revoked")
+)
+
+// Revocation reasons recorded on the session
+const (
+	ReasonLogout         = "logout"
+	ReasonLogoutAll      = "logout_all"
+	ReasonReuseDetected  = "reuse_detected"
+	ReasonPasswordChange = "password_change"
+)
+
+// Store persists sessions. Implementations must make Rotate atomic so that
+// two concurrent refreshes with the same token cannot both succeed.
+type Store interface {
+	Create(ctx context.Context, s *models.Session) error
+	Get(ctx context.Context, id primitive.ObjectID) (*models.Session, error)
+	// Rotate replaces oldHash with newHash, returning false if oldHash is no
+	// longer the current hash or the session is revoked.
+	Rotate(ctx context.Context, id primitive.ObjectID, oldHash, newHash, activeRole string, now, expiresAt time.Time) (bool, error)
+	Revoke(ctx context.Context, id primitive.ObjectID, reason string, now time.Time) error
+	// RevokeAllForUser revokes every active session of the user except
+	// exceptID, which may be primitive.NilObjectID.
+	RevokeAllForUser(ctx context.Context, userID, exceptID primitive.ObjectID, reason string, now time.Time) (int64, error)
+}
+
+var store Store
+
+// Init selects the session store. SESSION_STORE=redis uses config.Redis;
+// anything else uses the MongoDB "sessions" collection.
+func Init() {
+	if strings.EqualFold(os.Getenv("SESSION_STORE"), "redis") {
+		if config.Redis == nil {
+			log.Fatal("SESSION_STORE=redis requires REDIS_URL")
+		}
+		store = NewRedisStore(config.Redis)
+		log.Println("Session store: redis")
+		return
+	}
+
+	mongoStore := NewMongoStore(config.DB.Collection("sessions"))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := mongoStore.EnsureIndexes(ctx); err != nil {
+		log.Printf("Failed to create session indexes: %v", err)
+	}
+	store = mongoStore
+	log.Println("Session store: mongo")
+}
+
+// Start creates a new session and returns it with its first refresh token.
+func Start(ctx context.Context, userID primitive.ObjectID, activeRole, userAgent, ip string) (*models.Session, string, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	s := &models.Session{
+		ID:         primitive.NewObjectID(),
+		UserID:     userID,
+		TokenHash:  hashSecret(secret),
+		ActiveRole: activeRole,
+		UserAgent:  userAgent,
+		IP:         ip,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(config.RefreshTokenTTL),
+	}
+	if err := store.Create(ctx, s); err != nil {
+		return nil, "", err
+	}
+	return s, formatToken(s.ID, secret), nil
+}
+
+// Validate checks a refresh token against its session. Presenting a token
+// that has already been rotated away revokes the session, since it means
+// the token family has leaked.
+func Validate(ctx context.Context, refreshToken string) (*models.Session, error) {
+	id, secret, err := parseToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := store.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+	if !s.IsActive(time.Now()) {
+		return nil, ErrRevoked
+	}
+	if s.TokenHash != hashSecret(secret) {
+		revokeForReuse(ctx, s.ID)
+		return nil, ErrTokenReused
+	}
+	return s, nil
+}
+
+// Rotate issues a new refresh token for a session previously returned by
+// Validate. If another request rotated the same token first, the session is
+// revoked as a reuse.
+func Rotate(ctx context.Context, s *models.Session, refreshToken, activeRole string) (string, error) {
+	_, oldSecret, err := parseToken(refreshToken)
+	if err != nil {
+		return "", err
+	}
+	secret, err := newSecret()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(config.RefreshTokenTTL)
+	ok, err := store.Rotate(ctx, s.ID, hashSecret(oldSecret), hashSecret(secret), activeRole, now, expiresAt)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		revokeForReuse(ctx, s.ID)
+		return "", ErrTokenReused
+	}
+
+	s.ActiveRole = activeRole
+	s.LastUsedAt = now
+	s.ExpiresAt = expiresAt
+	return formatToken(s.ID, secret), nil
+}
+
+// IsActive reports whether the session behind an access token is still valid.
+func IsActive(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	s, err := store.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return s.IsActive(time.Now()), nil
+}
+
+// Revoke ends a single session.
+func Revoke(ctx context.Context, id primitive.ObjectID, reason string) error {
+	return store.Revoke(ctx, id, reason, time.Now())
+}
+
+// RevokeAll ends every session belonging to a user.
+func RevokeAll(ctx context.Context, userID primitive.ObjectID, reason string) (int64, error) {
+	return store.RevokeAllForUser(ctx, userID, primitive.NilObjectID, reason, time.Now())
+}
+
+// RevokeAllExcept ends every session belonging to a user other than keepID.
+func RevokeAllExcept(ctx context.Context, userID, keepID primitive.ObjectID, reason string) (int64, error) {
+	return store.RevokeAllForUser(ctx, userID, keepID, reason, time.Now())
+}
+
+func revokeForReuse(ctx context.Context, id primitive.ObjectID) {
+	log.Printf("[sessions] refresh token reuse detected, revoking session %s", id.Hex())
+	if err := store.Revoke(ctx, id, ReasonReuseDetected, time.Now()); err != nil {
+		log.Printf("[sessions] failed to revoke session %s: %v", id.Hex(), err)
+	}
+}
+
+// Refresh tokens look like "<sessionId>.<secret>"; only a hash of the secret is stored.
+func formatToken(id primitive.ObjectID, secret string) string {
+	return id.Hex() + "." + secret
+}
+
+func parseToken(token string) (primitive.ObjectID, string, error) {
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return primitive.NilObjectID, "", ErrInvalidToken
+	}
+	id, err := primitive.ObjectIDFromHex(parts[0])
+	if err != nil {
+		return primitive.NilObjectID, "", ErrInvalidToken
+	}
+	return id, parts[1], nil
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,246 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryStore keeps sessions in a map, with the same compare-and-swap
+// semantics the mongo and redis stores give Rotate.
+type memoryStore struct {
+	mu       sync.Mutex
+	sessions map[primitive.ObjectID]models.Session
+}
+
+func useMemoryStore(t *testing.T) *memoryStore {
+	t.Helper()
+	m := &memoryStore{sessions: map[primitive.ObjectID]models.Session{}}
+	prev := store
+	store = m
+	t.Cleanup(func() { store = prev })
+	return m
+}
+
+func (m *memoryStore) Create(ctx context.Context, s *models.Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[s.ID] = *s
+	return nil
+}
+
+func (m *memoryStore) Get(ctx context.Context, id primitive.ObjectID) (*models.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &s, nil
+}
+
+func (m *memoryStore) Rotate(ctx context.Context, id primitive.ObjectID, oldHash, newHash, activeRole string, now, expiresAt time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	if !ok || s.TokenHash != oldHash || !s.RevokedAt.IsZero() {
+		return false, nil
+	}
+	s.TokenHash = newHash
+	s.ActiveRole = activeRole
+	s.LastUsedAt = now
+	s.ExpiresAt = expiresAt
+	m.sessions[id] = s
+	return true, nil
+}
+
+func (m *memoryStore) Revoke(ctx context.Context, id primitive.ObjectID, reason string, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s, ok := m.sessions[id]; ok && s.RevokedAt.IsZero() {
+		s.RevokedAt = now
+		s.RevokeReason = reason
+		m.sessions[id] = s
+	}
+	return nil
+}
+
+func (m *memoryStore) RevokeAllForUser(ctx context.Context, userID, exceptID primitive.ObjectID, reason string, now time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int64
+	for id, s := range m.sessions {
+		if s.UserID != userID || id == exceptID || !s.RevokedAt.IsZero() {
+			continue
+		}
+		s.RevokedAt = now
+		s.RevokeReason = reason
+		m.sessions[id] = s
+		n++
+	}
+	return n, nil
+}
+
+func (m *memoryStore) session(id primitive.ObjectID) models.Session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sessions[id]
+}
+
+// refresh validates and rotates a token the way the refresh endpoint does.
+func refresh(ctx context.Context, token string) (string, error) {
+	s, err := Validate(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	return Rotate(ctx, s, token, s.ActiveRole)
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	m := useMemoryStore(t)
+	ctx := context.Background()
+
+	s, first, err := Start(ctx, primitive.NewObjectID(), models.RoleBuyer, "test", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	firstHash := m.session(s.ID).TokenHash
+
+	second, err := refresh(ctx, first)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if second == first {
+		t.Fatal("refresh returned the same token")
+	}
+	_, secret, _ := parseToken(second)
+	if got := m.session(s.ID).TokenHash; got == firstHash || got != hashSecret(secret) {
+		t.Fatal("stored hash was not rotated to the new token")
+	}
+
+	third, err := refresh(ctx, second)
+	if err != nil {
+		t.Fatalf("refresh with the rotated token: %v", err)
+	}
+	if active, _ := IsActive(ctx, s.ID); !active || third == "" {
+		t.Fatal("session should stay active across rotations")
+	}
+}
+
+func TestReusedTokenRevokesSession(t *testing.T) {
+	m := useMemoryStore(t)
+	ctx := context.Background()
+
+	s, stale, err := Start(ctx, primitive.NewObjectID(), models.RoleBuyer, "test", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := refresh(ctx, stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := refresh(ctx, stale); !errors.Is(err, ErrTokenReused) {
+		t.Fatalf("refresh with a rotated-away token: err = %v, want ErrTokenReused", err)
+	}
+	if got := m.session(s.ID); got.RevokedAt.IsZero() || got.RevokeReason != ReasonReuseDetected {
+		t.Fatalf("session revoked at %v for %q, want revoked for %q", got.RevokedAt, got.RevokeReason, ReasonReuseDetected)
+	}
+	// The whole family is gone, including the legitimate latest token
+	if _, err := refresh(ctx, current); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("refresh with the latest token: err = %v, want ErrRevoked", err)
+	}
+}
+
+func TestConcurrentRotationRevokesSession(t *testing.T) {
+	m := useMemoryStore(t)
+	ctx := context.Background()
+
+	s, token, err := Start(ctx, primitive.NewObjectID(), models.RoleBuyer, "test", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Both requests validate before either rotates
+	a, err := Validate(ctx, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Validate(ctx, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Rotate(ctx, a, token, a.ActiveRole); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Rotate(ctx, b, token, b.ActiveRole); !errors.Is(err, ErrTokenReused) {
+		t.Fatalf("second rotation: err = %v, want ErrTokenReused", err)
+	}
+	if got := m.session(s.ID).RevokeReason; got != ReasonReuseDetected {
+		t.Fatalf("revoke reason = %q, want %q", got, ReasonReuseDetected)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	m := useMemoryStore(t)
+	ctx := context.Background()
+	user := primitive.NewObjectID()
+
+	phone, phoneToken, err := Start(ctx, user, models.RoleBuyer, "phone", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	laptop, laptopToken, err := Start(ctx, user, models.RoleBuyer, "laptop", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tablet, _, err := Start(ctx, user, models.RoleBuyer, "tablet", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Revoke(ctx, phone.ID, ReasonLogout); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := refresh(ctx, phoneToken); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("refresh after logout: err = %v, want ErrRevoked", err)
+	}
+	if active, _ := IsActive(ctx, phone.ID); active {
+		t.Fatal("logged out session is still active")
+	}
+	if got := m.session(phone.ID).RevokeReason; got != ReasonLogout {
+		t.Fatalf("revoke reason = %q, want %q", got, ReasonLogout)
+	}
+	if active, _ := IsActive(ctx, laptop.ID); !active {
+		t.Fatal("logout ended the user's other sessions")
+	}
+
+	n, err := RevokeAllExcept(ctx, user, laptop.ID, ReasonLogoutAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("revoked %d sessions, want only the tablet", n)
+	}
+	if active, _ := IsActive(ctx, tablet.ID); active {
+		t.Fatal("tablet session is still active")
+	}
+	if _, err := refresh(ctx, laptopToken); err != nil {
+		t.Fatalf("kept session cannot refresh: %v", err)
+	}
+}
+
+func TestValidateRejectsMalformedTokens(t *testing.T) {
+	useMemoryStore(t)
+	ctx := context.Background()
+
+	for _, token := range []string{"", "nodot", primitive.NewObjectID().Hex() + ".", "zz.secret", primitive.NewObjectID().Hex() + ".secret"} {
+		if _, err := Validate(ctx, token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Validate(%q) err = %v, want ErrInvalidToken", token, err)
+		}
+	}
+}
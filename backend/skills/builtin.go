@@ -0,0 +1,38 @@
+package skills
+
+import "github.com/Aashishvatwani/homeworld/models"
+
+// Builtin is the taxonomy seeded into an empty skills collection and used
+// until the stored one is loaded. Canonical names match the labels returned
+// by the NLP service.
+func Builtin() []models.Skill {
+	return []models.Skill{
+		{ID: "machine-learning", Name: "Machine Learning", Aliases: []string{"ml", "scikit-learn", "sklearn"}},
+		{ID: "deep-learning", Name: "Deep Learning", Aliases: []string{"dl", "neural networks", "keras"}, ParentID: "machine-learning"},
+		{ID: "pytorch", Name: "PyTorch", Aliases: []string{"torch"}, ParentID: "deep-learning"},
+		{ID: "tensorflow", Name: "TensorFlow", Aliases: []string{"tf"}, ParentID: "deep-learning"},
+		{ID: "natural-language-processing", Name: "Natural Language Processing", Aliases: []string{"nlp", "huggingface"}, ParentID: "machine-learning"},
+		{ID: "computer-vision", Name: "Computer Vision", Aliases: []string{"cv", "opencv", "image processing"}, ParentID: "machine-learning"},
+		{ID: "data-science", Name: "Data Science", Aliases: []string{"data analysis", "eda"}},
+		{ID: "python", Name: "Python", Aliases: []string{"numpy", "pandas"}},
+		{ID: "data-engineering", Name: "Data Engineering", Aliases: []string{"spark", "pyspark", "hadoop", "etl", "airflow"}},
+		{ID: "cloud", Name: "Cloud", Aliases: []string{"aws", "azure", "gcp", "google cloud"}},
+		{ID: "devops", Name: "DevOps", Aliases: []string{"docker", "kubernetes", "k8s", "ci/cd"}},
+		{ID: "databases", Name: "Databases", Aliases: []string{"database", "sql", "mysql", "postgres", "postgresql", "mongodb", "nosql"}},
+		{ID: "web", Name: "Web", Aliases: []string{"html", "css", "javascript", "js", "node", "typescript", "django", "flask"}},
+		{ID: "react", Name: "React", Aliases: []string{"reactjs", "react.js"}, ParentID: "web"},
+		{ID: "electronics", Name: "Electronics", Aliases: []string{"circuit", "pcb", "proteus", "multisim"}},
+		{ID: "embedded", Name: "Embedded", Aliases: []string{"arduino", "esp32", "raspberry pi", "microcontroller", "firmware"}, ParentID: "electronics"},
+		{ID: "matlab", Name: "Matlab", Aliases: []string{"simulink"}},
+		{ID: "latex", Name: "LaTeX", Aliases: []string{"tex"}},
+		{ID: "cplusplus", Name: "C++", Aliases: []string{"cpp"}},
+		{ID: "java", Name: "Java", Aliases: []string{"spring", "spring boot"}},
+		{ID: "r", Name: "R", Aliases: []string{"r programming", "tidyverse", "ggplot2"}},
+		{ID: "excel", Name: "Excel", Aliases: []string{"vba", "spreadsheet"}},
+		{ID: "mobile", Name: "Mobile", Aliases: []string{"android", "ios", "flutter", "react native", "swift", "kotlin"}},
+		{ID: "security", Name: "Security", Aliases: []string{"cybersecurity", "penetration testing"}},
+		{ID: "blockchain", Name: "Blockchain", Aliases: []string{"solidity", "smart contracts", "ethereum", "web3"}},
+		{ID: "golang", Name: "Golang", Aliases: []string{"go"}},
+		{ID: "writing", Name: "Writing", Aliases: []string{"essay", "report writing", "content writing"}},
+	}
+}
@@ -0,0 +1,406 @@
+// Package skills holds the skill taxonomy: canonical skills with their
+// aliases and parent/child relations, e.g. PyTorch -> Deep Learning ->
+// Machine Learning. Free-form skills from users, buyers and the NLP service
+// are normalised against it, and matching uses the hierarchy to give
+// partial credit for related skills.
+package skills
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"unicode"
+
+	"github.com/Aashishvatwani/homeworld/models"
+)
+
+// Credit Similarity gives related skills. A narrower skill covers most of
+// a broader one (PyTorch for Machine Learning); a broader one covers less of
+// a narrower one. Both fall off with each level between them.
+const (
+	descendantCredit = 0.8
+	ancestorCredit   = 0.5
+	siblingCredit    = 0.4
+)
+
+const (
+	// maxDepth bounds the hierarchy, which also keeps walks up it finite.
+	maxDepth = 8
+	// minExtractLength skips short aliases such as "go" and "r" when
+	// scanning prose, where they are mostly ordinary words.
+	minExtractLength = 3
+)
+
+var idPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// Registry is an immutable, validated snapshot of the taxonomy. It is safe
+// for concurrent use.
+type Registry struct {
+	skills  map[string]models.Skill
+	lookup  map[string]string // key of a name or alias -> skill ID
+	phrases []phrase          // names and aliases as they appear in prose
+}
+
+type phrase struct {
+	text string
+	id   string
+}
+
+// Key is the form names and aliases are compared in: lower case with
+// whitespace collapsed.
+func Key(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// Slug derives a skill ID from its name, e.g. "C++" -> "cplusplus".
+func Slug(name string) string {
+	var words []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r == '+':
+			word.WriteString("plus")
+		case r == '#':
+			word.WriteString("sharp")
+		case r <= unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word.WriteRune(r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return strings.Join(words, "-")
+}
+
+// ValidID reports whether id is a usable skill ID: lower-case letters and
+// digits separated by single dashes.
+func ValidID(id string) bool {
+	return len(id) <= 100 && idPattern.MatchString(id)
+}
+
+// Build validates skills and indexes them. IDs must be unique, no name or
+// alias may belong to two skills, and parents must exist without forming a
+// cycle or nesting deeper than maxDepth.
+func Build(list []models.Skill) (*Registry, error) {
+	r := &Registry{
+		skills: make(map[string]models.Skill, len(list)),
+		lookup: make(map[string]string),
+	}
+	for _, s := range list {
+		if !ValidID(s.ID) {
+			return nil, fmt.Errorf("invalid skill ID %q", s.ID)
+		}
+		if Key(s.Name) == "" {
+			return nil, fmt.Errorf("skill %s has no name", s.ID)
+		}
+		if _, dup := r.skills[s.ID]; dup {
+			return nil, fmt.Errorf("duplicate skill ID %s", s.ID)
+		}
+		r.skills[s.ID] = s
+
+		for _, k := range append([]string{s.Name}, s.Aliases...) {
+			k = Key(k)
+			if k == "" {
+				continue
+			}
+			if other, taken := r.lookup[k]; taken && other != s.ID {
+				return nil, fmt.Errorf("%q is used by both %s and %s", k, other, s.ID)
+			}
+			r.lookup[k] = s.ID
+		}
+	}
+
+	for _, s := range list {
+		if s.ParentID == "" {
+			continue
+		}
+		if _, ok := r.skills[s.ParentID]; !ok {
+			return nil, fmt.Errorf("skill %s has unknown parent %s", s.ID, s.ParentID)
+		}
+		depth := 0
+		for id := s.ParentID; id != ""; id = r.skills[id].ParentID {
+			if id == s.ID {
+				return nil, fmt.Errorf("skill %s is its own ancestor", s.ID)
+			}
+			if depth++; depth > maxDepth {
+				return nil, fmt.Errorf("skill %s is nested more than %d levels deep", s.ID, maxDepth)
+			}
+		}
+	}
+
+	for k, id := range r.lookup {
+		text := strings.Join(strings.FieldsFunc(k, isSeparator), " ")
+		if len(text) >= minExtractLength {
+			r.phrases = append(r.phrases, phrase{text: text, id: id})
+		}
+	}
+	// Longer phrases first so "react native" is found before "react"
+	sort.Slice(r.phrases, func(i, j int) bool {
+		if len(r.phrases[i].text) != len(r.phrases[j].text) {
+			return len(r.phrases[i].text) > len(r.phrases[j].text)
+		}
+		return r.phrases[i].text < r.phrases[j].text
+	})
+	return r, nil
+}
+
+// List returns every skill, sorted by name.
+func (r *Registry) List() []models.Skill {
+	list := make([]models.Skill, 0, len(r.skills))
+	for _, s := range r.skills {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Get returns the skill with the given ID.
+func (r *Registry) Get(id string) (models.Skill, bool) {
+	s, ok := r.skills[id]
+	return s, ok
+}
+
+// Children returns the IDs of the skills directly under id, sorted.
+func (r *Registry) Children(id string) []string {
+	var children []string
+	for _, s := range r.skills {
+		if s.ParentID == id {
+			children = append(children, s.ID)
+		}
+	}
+	sort.Strings(children)
+	return children
+}
+
+// Lookup finds the skill a free-form name or alias refers to.
+func (r *Registry) Lookup(skill string) (models.Skill, bool) {
+	id, ok := r.lookup[Key(skill)]
+	if !ok {
+		return models.Skill{}, false
+	}
+	return r.skills[id], true
+}
+
+// Normalize maps a free-form skill to its canonical name.
+func (r *Registry) Normalize(skill string) (string, bool) {
+	s, ok := r.Lookup(skill)
+	return s.Name, ok
+}
+
+// NormalizeAll canonicalises and de-duplicates skills, returning any
+// entries that are not in the taxonomy separately.
+func (r *Registry) NormalizeAll(skills []string) (normalized []string, unknown []string) {
+	seen := make(map[string]bool)
+	for _, s := range skills {
+		canonical, ok := r.Normalize(s)
+		if !ok {
+			unknown = append(unknown, s)
+			continue
+		}
+		if !seen[canonical] {
+			seen[canonical] = true
+			normalized = append(normalized, canonical)
+		}
+	}
+	return normalized, unknown
+}
+
+// Canonicalize is NormalizeAll for input that may name skills outside the
+// taxonomy: unknown entries are kept, trimmed, rather than rejected. The
+// result is never nil.
+func (r *Registry) Canonicalize(skills []string) []string {
+	out := make([]string, 0, len(skills))
+	seen := make(map[string]bool)
+	for _, s := range skills {
+		name, ok := r.Normalize(s)
+		if !ok {
+			name = strings.Join(strings.Fields(s), " ")
+		}
+		if k := Key(name); k != "" && !seen[k] {
+			seen[k] = true
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// levelsUp is how many levels above id the skill ancestor is, or -1 when
+// it is not an ancestor.
+func (r *Registry) levelsUp(id, ancestor string) int {
+	levels := 0
+	for p := r.skills[id].ParentID; p != "" && levels < maxDepth; p = r.skills[p].ParentID {
+		levels++
+		if p == ancestor {
+			return levels
+		}
+	}
+	return -1
+}
+
+// Similarity is how well having skill held covers skill required, in
+// [0, 1]: 1 for the same skill, partial credit along the hierarchy, and 0
+// for unrelated skills. Skills outside the taxonomy only match themselves.
+func (r *Registry) Similarity(required, held string) float64 {
+	req, reqOK := r.Lookup(required)
+	has, hasOK := r.Lookup(held)
+	if !reqOK || !hasOK {
+		if k := Key(required); k != "" && k == Key(held) {
+			return 1
+		}
+		return 0
+	}
+
+	switch {
+	case req.ID == has.ID:
+		return 1
+	case req.ParentID != "" && req.ParentID == has.ParentID:
+		return siblingCredit
+	}
+	if n := r.levelsUp(has.ID, req.ID); n > 0 {
+		return pow(descendantCredit, n)
+	}
+	if n := r.levelsUp(req.ID, has.ID); n > 0 {
+		return pow(ancestorCredit, n)
+	}
+	return 0
+}
+
+func pow(base float64, n int) float64 {
+	v := 1.0
+	for i := 0; i < n; i++ {
+		v *= base
+	}
+	return v
+}
+
+// Related returns the canonical names of skills, their ancestors and their
+// descendants: every skill Similarity gives credit to, except siblings.
+// Unknown entries are passed through.
+func (r *Registry) Related(skills []string) []string {
+	selected := make(map[string]bool)
+	var out []string
+	for _, s := range r.Canonicalize(skills) {
+		if skill, ok := r.Lookup(s); ok {
+			selected[skill.ID] = true
+		} else {
+			out = append(out, s)
+		}
+	}
+
+	related := make(map[string]bool)
+	for id := range r.skills {
+		for p := id; p != ""; p = r.skills[p].ParentID {
+			if selected[p] {
+				related[id] = true
+				break
+			}
+		}
+	}
+	for id := range selected {
+		for p := r.skills[id].ParentID; p != ""; p = r.skills[p].ParentID {
+			related[p] = true
+		}
+	}
+	for id := range related {
+		out = append(out, r.skills[id].Name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Extract finds the skills mentioned in free text by their names and
+// aliases, in the order they first appear.
+func (r *Registry) Extract(text string) []string {
+	padded := " " + strings.Join(strings.FieldsFunc(strings.ToLower(text), isSeparator), " ") + " "
+
+	type mention struct {
+		name string
+		at   int
+	}
+	var found []mention
+	seen := make(map[string]bool)
+	for _, p := range r.phrases {
+		if seen[p.id] {
+			continue
+		}
+		at := strings.Index(padded, " "+p.text+" ")
+		if at < 0 {
+			continue
+		}
+		seen[p.id] = true
+		found = append(found, mention{name: r.skills[p.id].Name, at: at})
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].at < found[j].at })
+
+	names := make([]string, 0, len(found))
+	for _, m := range found {
+		names = append(names, m.name)
+	}
+	return names
+}
+
+// isSeparator splits prose into words, keeping the '+' and '#' of names
+// such as "C++".
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '+' && r != '#'
+}
+
+var current atomic.Pointer[Registry]
+
+func init() {
+	r, err := Build(Builtin())
+	if err != nil {
+		panic("skills: invalid built-in taxonomy: " + err.Error())
+	}
+	current.Store(r)
+}
+
+// Current returns the registry in use: the stored taxonomy once loaded,
+// the built-in one until then.
+func Current() *Registry {
+	return current.Load()
+}
+
+// Use replaces the registry in use.
+func Use(r *Registry) {
+	current.Store(r)
+}
+
+// Normalize maps a free-form skill to its canonical name in the current
+// registry.
+func Normalize(skill string) (string, bool) {
+	return Current().Normalize(skill)
+}
+
+// NormalizeAll is Registry.NormalizeAll on the current registry.
+func NormalizeAll(skills []string) (normalized []string, unknown []string) {
+	return Current().NormalizeAll(skills)
+}
+
+// Canonicalize is Registry.Canonicalize on the current registry.
+func Canonicalize(skills []string) []string {
+	return Current().Canonicalize(skills)
+}
+
+// Similarity is Registry.Similarity on the current registry.
+func Similarity(required, held string) float64 {
+	return Current().Similarity(required, held)
+}
+
+// Related is Registry.Related on the current registry.
+func Related(skills []string) []string {
+	return Current().Related(skills)
+}
+
+// Extract is Registry.Extract on the current registry.
+func Extract(text string) []string {
+	return Current().Extract(text)
+}
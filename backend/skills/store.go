@@ -0,0 +1,96 @@
+package skills
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const defaultRefreshInterval = time.Minute
+
+// Collection holds the stored taxonomy, one document per skill.
+func Collection() *mongo.Collection {
+	return config.DB.Collection("skills")
+}
+
+// LoadAll reads the stored taxonomy.
+func LoadAll(ctx context.Context) ([]models.Skill, error) {
+	cursor, err := Collection().Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var list []models.Skill
+	err = cursor.All(ctx, &list)
+	return list, err
+}
+
+// Reload replaces the current registry with the stored taxonomy. An empty
+// collection keeps the current one, and an invalid taxonomy is rejected.
+func Reload(ctx context.Context) error {
+	list, err := LoadAll(ctx)
+	if err != nil || len(list) == 0 {
+		return err
+	}
+	r, err := Build(list)
+	if err != nil {
+		return err
+	}
+	Use(r)
+	return nil
+}
+
+// Seed stores the built-in taxonomy when the collection is empty, so skills
+// an admin removed are not brought back on the next start.
+func Seed(ctx context.Context) (int64, error) {
+	n, err := Collection().CountDocuments(ctx, bson.M{})
+	if err != nil || n > 0 {
+		return 0, err
+	}
+	now := time.Now()
+	docs := make([]interface{}, 0, len(Builtin()))
+	for _, s := range Builtin() {
+		s.CreatedAt, s.UpdatedAt = now, now
+		docs = append(docs, s)
+	}
+	result, err := Collection().InsertMany(ctx, docs)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(result.InsertedIDs)), nil
+}
+
+// Init loads the stored taxonomy and keeps reloading it every
+// SKILLS_REFRESH_INTERVAL (default 1m), so admin changes made through
+// another replica are picked up.
+func Init() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	if err := Reload(ctx); err != nil {
+		log.Printf("skills: using the built-in taxonomy: %v", err)
+	}
+	cancel()
+
+	interval := defaultRefreshInterval
+	if v := os.Getenv("SKILLS_REFRESH_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			interval = d
+		} else {
+			log.Printf("skills: invalid SKILLS_REFRESH_INTERVAL %q, using %s", v, interval)
+		}
+	}
+
+	go func() {
+		for range time.Tick(interval) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			if err := Reload(ctx); err != nil {
+				log.Printf("skills: reload failed: %v", err)
+			}
+			cancel()
+		}
+	}()
+}
@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Aashishvatwani/homeworld/config"
+)
+
+var (
+	ErrNotFound     = errors.New("object not found")
+	ErrInvalidKey   = errors.New("invalid object key")
+	ErrBadSignature = errors.New("download link is invalid or has expired")
+)
+
+// PutOptions describes an object being stored. SHA256 is the raw digest of
+// the body; backends that support it use it to verify the upload.
+type PutOptions struct {
+	ContentType string
+	SHA256      []byte
+}
+
+// Download sets how a signed URL presents the object to the browser.
+type Download struct {
+	Filename    string
+	ContentType string
+}
+
+// Store keeps attachment bodies. Keys are slash-separated paths such as
+// "assignments/<id>/<attachmentId>". Implementations must be safe for
+// concurrent use.
+type Store interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, opts PutOptions) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL that downloads the object without further
+	// authentication until ttl has passed.
+	SignedURL(ctx context.Context, key string, dl Download, ttl time.Duration) (string, error)
+}
+
+var store Store
+
+// Init selects the backend from STORAGE_BACKEND:
+//   - "s3": S3_ENDPOINT, S3_REGION, S3_BUCKET, S3_ACCESS_KEY_ID,
+//     S3_SECRET_ACCESS_KEY, S3_PATH_STYLE (set "true" for MinIO) and
+//     optionally S3_PUBLIC_ENDPOINT for download links
+//   - anything else: files under STORAGE_DIR (default ./tmp/uploads), served
+//     through /api/files with links signed by STORAGE_SIGNING_KEY
+func Init() {
+	switch strings.ToLower(os.Getenv("STORAGE_BACKEND")) {
+	case "s3":
+		s, err := NewS3StoreFromEnv()
+		if err != nil {
+			log.Fatal("Invalid S3 storage configuration: ", err)
+		}
+		store = s
+		log.Println("File storage: s3 (" + s.Bucket + ")")
+	default:
+		dir := os.Getenv("STORAGE_DIR")
+		if dir == "" {
+			dir = "tmp/uploads"
+		}
+		store = NewLocalStore(dir, os.Getenv("API_BASE_URL"), signingKey())
+		log.Println("File storage: local (" + dir + ")")
+	}
+}
+
+// signingKey is STORAGE_SIGNING_KEY, or a key derived from the JWT secret so
+// local development works without extra configuration.
+func signingKey() []byte {
+	if v := os.Getenv("STORAGE_SIGNING_KEY"); v != "" {
+		return []byte(v)
+	}
+	mac := hmac.New(sha256.New, config.JWTSecret)
+	mac.Write([]byte("homeworld attachment downloads"))
+	return mac.Sum(nil)
+}
+
+func Put(ctx context.Context, key string, body io.Reader, size int64, opts PutOptions) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	return store.Put(ctx, key, body, size, opts)
+}
+
+func Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+	return store.Open(ctx, key)
+}
+
+func Delete(ctx context.Context, key string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	return store.Delete(ctx, key)
+}
+
+func SignedURL(ctx context.Context, key string, dl Download, ttl time.Duration) (string, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", err
+	}
+	return store.SignedURL(ctx, key, dl, ttl)
+}
+
+// VerifyDownload checks a link issued by the local backend and returns how
+// the object should be served. Links are only served by the local backend.
+func VerifyDownload(key string, query url.Values) (Download, error) {
+	local, ok := store.(*LocalStore)
+	if !ok {
+		return Download{}, ErrNotFound
+	}
+	if err := ValidateKey(key); err != nil {
+		return Download{}, err
+	}
+	return local.Verify(key, query, time.Now())
+}
+
+// ValidateKey rejects keys that are empty, absolute or escape their prefix.
+func ValidateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || path.Clean(key) != key || strings.HasPrefix(key, "..") {
+		return ErrInvalidKey
+	}
+	return nil
+}
@@ -1,17 +0,0 @@
-package utils
-
-import "math"
-
-// Haversine formula to calculate distance between two points (in km)
-func CalculateDistance(lat1, lon1, lat2, lon2 float64) float64 {
-	const R = 6371 // Earth radius in KM
-	dLat := (lat2 - lat1) * math.Pi / 180.0
-	dLon := (lon2 - lon1) * math.Pi / 180.0
-
-	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
-		math.Cos(lat1*math.Pi/180.0)*math.Cos(lat2*math.Pi/180.0)*
-			math.Sin(dLon/2)*math.Sin(dLon/2)
-	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
-
-	return R * c
-}
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Encrypted fields are stored as "enc:v1:<keyID>:<base64(nonce||ciphertext)>".
+// Values without the prefix are legacy plaintext and are returned unchanged.
+const encryptedFieldPrefix = "enc:v1:"
+
+var ErrUnknownEncryptionKey = errors.New("field was encrypted with an unknown key")
+
+// EncryptField seals plaintext with the active key using AES-256-GCM. The
+// associated data binds the ciphertext to its owner and field so it cannot
+// be copied onto another record. Empty values stay empty.
+func EncryptField(plaintext, associatedData string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	keyID := config.FieldEncryptionKeyID
+	gcm, err := fieldCipher(keyID)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), []byte(associatedData))
+	return encryptedFieldPrefix + keyID + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptField opens a value produced by EncryptField with any configured key.
+func DecryptField(value, associatedData string) (string, error) {
+	if !IsEncryptedField(value) {
+		return value, nil
+	}
+	keyID, encoded, ok := strings.Cut(strings.TrimPrefix(value, encryptedFieldPrefix), ":")
+	if !ok {
+		return "", fmt.Errorf("malformed encrypted field")
+	}
+	gcm, err := fieldCipher(keyID)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("malformed encrypted field")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, []byte(associatedData))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt field: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// IsEncryptedField reports whether value was produced by EncryptField.
+func IsEncryptedField(value string) bool {
+	return strings.HasPrefix(value, encryptedFieldPrefix)
+}
+
+// FieldNeedsReencryption reports whether value is plaintext or was sealed
+// with a key other than the active one.
+func FieldNeedsReencryption(value string) bool {
+	if value == "" {
+		return false
+	}
+	return !strings.HasPrefix(value, encryptedFieldPrefix+config.FieldEncryptionKeyID+":")
+}
+
+func fieldCipher(keyID string) (cipher.AEAD, error) {
+	key, ok := config.FieldEncryptionKeys[keyID]
+	if !ok {
+		return nil, ErrUnknownEncryptionKey
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptPayout encrypts the account number, IFSC and UPI ID of a user's
+// payout details. Holder and bank names are stored as given.
+func EncryptPayout(userID primitive.ObjectID, p models.PayoutDetails) (models.PayoutDetails, error) {
+	var err error
+	if p.AccountNumber, err = EncryptField(p.AccountNumber, payoutAAD(userID, "accountNumber")); err != nil {
+		return p, err
+	}
+	if p.IFSC, err = EncryptField(p.IFSC, payoutAAD(userID, "ifsc")); err != nil {
+		return p, err
+	}
+	if p.UPI, err = EncryptField(p.UPI, payoutAAD(userID, "upi")); err != nil {
+		return p, err
+	}
+	return p, nil
+}
+
+// DecryptPayout reverses EncryptPayout.
+func DecryptPayout(userID primitive.ObjectID, p models.PayoutDetails) (models.PayoutDetails, error) {
+	var err error
+	if p.AccountNumber, err = DecryptField(p.AccountNumber, payoutAAD(userID, "accountNumber")); err != nil {
+		return p, err
+	}
+	if p.IFSC, err = DecryptField(p.IFSC, payoutAAD(userID, "ifsc")); err != nil {
+		return p, err
+	}
+	if p.UPI, err = DecryptField(p.UPI, payoutAAD(userID, "upi")); err != nil {
+		return p, err
+	}
+	return p, nil
+}
+
+// PayoutNeedsReencryption reports whether any encrypted payout field is
+// plaintext or sealed with a retired key.
+func PayoutNeedsReencryption(p models.PayoutDetails) bool {
+	return FieldNeedsReencryption(p.AccountNumber) || FieldNeedsReencryption(p.IFSC) || FieldNeedsReencryption(p.UPI)
+}
+
+func payoutAAD(userID primitive.ObjectID, field string) string {
+	return "users/" + userID.Hex() + "/payout." + field
+}
@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Aashishvatwani/homeworld/config"
+	"github.com/Aashishvatwani/homeworld/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// useKeys installs field encryption keys for the test, with active as the
+// key new values are sealed with.
+func useKeys(t *testing.T, active string, keys map[string][]byte) {
+	t.Helper()
+	prevKeys, prevID := config.FieldEncryptionKeys, config.FieldEncryptionKeyID
+	config.FieldEncryptionKeys, config.FieldEncryptionKeyID = keys, active
+	t.Cleanup(func() {
+		config.FieldEncryptionKeys, config.FieldEncryptionKeyID = prevKeys, prevID
+	})
+}
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func TestEncryptFieldRoundTrip(t *testing.T) {
+	useKeys(t, "k1", map[string][]byte{"k1": testKey(1)})
+
+	sealed, err := EncryptField("123456789012", "users/1/payout.accountNumber")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(sealed, encryptedFieldPrefix+"k1:") || strings.Contains(sealed, "123456789012") {
+		t.Fatalf("EncryptField = %q, want an opaque value tagged with k1", sealed)
+	}
+	again, _ := EncryptField("123456789012", "users/1/payout.accountNumber")
+	if again == sealed {
+		t.Fatal("two encryptions of the same value are identical; nonce is not random")
+	}
+
+	got, err := DecryptField(sealed, "users/1/payout.accountNumber")
+	if err != nil || got != "123456789012" {
+		t.Fatalf("DecryptField = %q, %v; want the plaintext", got, err)
+	}
+
+	if sealed, _ := EncryptField("", "aad"); sealed != "" {
+		t.Fatalf("EncryptField of an empty value = %q, want empty", sealed)
+	}
+	if got, err := DecryptField("legacy@upi", "aad"); err != nil || got != "legacy@upi" {
+		t.Fatalf("DecryptField of legacy plaintext = %q, %v; want it unchanged", got, err)
+	}
+}
+
+func TestDecryptFieldDetectsTampering(t *testing.T) {
+	useKeys(t, "k1", map[string][]byte{"k1": testKey(1)})
+	const aad = "users/1/payout.upi"
+
+	sealed, err := EncryptField("solver@upi", aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefix := encryptedFieldPrefix + "k1:"
+	raw, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(sealed, prefix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw[len(raw)-1] ^= 0x01
+	tampered := prefix + base64.RawStdEncoding.EncodeToString(raw)
+
+	if got, err := DecryptField(tampered, aad); err == nil {
+		t.Fatalf("DecryptField of a flipped bit = %q, want an error", got)
+	}
+	// Copying the value onto another user's record must not decrypt
+	if got, err := DecryptField(sealed, "users/2/payout.upi"); err == nil {
+		t.Fatalf("DecryptField with another record's AAD = %q, want an error", got)
+	}
+	for _, malformed := range []string{encryptedFieldPrefix + "k1", prefix + "!!!", prefix + "AAAA"} {
+		if _, err := DecryptField(malformed, aad); err == nil {
+			t.Errorf("DecryptField(%q) succeeded, want an error", malformed)
+		}
+	}
+}
+
+func TestDecryptFieldWithWrongKey(t *testing.T) {
+	useKeys(t, "k1", map[string][]byte{"k1": testKey(1)})
+	sealed, err := EncryptField("HDFC0001234", "aad")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Same key ID, different key material
+	config.FieldEncryptionKeys = map[string][]byte{"k1": testKey(9)}
+	if got, err := DecryptField(sealed, "aad"); err == nil {
+		t.Fatalf("DecryptField with the wrong key = %q, want an error", got)
+	}
+
+	config.FieldEncryptionKeys = map[string][]byte{"k2": testKey(2)}
+	if _, err := DecryptField(sealed, "aad"); !errors.Is(err, ErrUnknownEncryptionKey) {
+		t.Fatalf("DecryptField with the key missing: err = %v, want ErrUnknownEncryptionKey", err)
+	}
+}
+
+func TestPayoutKeyRotation(t *testing.T) {
+	userID := primitive.NewObjectID()
+	payout := models.PayoutDetails{AccountHolderName: "Solver", AccountNumber: "123456789012", IFSC: "HDFC0001234", UPI: "solver@upi"}
+
+	useKeys(t, "k1", map[string][]byte{"k1": testKey(1)})
+	old, err := EncryptPayout(userID, payout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if old.AccountHolderName != "Solver" {
+		t.Fatalf("holder name = %q, want it stored as given", old.AccountHolderName)
+	}
+	if PayoutNeedsReencryption(old) {
+		t.Fatal("payout sealed with the active key needs re-encryption")
+	}
+	if !PayoutNeedsReencryption(payout) {
+		t.Fatal("plaintext payout does not need encryption")
+	}
+
+	// Rotate: k2 becomes active and k1 stays loaded for existing values
+	config.FieldEncryptionKeys = map[string][]byte{"k1": testKey(1), "k2": testKey(2)}
+	config.FieldEncryptionKeyID = "k2"
+	if !PayoutNeedsReencryption(old) {
+		t.Fatal("payout sealed with a retired key does not need re-encryption")
+	}
+	plain, err := DecryptPayout(userID, old)
+	if err != nil || plain != payout {
+		t.Fatalf("DecryptPayout after rotation = %+v, %v; want %+v", plain, err, payout)
+	}
+	rotated, err := EncryptPayout(userID, plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if PayoutNeedsReencryption(rotated) || !strings.HasPrefix(rotated.UPI, encryptedFieldPrefix+"k2:") {
+		t.Fatalf("re-encrypted UPI = %q, want it sealed with k2", rotated.UPI)
+	}
+
+	// Once k1 is retired only the re-encrypted values can be read
+	config.FieldEncryptionKeys = map[string][]byte{"k2": testKey(2)}
+	if plain, err := DecryptPayout(userID, rotated); err != nil || plain != payout {
+		t.Fatalf("DecryptPayout = %+v, %v; want %+v", plain, err, payout)
+	}
+	if _, err := DecryptPayout(userID, old); !errors.Is(err, ErrUnknownEncryptionKey) {
+		t.Fatalf("DecryptPayout of a k1 value: err = %v, want ErrUnknownEncryptionKey", err)
+	}
+	// Fields are bound to their user
+	if _, err := DecryptPayout(primitive.NewObjectID(), rotated); err == nil {
+		t.Fatal("another user's payout decrypted")
+	}
+}
@@ -1,28 +1,63 @@
 package utils
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/Aashishvatwani/homeworld/config"
 	"github.com/dgrijalva/jwt-go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-var jwtKey = []byte("secret_key") // move to env later
-
 type JWTClaim struct {
-	Email string
-	Role  string
+	UserID    string
+	Email     string
+	Role      string
+	SessionID string
 	jwt.StandardClaims
 }
 
-func GenerateJWT(email, role string) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour)
+// GenerateJWT issues a short-lived access token bound to a session so that
+// revoking the session also invalidates the token.
+func GenerateJWT(userID primitive.ObjectID, email, role string, sessionID primitive.ObjectID) (string, error) {
+	expirationTime := time.Now().Add(config.AccessTokenTTL)
 	claims := &JWTClaim{
-		Email: email,
-		Role:  role,
+		UserID:    userID.Hex(),
+		Email:     email,
+		Role:      role,
+		SessionID: sessionID.Hex(),
 		StandardClaims: jwt.StandardClaims{
+			Subject:   userID.Hex(),
+			IssuedAt:  time.Now().Unix(),
 			ExpiresAt: expirationTime.Unix(),
 		},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtKey)
+	return token.SignedString(config.JWTSecret)
+}
+
+// ValidateJWT parses a signed token and returns its claims if the signature
+// and expiry are valid.
+func ValidateJWT(signedToken string) (*JWTClaim, error) {
+	token, err := jwt.ParseWithClaims(signedToken, &JWTClaim{}, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return config.JWTSecret, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*JWTClaim)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+	if _, err := primitive.ObjectIDFromHex(claims.UserID); err != nil {
+		return nil, fmt.Errorf("token is missing a valid user id")
+	}
+	if _, err := primitive.ObjectIDFromHex(claims.SessionID); err != nil {
+		return nil, fmt.Errorf("token is missing a valid session id")
+	}
+	return claims, nil
 }
@@ -7,8 +7,9 @@ import (
 	"io"
 	"net/http"
 	"os"
-	"strings"
 	"time"
+
+	"github.com/Aashishvatwani/homeworld/skills"
 )
 
 // NLPParseRequest is the request body for the NLP service
@@ -104,33 +105,14 @@ func CallNLPService(text string, userID string) (*NLPParseResponse, error) {
 	if err := json.Unmarshal(body, &nlpResponse); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
+	// Map the service's labels onto the taxonomy
+	nlpResponse.SkillsRequired = skills.Canonicalize(nlpResponse.SkillsRequired)
 
 	return &nlpResponse, nil
 }
 
-// simpleKeywordExtraction is a fallback function for basic keyword extraction
+// simpleKeywordExtraction is the fallback when the NLP service is
+// unavailable: the taxonomy skills named in the text
 func simpleKeywordExtraction(text string) []string {
-	keywords := []string{"python", "ml", "ai", "blockchain", "database", "golang", "react", "cloud", "aws", "docker",
-		"machine learning", "deep learning", "data science", "computer vision", "nlp", "natural language processing"}
-	found := []string{}
-
-	lower := strings.ToLower(text)
-	for _, kw := range keywords {
-		if strings.Contains(lower, kw) {
-			// Avoid duplicates
-			if !stringInSlice(kw, found) {
-				found = append(found, kw)
-			}
-		}
-	}
-	return found
-}
-
-func stringInSlice(a string, list []string) bool {
-	for _, b := range list {
-		if b == a {
-			return true
-		}
-	}
-	return false
+	return skills.Extract(text)
 }
@@ -14,7 +14,8 @@ func CreateRazorpayPayout(amount float64, currency string, payoutInfo map[string
 	// This mock simply returns a generated payout id.
 	if os.Getenv("RAZORPAY_PAYOUT_ENABLED") != "true" {
 		mockID := fmt.Sprintf("payout_mock_%d", time.Now().Unix())
-		fmt.Printf("[razorpay_payout] Mock payout created: %s (amount=%.2f %s) beneficiary=%v\n", mockID, amount, currency, payoutInfo)
+		// Log only the holder name; account details must not reach the logs
+		fmt.Printf("[razorpay_payout] Mock payout created: %s (amount=%.2f %s) beneficiary=%s\n", mockID, amount, currency, payoutInfo["accountHolderName"])
 		return mockID, nil
 	}
 
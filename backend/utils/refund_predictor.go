@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// RefundPredictionRequest is the request body for POST /predict_refund
+type RefundPredictionRequest struct {
+	Price      float64 `json:"price"`
+	ReasonText string  `json:"reason_text"`
+	DelayDays  int     `json:"delay_days"`
+	Rating     *int    `json:"rating,omitempty"`
+}
+
+// RefundPrediction is the refund service's suggested split of Price.
+// RefundPercent applies to the price net of the platform fee.
+type RefundPrediction struct {
+	RefundPercent  float64 `json:"refund_percent"`
+	PlatformFee    float64 `json:"platform_fee"`
+	CustomerRefund float64 `json:"customer_refund"`
+	SolverAmount   float64 `json:"solver_amount"`
+}
+
+// PredictRefund asks the refund service how much of a cancelled
+// assignment's price should go back to the buyer.
+func PredictRefund(reqBody RefundPredictionRequest) (*RefundPrediction, error) {
+	serviceURL := os.Getenv("REFUND_SERVICE_URL")
+	if serviceURL == "" {
+		serviceURL = os.Getenv("NLP_SERVICE_URL")
+	}
+	if serviceURL == "" {
+		serviceURL = "http://localhost:8000"
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequest("POST", serviceURL+"/predict_refund", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// Callers fall back to fixed rules, so do not keep the buyer waiting long
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call refund service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("refund service returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var prediction RefundPrediction
+	if err := json.Unmarshal(body, &prediction); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if prediction.RefundPercent < 0 || prediction.RefundPercent > 100 {
+		return nil, fmt.Errorf("refund service returned refund_percent %.2f", prediction.RefundPercent)
+	}
+	return &prediction, nil
+}
@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	ifscPattern          = regexp.MustCompile(`^[A-Z]{4}0[A-Z0-9]{6}$`)
+	upiPattern           = regexp.MustCompile(`^[a-zA-Z0-9.\-_]{2,256}@[a-zA-Z][a-zA-Z0-9]{1,63}$`)
+	accountNumberPattern = regexp.MustCompile(`^[0-9]{9,18}$`)
+)
+
+// ValidateIFSC checks an Indian Financial System Code, e.g. "HDFC0001234".
+func ValidateIFSC(ifsc string) bool {
+	return ifscPattern.MatchString(strings.ToUpper(strings.TrimSpace(ifsc)))
+}
+
+// ValidateUPI checks a UPI virtual payment address, e.g. "name@okbank".
+func ValidateUPI(upi string) bool {
+	return upiPattern.MatchString(strings.TrimSpace(upi))
+}
+
+// ValidateAccountNumber checks an Indian bank account number (9-18 digits).
+func ValidateAccountNumber(number string) bool {
+	return accountNumberPattern.MatchString(strings.TrimSpace(number))
+}
+
+// ValidateCoordinates checks that lat/lng are within valid ranges.
+func ValidateCoordinates(lat, lng float64) bool {
+	return lat >= -90 && lat <= 90 && lng >= -180 && lng <= 180
+}